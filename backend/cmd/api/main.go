@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -9,11 +11,15 @@ import (
 	"syscall"
 	"time"
 
+	"job-queue-llm-orchestrator/backend/internal/breaker"
 	"job-queue-llm-orchestrator/backend/internal/config"
 	"job-queue-llm-orchestrator/backend/internal/httpapi"
 	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/jwtauth"
+	"job-queue-llm-orchestrator/backend/internal/migrate"
 	"job-queue-llm-orchestrator/backend/internal/queue"
 	"job-queue-llm-orchestrator/backend/internal/store"
+	"job-queue-llm-orchestrator/backend/internal/telemetry"
 )
 
 func main() {
@@ -26,6 +32,25 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	shutdownTracing, err := telemetry.SetupTracing(ctx, telemetry.TracingConfig{
+		ServiceName:  "job-queue-api",
+		Exporter:     cfg.TraceExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		FilePath:     cfg.TraceFilePath,
+		SampleRatio:  cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		logger.Error("failed to init tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("tracing shutdown failed", "error", err)
+		}
+	}()
+
 	postgresStore, err := store.NewPostgresStore(ctx, cfg.DatabaseURL)
 	if err != nil {
 		logger.Error("failed to init postgres store", "error", err)
@@ -33,14 +58,28 @@ func main() {
 	}
 	defer postgresStore.Close()
 
-	redisQueue := queue.NewRedisQueue(
-		cfg.RedisAddr,
-		cfg.RedisPassword,
-		cfg.RedisDB,
-		cfg.ReadyQueueKey,
-		cfg.LeaseTTL,
-	)
-	defer redisQueue.Close() //nolint:errcheck
+	if cfg.SchemaCheck {
+		if err := migrate.CheckEmbedded(ctx, postgresStore); err != nil {
+			logger.Error("schema check failed", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	jobQueue, err := queue.New(ctx, cfg)
+	if err != nil {
+		logger.Error("failed to init job queue", "backend", cfg.QueueBackend, "error", err)
+		os.Exit(1)
+	}
+	defer jobQueue.Close() //nolint:errcheck
+
+	breakers, err := breaker.FromConfig(cfg)
+	if err != nil {
+		logger.Error("failed to init circuit breakers", "backend", cfg.BreakerBackend, "error", err)
+		os.Exit(1)
+	}
+	if breakers != nil {
+		defer breakers.Close() //nolint:errcheck
+	}
 
 	healthCtx, cancel := context.WithTimeout(ctx, 4*time.Second)
 	if err := postgresStore.Ping(healthCtx); err != nil {
@@ -48,15 +87,31 @@ func main() {
 		logger.Error("postgres ping failed", "error", err)
 		os.Exit(1)
 	}
-	if err := redisQueue.Ping(healthCtx); err != nil {
+	if err := jobQueue.Ping(healthCtx); err != nil {
 		cancel()
-		logger.Error("redis ping failed", "error", err)
+		logger.Error("queue ping failed", "backend", cfg.QueueBackend, "error", err)
 		os.Exit(1)
 	}
 	cancel()
 
-	jobService := jobs.NewService(postgresStore, redisQueue, logger)
-	apiServer := httpapi.NewServer(jobService)
+	jobService := jobs.NewService(postgresStore, jobQueue, breakers, cfg.IdempotencyTTL, logger)
+	tokens, err := newTokenVerifier(cfg)
+	if err != nil {
+		logger.Error("failed to init jwt authentication", "error", err)
+		os.Exit(1)
+	}
+	if cfg.AuthRequired && cfg.AdminAPIKey == "" && tokens == nil {
+		logger.Warn("neither ADMIN_API_KEY nor a JWKS is configured; only existing API keys can authenticate")
+	}
+	if !cfg.AuthRequired {
+		logger.Warn("authentication is disabled; any caller can act for any tenant")
+	}
+	apiServer := httpapi.NewServer(jobService, cfg.BatchMaxJobs, httpapi.AuthConfig{
+		Required:   cfg.AuthRequired,
+		AdminKey:   cfg.AdminAPIKey,
+		Tokens:     tokens,
+		TrustProxy: cfg.TrustProxy,
+	})
 
 	server := &http.Server{
 		Addr:              cfg.HTTPAddr,
@@ -80,3 +135,36 @@ func main() {
 		logger.Error("api shutdown failed", "error", err)
 	}
 }
+
+// newTokenVerifier returns the JWT verifier configured by JWT_JWKS_URL or
+// JWT_JWKS_FILE, or nil when neither is set.
+func newTokenVerifier(cfg config.Config) (*jwtauth.Verifier, error) {
+	var keys *jwtauth.KeySet
+	switch {
+	case cfg.JWKSURL != "" && cfg.JWKSFile != "":
+		return nil, errors.New("set only one of JWT_JWKS_URL and JWT_JWKS_FILE")
+	case cfg.JWKSURL != "":
+		keys = jwtauth.NewRemoteKeySet(cfg.JWKSURL, cfg.JWKSRefresh)
+	case cfg.JWKSFile != "":
+		keys = jwtauth.NewFileKeySet(cfg.JWKSFile, cfg.JWKSRefresh)
+	default:
+		return nil, nil
+	}
+	if cfg.JWTIssuer == "" {
+		return nil, errors.New("JWT_ISSUER is required with a JWKS")
+	}
+	if cfg.JWTAudience == "" {
+		return nil, errors.New("JWT_AUDIENCE is required with a JWKS")
+	}
+	roles, err := jwtauth.ParseRoleMap(cfg.JWTRoleMap)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_ROLE_MAP: %w", err)
+	}
+	return jwtauth.NewVerifier(jwtauth.Config{
+		Issuer:      cfg.JWTIssuer,
+		Audience:    cfg.JWTAudience,
+		TenantClaim: cfg.JWTTenantClaim,
+		RoleClaim:   cfg.JWTRoleClaim,
+		RoleMap:     roles,
+	}, keys), nil
+}
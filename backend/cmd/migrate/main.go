@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/config"
+	"job-queue-llm-orchestrator/backend/internal/migrate"
+	"job-queue-llm-orchestrator/backend/migrations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const usage = `usage: migrate <command> [flags]
+
+commands:
+  up [-to N]       apply pending migrations, up to version N if given
+  down [-steps N]  revert the N most recent migrations (default 1)
+  status           list migrations and whether they are applied
+  baseline -to N   record migrations up to version N as applied without
+                   running them, for databases migrated by hand
+`
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	cfg := config.Load()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	all, err := migrate.Load(migrations.Files)
+	if err != nil {
+		logger.Error("failed to load migrations", "error", err)
+		os.Exit(1)
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logger.Error("failed to init postgres pool", "error", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	migrator := migrate.NewMigrator(pool, all)
+
+	switch command {
+	case "up":
+		flags := flag.NewFlagSet("up", flag.ExitOnError)
+		target := flags.Int("to", 0, "stop after this version (default: latest)")
+		_ = flags.Parse(args)
+
+		ran, err := migrator.Up(ctx, *target)
+		for _, migration := range ran {
+			logger.Info("applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			logger.Error("migrate up failed", "error", err)
+			os.Exit(1)
+		}
+		if len(ran) == 0 {
+			logger.Info("schema is up to date")
+		}
+	case "down":
+		flags := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "number of migrations to revert")
+		_ = flags.Parse(args)
+
+		ran, err := migrator.Down(ctx, *steps)
+		for _, migration := range ran {
+			logger.Info("reverted migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			logger.Error("migrate down failed", "error", err)
+			os.Exit(1)
+		}
+	case "baseline":
+		flags := flag.NewFlagSet("baseline", flag.ExitOnError)
+		target := flags.Int("to", 0, "last version already present in the schema (required)")
+		_ = flags.Parse(args)
+
+		recorded, err := migrator.Baseline(ctx, *target)
+		for _, migration := range recorded {
+			logger.Info("recorded migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			logger.Error("migrate baseline failed", "error", err)
+			os.Exit(1)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			logger.Error("migrate status failed", "error", err)
+			os.Exit(1)
+		}
+		printStatus(statuses)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func printStatus(statuses []migrate.Status) {
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "VERSION\tNAME\tAPPLIED AT\tNOTE")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		note := ""
+		switch {
+		case status.Missing:
+			note = "no file in this binary"
+		case status.Modified:
+			note = "file changed since applied"
+		}
+		fmt.Fprintf(out, "%03d\t%s\t%s\t%s\n", status.Version, status.Name, appliedAt, note)
+	}
+	_ = out.Flush()
+}
@@ -6,10 +6,20 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"job-queue-llm-orchestrator/backend/internal/breaker"
 	"job-queue-llm-orchestrator/backend/internal/config"
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/migrate"
+	"job-queue-llm-orchestrator/backend/internal/outbox"
 	"job-queue-llm-orchestrator/backend/internal/queue"
+	"job-queue-llm-orchestrator/backend/internal/retention"
+	"job-queue-llm-orchestrator/backend/internal/scheduler"
 	"job-queue-llm-orchestrator/backend/internal/store"
+	"job-queue-llm-orchestrator/backend/internal/telemetry"
+	"job-queue-llm-orchestrator/backend/internal/usage"
+	"job-queue-llm-orchestrator/backend/internal/webhooks"
 	"job-queue-llm-orchestrator/backend/internal/worker"
 )
 
@@ -23,6 +33,25 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	shutdownTracing, err := telemetry.SetupTracing(ctx, telemetry.TracingConfig{
+		ServiceName:  "job-queue-worker",
+		Exporter:     cfg.TraceExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		FilePath:     cfg.TraceFilePath,
+		SampleRatio:  cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		logger.Error("failed to init tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("tracing shutdown failed", "error", err)
+		}
+	}()
+
 	postgresStore, err := store.NewPostgresStore(ctx, cfg.DatabaseURL)
 	if err != nil {
 		logger.Error("failed to init postgres store", "error", err)
@@ -30,16 +59,82 @@ func main() {
 	}
 	defer postgresStore.Close()
 
-	redisQueue := queue.NewRedisQueue(
-		cfg.RedisAddr,
-		cfg.RedisPassword,
-		cfg.RedisDB,
-		cfg.ReadyQueueKey,
-		cfg.LeaseTTL,
-	)
-	defer redisQueue.Close() //nolint:errcheck
+	if cfg.SchemaCheck {
+		if err := migrate.CheckEmbedded(ctx, postgresStore); err != nil {
+			logger.Error("schema check failed", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	jobQueue, err := queue.New(ctx, cfg)
+	if err != nil {
+		logger.Error("failed to init job queue", "backend", cfg.QueueBackend, "error", err)
+		os.Exit(1)
+	}
+	defer jobQueue.Close() //nolint:errcheck
+
+	breakers, err := breaker.FromConfig(cfg)
+	if err != nil {
+		logger.Error("failed to init circuit breakers", "backend", cfg.BreakerBackend, "error", err)
+		os.Exit(1)
+	}
+	if breakers != nil {
+		defer breakers.Close() //nolint:errcheck
+	}
+
+	if cfg.WebhookSecret == "" {
+		logger.Warn("WEBHOOK_SIGNING_SECRET is not set; group webhooks are sent unsigned")
+	}
+	dispatcher := webhooks.NewDispatcher(postgresStore, cfg, logger)
+	go func() {
+		if err := dispatcher.Run(ctx); err != nil {
+			logger.Error("webhook dispatcher exited with error", "error", err)
+		}
+	}()
+
+	relay := outbox.NewRelay(postgresStore, jobQueue, cfg.OutboxPoll, logger)
+	go func() {
+		if err := relay.Run(ctx); err != nil {
+			logger.Error("queue outbox relay exited with error", "error", err)
+		}
+	}()
+
+	promoter := scheduler.NewPromoter(postgresStore, cfg.SchedulerPoll, logger)
+	go func() {
+		if err := promoter.Run(ctx); err != nil {
+			logger.Error("scheduled job promoter exited with error", "error", err)
+		}
+	}()
+
+	cronRunner := scheduler.NewCronRunner(postgresStore, jobs.NewService(postgresStore, jobQueue, breakers, cfg.IdempotencyTTL, logger), cfg.CronPoll, logger)
+	go func() {
+		if err := cronRunner.Run(ctx); err != nil {
+			logger.Error("cron scheduler exited with error", "error", err)
+		}
+	}()
+
+	sweeper := scheduler.NewSweeper(postgresStore, cfg.ExpirySweep, logger)
+	go func() {
+		if err := sweeper.Run(ctx); err != nil {
+			logger.Error("deadline sweeper exited with error", "error", err)
+		}
+	}()
+
+	archiver := retention.NewArchiver(postgresStore, cfg, logger)
+	go func() {
+		if err := archiver.Run(ctx); err != nil {
+			logger.Error("retention archiver exited with error", "error", err)
+		}
+	}()
+
+	rollup := usage.NewRollup(postgresStore, cfg.UsageRollupPoll, logger)
+	go func() {
+		if err := rollup.Run(ctx); err != nil {
+			logger.Error("usage rollup exited with error", "error", err)
+		}
+	}()
 
-	runner := worker.NewRunner(postgresStore, redisQueue, cfg, logger)
+	runner := worker.NewRunner(postgresStore, jobQueue, breakers, cfg, logger)
 	logger.Info("worker started", "worker_id", cfg.WorkerID)
 
 	if err := runner.Run(ctx); err != nil {
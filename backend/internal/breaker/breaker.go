@@ -0,0 +1,251 @@
+// Package breaker keeps a circuit breaker per provider and model, shared by
+// every worker, so a failing provider is left alone for a while instead of
+// every worker spending attempts on it.
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/config"
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendOff    = "off"
+)
+
+// FromConfig builds the breakers selected by cfg.BreakerBackend. It returns
+// nil when breakers are turned off.
+func FromConfig(cfg config.Config) (*Breakers, error) {
+	var backend Backend
+	switch cfg.BreakerBackend {
+	case BackendRedis:
+		backend = NewRedisBackend(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, "breaker:")
+	case BackendMemory:
+		backend = NewMemoryBackend()
+	case BackendOff:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown circuit breaker backend %q", cfg.BreakerBackend)
+	}
+	return New(backend, Config{
+		Window:      cfg.BreakerWindow,
+		MinRequests: cfg.BreakerMinCalls,
+		ErrorRate:   cfg.BreakerErrorRate,
+		OpenFor:     cfg.BreakerOpenFor,
+		Probes:      cfg.BreakerProbes,
+	}), nil
+}
+
+// Config holds the thresholds shared by every breaker.
+type Config struct {
+	// Window is how long calls count towards the error rate of a closed
+	// breaker before the counts start over.
+	Window time.Duration
+	// MinRequests is the number of calls in a window below which the breaker
+	// never opens, so a single early failure does not trip it.
+	MinRequests int
+	// ErrorRate is the share of failed calls, from 0 to 1, that opens the
+	// breaker.
+	ErrorRate float64
+	// OpenFor is how long an open breaker rejects calls before letting
+	// probes through.
+	OpenFor time.Duration
+	// Probes is how many calls a half-open breaker lets through at once.
+	Probes int
+}
+
+// Backend stores breaker state where every worker sees it.
+type Backend interface {
+	// Update applies fn to the current state of the provider/model breaker,
+	// a zero CircuitBreaker when there is none, and stores the result. Two
+	// updates of the same breaker never interleave.
+	Update(ctx context.Context, provider string, model string, fn func(*models.CircuitBreaker)) (models.CircuitBreaker, error)
+	// List returns every breaker ordered by provider and model.
+	List(ctx context.Context) ([]models.CircuitBreaker, error)
+	Close() error
+}
+
+// Result is the outcome of asking a breaker for a call or reporting one.
+type Result struct {
+	Breaker models.CircuitBreaker
+	// Allowed reports whether the call may go ahead. It is only set by Allow.
+	Allowed bool
+	// Changed reports whether this call moved the breaker to Breaker.State.
+	Changed bool
+}
+
+// Breakers applies Config to the breakers kept in a Backend.
+type Breakers struct {
+	backend Backend
+	cfg     Config
+	now     func() time.Time
+}
+
+func New(backend Backend, cfg Config) *Breakers {
+	if cfg.Probes < 1 {
+		cfg.Probes = 1
+	}
+	return &Breakers{backend: backend, cfg: cfg, now: time.Now}
+}
+
+// Allow asks the provider/model breaker whether a call may go ahead. A closed
+// breaker always allows it. An open breaker rejects it until RetryAt, then
+// turns half-open and lets up to Config.Probes calls through; their results
+// close or reopen it.
+func (b *Breakers) Allow(ctx context.Context, provider string, model string) (Result, error) {
+	var result Result
+	breaker, err := b.backend.Update(ctx, provider, model, func(breaker *models.CircuitBreaker) {
+		now := b.now()
+		b.init(breaker, provider, model, now)
+		result = Result{}
+
+		switch breaker.State {
+		case models.BreakerClosed:
+			result.Allowed = true
+			return
+		case models.BreakerOpen:
+			if now.Before(*breaker.RetryAt) {
+				return
+			}
+			b.transition(breaker, models.BreakerHalfOpen, now)
+			result.Changed = true
+		case models.BreakerHalfOpen:
+			// Probes that never reported back, say because their worker died,
+			// stop holding their slots after OpenFor.
+			if !now.Before(*breaker.RetryAt) {
+				breaker.ProbesInFlight = 0
+				retryAt := now.Add(b.cfg.OpenFor)
+				breaker.RetryAt = &retryAt
+			}
+		}
+		if breaker.ProbesInFlight < b.cfg.Probes {
+			breaker.ProbesInFlight++
+			result.Allowed = true
+		}
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	result.Breaker = breaker
+	return result, nil
+}
+
+// Record reports the outcome of a call made after Allow. A closed breaker
+// opens once at least MinRequests calls finished in the window and the share
+// of failures reaches ErrorRate. In a half-open breaker a failed probe reopens
+// it and a successful one closes it. Calls finishing while the breaker is open
+// started before it opened and are ignored.
+func (b *Breakers) Record(ctx context.Context, provider string, model string, failed bool) (Result, error) {
+	var result Result
+	breaker, err := b.backend.Update(ctx, provider, model, func(breaker *models.CircuitBreaker) {
+		now := b.now()
+		b.init(breaker, provider, model, now)
+		result = Result{}
+
+		switch breaker.State {
+		case models.BreakerClosed:
+			if now.Sub(breaker.WindowStart) >= b.cfg.Window {
+				breaker.WindowStart = now
+				breaker.Requests, breaker.Failures = 0, 0
+			}
+			breaker.Requests++
+			if failed {
+				breaker.Failures++
+			}
+			if breaker.Requests >= b.cfg.MinRequests && float64(breaker.Failures) >= b.cfg.ErrorRate*float64(breaker.Requests) {
+				b.transition(breaker, models.BreakerOpen, now)
+				result.Changed = true
+			}
+		case models.BreakerHalfOpen:
+			if failed {
+				breaker.Requests, breaker.Failures = 0, 0
+				b.transition(breaker, models.BreakerOpen, now)
+			} else {
+				b.transition(breaker, models.BreakerClosed, now)
+			}
+			result.Changed = true
+		}
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	result.Breaker = breaker
+	return result, nil
+}
+
+// Release gives back the slot of a call that Allow let through but that never
+// reached the provider. It counts as neither a success nor a failure; in a
+// half-open breaker the slot goes to the next probe.
+func (b *Breakers) Release(ctx context.Context, provider string, model string) error {
+	_, err := b.backend.Update(ctx, provider, model, func(breaker *models.CircuitBreaker) {
+		b.init(breaker, provider, model, b.now())
+		if breaker.State == models.BreakerHalfOpen && breaker.ProbesInFlight > 0 {
+			breaker.ProbesInFlight--
+		}
+	})
+	return err
+}
+
+func (b *Breakers) List(ctx context.Context) ([]models.CircuitBreaker, error) {
+	return b.backend.List(ctx)
+}
+
+func (b *Breakers) Close() error {
+	return b.backend.Close()
+}
+
+// init turns the zero value the backend passes for an unknown breaker into a
+// closed one.
+func (b *Breakers) init(breaker *models.CircuitBreaker, provider string, model string, now time.Time) {
+	if breaker.State != "" {
+		return
+	}
+	*breaker = models.CircuitBreaker{
+		Provider:    provider,
+		Model:       model,
+		State:       models.BreakerClosed,
+		WindowStart: now,
+		ChangedAt:   now,
+	}
+}
+
+func (b *Breakers) transition(breaker *models.CircuitBreaker, state models.BreakerState, now time.Time) {
+	breaker.State = state
+	breaker.ChangedAt = now
+	breaker.ProbesInFlight = 0
+	switch state {
+	case models.BreakerClosed:
+		breaker.Requests, breaker.Failures = 0, 0
+		breaker.WindowStart = now
+		breaker.OpenedAt, breaker.RetryAt = nil, nil
+	case models.BreakerOpen:
+		retryAt := now.Add(b.cfg.OpenFor)
+		breaker.OpenedAt, breaker.RetryAt = &now, &retryAt
+	case models.BreakerHalfOpen:
+		retryAt := now.Add(b.cfg.OpenFor)
+		breaker.RetryAt = &retryAt
+	}
+}
+
+// Describe explains the state breaker just moved to, for its event.
+func Describe(breaker models.CircuitBreaker) string {
+	name := breaker.Provider + "/" + breaker.Model
+	switch breaker.State {
+	case models.BreakerOpen:
+		if breaker.Requests == 0 {
+			return fmt.Sprintf("Circuit breaker for %s opened after a failed probe; retrying at %s",
+				name, breaker.RetryAt.UTC().Format(time.RFC3339))
+		}
+		return fmt.Sprintf("Circuit breaker for %s opened after %d of %d calls failed; retrying at %s",
+			name, breaker.Failures, breaker.Requests, breaker.RetryAt.UTC().Format(time.RFC3339))
+	case models.BreakerHalfOpen:
+		return fmt.Sprintf("Circuit breaker for %s is half-open; letting probes through", name)
+	default:
+		return fmt.Sprintf("Circuit breaker for %s closed", name)
+	}
+}
@@ -0,0 +1,135 @@
+package breaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+type breakerHarness struct {
+	t        *testing.T
+	breakers *Breakers
+	now      time.Time
+}
+
+func newBreakerHarness(t *testing.T) *breakerHarness {
+	h := &breakerHarness{t: t, now: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	h.breakers = New(NewMemoryBackend(), Config{
+		Window:      time.Minute,
+		MinRequests: 4,
+		ErrorRate:   0.5,
+		OpenFor:     30 * time.Second,
+		Probes:      1,
+	})
+	h.breakers.now = func() time.Time { return h.now }
+	return h
+}
+
+func (h *breakerHarness) allow() Result {
+	h.t.Helper()
+	result, err := h.breakers.Allow(context.Background(), "mock-llm", "gpt-4.1-mini")
+	if err != nil {
+		h.t.Fatalf("allow: %v", err)
+	}
+	return result
+}
+
+func (h *breakerHarness) record(failed bool) Result {
+	h.t.Helper()
+	result, err := h.breakers.Record(context.Background(), "mock-llm", "gpt-4.1-mini", failed)
+	if err != nil {
+		h.t.Fatalf("record: %v", err)
+	}
+	return result
+}
+
+func expectState(t *testing.T, result Result, state models.BreakerState, allowed bool, changed bool) {
+	t.Helper()
+	if result.Breaker.State != state || result.Allowed != allowed || result.Changed != changed {
+		t.Fatalf("result = state %s, allowed %v, changed %v; want %s, %v, %v",
+			result.Breaker.State, result.Allowed, result.Changed, state, allowed, changed)
+	}
+}
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	h := newBreakerHarness(t)
+
+	expectState(t, h.allow(), models.BreakerClosed, true, false)
+	// Three failures stay below MinRequests.
+	for i := 0; i < 3; i++ {
+		expectState(t, h.record(true), models.BreakerClosed, false, false)
+	}
+	opened := h.record(false)
+	expectState(t, opened, models.BreakerOpen, false, true)
+	if opened.Breaker.Failures != 3 || opened.Breaker.Requests != 4 || !opened.Breaker.RetryAt.Equal(h.now.Add(30*time.Second)) {
+		t.Fatalf("opened breaker = %+v", opened.Breaker)
+	}
+	expectState(t, h.allow(), models.BreakerOpen, false, false)
+	// Calls that started before the breaker opened do not change it.
+	expectState(t, h.record(false), models.BreakerOpen, false, false)
+
+	h.now = h.now.Add(30 * time.Second)
+	expectState(t, h.allow(), models.BreakerHalfOpen, true, true)
+	expectState(t, h.allow(), models.BreakerHalfOpen, false, false)
+	expectState(t, h.record(true), models.BreakerOpen, false, true)
+
+	h.now = h.now.Add(30 * time.Second)
+	expectState(t, h.allow(), models.BreakerHalfOpen, true, true)
+	closed := h.record(false)
+	expectState(t, closed, models.BreakerClosed, false, true)
+	if closed.Breaker.Requests != 0 || closed.Breaker.OpenedAt != nil || closed.Breaker.RetryAt != nil {
+		t.Fatalf("closed breaker = %+v", closed.Breaker)
+	}
+}
+
+func TestBreakerCountsOnlyTheCurrentWindow(t *testing.T) {
+	h := newBreakerHarness(t)
+
+	for i := 0; i < 3; i++ {
+		h.record(true)
+	}
+	h.now = h.now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		expectState(t, h.record(false), models.BreakerClosed, false, false)
+	}
+	result := h.record(true)
+	expectState(t, result, models.BreakerClosed, false, false)
+	if result.Breaker.Requests != 4 || result.Breaker.Failures != 1 {
+		t.Fatalf("window counts = %d of %d, want 1 of 4", result.Breaker.Failures, result.Breaker.Requests)
+	}
+}
+
+func TestBreakerReleasesProbesThatNeverReport(t *testing.T) {
+	h := newBreakerHarness(t)
+	for i := 0; i < 4; i++ {
+		h.record(true)
+	}
+	h.now = h.now.Add(30 * time.Second)
+	expectState(t, h.allow(), models.BreakerHalfOpen, true, true)
+	expectState(t, h.allow(), models.BreakerHalfOpen, false, false)
+
+	h.now = h.now.Add(30 * time.Second)
+	expectState(t, h.allow(), models.BreakerHalfOpen, true, false)
+
+	breakers, err := h.breakers.List(context.Background())
+	if err != nil || len(breakers) != 1 || breakers[0].ProbesInFlight != 1 {
+		t.Fatalf("breakers = %+v, %v", breakers, err)
+	}
+}
+
+func TestBreakerReleaseFreesAProbeSlot(t *testing.T) {
+	h := newBreakerHarness(t)
+	for i := 0; i < 4; i++ {
+		h.record(true)
+	}
+	h.now = h.now.Add(30 * time.Second)
+	expectState(t, h.allow(), models.BreakerHalfOpen, true, true)
+	if err := h.breakers.Release(context.Background(), "mock-llm", "gpt-4.1-mini"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	// The released probe did not decide anything; the next call probes.
+	expectState(t, h.allow(), models.BreakerHalfOpen, true, false)
+	expectState(t, h.record(false), models.BreakerClosed, false, true)
+}
@@ -0,0 +1,51 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+type memoryKey struct {
+	provider string
+	model    string
+}
+
+// MemoryBackend keeps breakers in process. It is meant for tests and for a
+// single worker; separate processes do not share its state.
+type MemoryBackend struct {
+	mu       sync.Mutex
+	breakers map[memoryKey]models.CircuitBreaker
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{breakers: make(map[memoryKey]models.CircuitBreaker)}
+}
+
+func (b *MemoryBackend) Close() error {
+	return nil
+}
+
+func (b *MemoryBackend) Update(ctx context.Context, provider string, model string, fn func(*models.CircuitBreaker)) (models.CircuitBreaker, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := memoryKey{provider: provider, model: model}
+	breaker := b.breakers[key]
+	fn(&breaker)
+	b.breakers[key] = breaker
+	return breaker, nil
+}
+
+func (b *MemoryBackend) List(ctx context.Context) ([]models.CircuitBreaker, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breakers := make([]models.CircuitBreaker, 0, len(b.breakers))
+	for _, breaker := range b.breakers {
+		breakers = append(breakers, breaker)
+	}
+	sortBreakers(breakers)
+	return breakers, nil
+}
@@ -0,0 +1,127 @@
+package breaker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxUpdateTries bounds how often an update is retried when another worker
+// changed the same breaker in between.
+const maxUpdateTries = 10
+
+// RedisBackend keeps each breaker as a JSON value under keyPrefix plus
+// provider and model, and the set of those keys under keyPrefix + "index".
+type RedisBackend struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+func NewRedisBackend(addr string, password string, db int, keyPrefix string) *RedisBackend {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+	return &RedisBackend{client: client, keyPrefix: keyPrefix}
+}
+
+func (b *RedisBackend) Close() error {
+	return b.client.Close()
+}
+
+func (b *RedisBackend) key(provider string, model string) string {
+	return b.keyPrefix + provider + ":" + model
+}
+
+func (b *RedisBackend) indexKey() string {
+	return b.keyPrefix + "index"
+}
+
+// Update reads, changes and writes the breaker in a WATCH transaction, and
+// starts over if another worker wrote it first.
+func (b *RedisBackend) Update(ctx context.Context, provider string, model string, fn func(*models.CircuitBreaker)) (models.CircuitBreaker, error) {
+	key := b.key(provider, model)
+	var breaker models.CircuitBreaker
+	update := func(tx *redis.Tx) error {
+		breaker = models.CircuitBreaker{}
+		encoded, err := tx.Get(ctx, key).Bytes()
+		switch {
+		case errors.Is(err, redis.Nil):
+		case err != nil:
+			return err
+		default:
+			if err := json.Unmarshal(encoded, &breaker); err != nil {
+				return fmt.Errorf("decode circuit breaker %s: %w", key, err)
+			}
+		}
+
+		fn(&breaker)
+		encoded, err = json.Marshal(breaker)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, encoded, 0)
+			pipe.SAdd(ctx, b.indexKey(), key)
+			return nil
+		})
+		return err
+	}
+
+	for try := 0; try < maxUpdateTries; try++ {
+		err := b.client.Watch(ctx, update, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return models.CircuitBreaker{}, fmt.Errorf("update circuit breaker %s: %w", key, err)
+		}
+		return breaker, nil
+	}
+	return models.CircuitBreaker{}, fmt.Errorf("update circuit breaker %s: gave up after %d conflicting writes", key, maxUpdateTries)
+}
+
+func (b *RedisBackend) List(ctx context.Context) ([]models.CircuitBreaker, error) {
+	keys, err := b.client.SMembers(ctx, b.indexKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list circuit breakers: %w", err)
+	}
+	breakers := make([]models.CircuitBreaker, 0, len(keys))
+	if len(keys) == 0 {
+		return breakers, nil
+	}
+
+	values, err := b.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("read circuit breakers: %w", err)
+	}
+	for i, value := range values {
+		encoded, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var breaker models.CircuitBreaker
+		if err := json.Unmarshal([]byte(encoded), &breaker); err != nil {
+			return nil, fmt.Errorf("decode circuit breaker %s: %w", keys[i], err)
+		}
+		breakers = append(breakers, breaker)
+	}
+	sortBreakers(breakers)
+	return breakers, nil
+}
+
+func sortBreakers(breakers []models.CircuitBreaker) {
+	sort.Slice(breakers, func(i, j int) bool {
+		if breakers[i].Provider != breakers[j].Provider {
+			return breakers[i].Provider < breakers[j].Provider
+		}
+		return breakers[i].Model < breakers[j].Model
+	})
+}
@@ -0,0 +1,61 @@
+package cronexpr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var parser = cron.NewParser(
+	cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// Schedule is a parsed five-field cron expression bound to a time zone.
+type Schedule struct {
+	spec     cron.Schedule
+	location *time.Location
+}
+
+// Parse accepts standard five-field expressions and descriptors such as
+// @hourly. An empty timezone means UTC. The timezone is only taken from its
+// own argument; a CRON_TZ= or TZ= prefix in the expression is rejected.
+func Parse(expression string, timezone string) (Schedule, error) {
+	trimmed := strings.TrimSpace(expression)
+	if strings.HasPrefix(trimmed, "CRON_TZ=") || strings.HasPrefix(trimmed, "TZ=") {
+		return Schedule{}, errors.New("invalid cron expression: set the timezone separately, not with a TZ prefix")
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("unknown timezone %q", timezone)
+	}
+	spec, err := parser.Parse(expression)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("invalid cron expression: %w", err)
+	}
+	return Schedule{spec: spec, location: location}, nil
+}
+
+// Next returns the first fire time strictly after the given instant, or the
+// zero time when the expression never matches. Wall-clock times skipped when
+// clocks spring forward do not fire; those repeated when clocks fall back fire
+// once, on their first occurrence.
+func (s Schedule) Next(after time.Time) time.Time {
+	local := after.In(s.location)
+	next := s.spec.Next(local)
+	for !next.IsZero() && !wallClock(next).After(wallClock(local)) {
+		next = s.spec.Next(next)
+	}
+	return next.UTC()
+}
+
+// wallClock drops the zone offset, so times in the repeated hour after clocks
+// fall back compare as equal to their first occurrence.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,94 @@
+package cronexpr
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		timezone   string
+		want       string
+	}{
+		{name: "empty", expression: "", want: "invalid cron expression"},
+		{name: "four fields", expression: "* * * *", want: "invalid cron expression"},
+		{name: "six fields", expression: "0 * * * * *", want: "invalid cron expression"},
+		{name: "minute out of range", expression: "60 * * * *", want: "invalid cron expression"},
+		{name: "hour out of range", expression: "0 24 * * *", want: "invalid cron expression"},
+		{name: "day of month zero", expression: "0 0 0 * *", want: "invalid cron expression"},
+		{name: "month out of range", expression: "0 0 1 13 *", want: "invalid cron expression"},
+		{name: "day of week out of range", expression: "0 0 * * 8", want: "invalid cron expression"},
+		{name: "zero step", expression: "*/0 * * * *", want: "invalid cron expression"},
+		{name: "reversed range", expression: "0 17-9 * * *", want: "invalid cron expression"},
+		{name: "unknown name", expression: "0 0 * * FUNDAY", want: "invalid cron expression"},
+		{name: "unknown descriptor", expression: "@fortnightly", want: "invalid cron expression"},
+		{name: "tz prefix", expression: "CRON_TZ=Asia/Tokyo 0 9 * * *", want: "timezone separately"},
+		{name: "unknown timezone", expression: "0 9 * * *", timezone: "Mars/Olympus", want: "unknown timezone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression, tt.timezone)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Parse(%q, %q) error = %v, want %q", tt.expression, tt.timezone, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Monday 2 March 2026, 10:17 UTC.
+	monday := time.Date(2026, 3, 2, 10, 17, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		expression string
+		timezone   string
+		after      time.Time
+		want       time.Time
+	}{
+		{name: "step", expression: "*/15 * * * *", after: monday, want: time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)},
+		{name: "list", expression: "5,45 * * * *", after: monday, want: time.Date(2026, 3, 2, 10, 45, 0, 0, time.UTC)},
+		{name: "range with step", expression: "0 9-17/4 * * *", after: monday, want: time.Date(2026, 3, 2, 13, 0, 0, 0, time.UTC)},
+		{name: "strictly after", expression: "17 10 * * *", after: monday, want: time.Date(2026, 3, 3, 10, 17, 0, 0, time.UTC)},
+		{name: "weekday names", expression: "30 8 * * MON-FRI", after: monday, want: time.Date(2026, 3, 3, 8, 30, 0, 0, time.UTC)},
+		{name: "weekend from friday", expression: "0 8 * * SAT,SUN", after: time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC), want: time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)},
+		{name: "sunday as 0", expression: "0 8 * * 0", after: monday, want: time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC)},
+		{name: "month names", expression: "0 0 1 JAN,JUL *", after: monday, want: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month", expression: "0 12 13 * *", after: monday, want: time.Date(2026, 3, 13, 12, 0, 0, 0, time.UTC)},
+		// With both day fields restricted, either one matching fires.
+		{name: "day of month or week", expression: "0 12 13 * FRI", after: monday, want: time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)},
+		{name: "day of month or week, month day first", expression: "0 12 3 * FRI", after: monday, want: time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)},
+		{name: "leap day", expression: "0 0 29 2 *", after: monday, want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "hourly", expression: "@hourly", after: monday, want: time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)},
+		{name: "daily", expression: "@daily", after: monday, want: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "weekly", expression: "@weekly", after: monday, want: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{name: "timezone", expression: "0 9 * * *", timezone: "America/New_York", after: monday, want: time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)},
+		{name: "timezone day boundary", expression: "0 0 * * *", timezone: "Asia/Tokyo", after: monday, want: time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)},
+		{name: "never matches", expression: "0 0 30 2 *", after: monday, want: time.Time{}},
+
+		// New York springs forward at 02:00 on 8 March 2026 and falls back
+		// at 02:00 on 1 November 2026.
+		{name: "across spring forward", expression: "0 9 * * *", timezone: "America/New_York", after: time.Date(2026, 3, 7, 15, 0, 0, 0, time.UTC), want: time.Date(2026, 3, 8, 13, 0, 0, 0, time.UTC)},
+		{name: "skipped by spring forward", expression: "30 2 * * *", timezone: "America/New_York", after: time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC), want: time.Date(2026, 3, 9, 6, 30, 0, 0, time.UTC)},
+		{name: "repeated by fall back, first pass", expression: "30 1 * * *", timezone: "America/New_York", after: time.Date(2026, 10, 31, 12, 0, 0, 0, time.UTC), want: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC)},
+		{name: "repeated by fall back, fires once", expression: "30 1 * * *", timezone: "America/New_York", after: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), want: time.Date(2026, 11, 2, 6, 30, 0, 0, time.UTC)},
+		{name: "repeated hour steps", expression: "*/30 * * * *", timezone: "America/New_York", after: time.Date(2026, 11, 1, 5, 45, 0, 0, time.UTC), want: time.Date(2026, 11, 1, 7, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expression, tt.timezone)
+			if err != nil {
+				t.Fatalf("Parse(%q, %q): %v", tt.expression, tt.timezone, err)
+			}
+			want := tt.want
+			if !want.IsZero() {
+				want = want.UTC()
+			}
+			if got := schedule.Next(tt.after); !got.Equal(want) {
+				t.Fatalf("Next(%s) = %s, want %s", tt.after, got, want)
+			}
+		})
+	}
+}
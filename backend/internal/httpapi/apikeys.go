@@ -0,0 +1,161 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+type createAPIKeyRequest struct {
+	TenantID  string      `json:"tenant_id"`
+	Name      string      `json:"name"`
+	Role      models.Role `json:"role"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+	Reason    string      `json:"reason"`
+}
+
+type rotateAPIKeyRequest struct {
+	GraceSeconds int    `json:"grace_seconds"`
+	Reason       string `json:"reason"`
+}
+
+type apiKeyResponse struct {
+	APIKey models.APIKey `json:"api_key"`
+}
+
+type listAPIKeysResponse struct {
+	APIKeys []models.APIKey `json:"api_keys"`
+}
+
+func (s *Server) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tenantID, ok := resolveTenant(r, r.URL.Query().Get("tenant"))
+		if !ok {
+			writeTenantMismatch(w)
+			return
+		}
+		keys, err := s.service.ListAPIKeys(r.Context(), tenantID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, listAPIKeysResponse{APIKeys: keys})
+	case http.MethodPost:
+		s.handleCreateAPIKey(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var request createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	// A tenant admin issues keys for its own tenant only.
+	var ok bool
+	if request.TenantID, ok = resolveTenant(r, strings.TrimSpace(request.TenantID)); !ok {
+		writeTenantMismatch(w)
+		return
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		writeError(w, http.StatusBadRequest, "validation_error", "expires_at must be in the future")
+		return
+	}
+	reason, ok := checkReason(w, request.Reason)
+	if !ok {
+		return
+	}
+
+	issued, err := s.service.CreateAPIKey(r.Context(), request.TenantID, request.Name, request.Role, request.ExpiresAt, reason)
+	if errors.Is(err, jobs.ErrInvalidAPIKey) {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, issued)
+}
+
+func (s *Server) handleAPIKeyByID(w http.ResponseWriter, r *http.Request) {
+	keyID, action, ok := parsePathTail(r.URL.Path, "/v1/admin/api-keys/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "API key not found")
+		return
+	}
+	if !s.apiKeyVisible(w, r, keyID) {
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		key, err := s.service.GetAPIKey(r.Context(), keyID)
+		if err != nil {
+			writeAPIKeyError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, apiKeyResponse{APIKey: key})
+	case action == "rotate" && r.Method == http.MethodPost:
+		s.handleRotateAPIKey(w, r, keyID)
+	case action == "revoke" && r.Method == http.MethodPost:
+		reason, ok := decodeReason(w, r)
+		if !ok {
+			return
+		}
+		key, err := s.service.RevokeAPIKey(r.Context(), keyID, reason)
+		if err != nil {
+			writeAPIKeyError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, apiKeyResponse{APIKey: key})
+	case action != "" && action != "rotate" && action != "revoke":
+		writeError(w, http.StatusNotFound, "not_found", "API key not found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handleRotateAPIKey(w http.ResponseWriter, r *http.Request, keyID string) {
+	var request rotateAPIKeyRequest
+	// The body is optional; an empty one retires the old key immediately.
+	if err := decodeOptionalJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	if request.GraceSeconds < 0 {
+		writeError(w, http.StatusBadRequest, "validation_error", "grace_seconds must not be negative")
+		return
+	}
+	reason, ok := checkReason(w, request.Reason)
+	if !ok {
+		return
+	}
+
+	issued, err := s.service.RotateAPIKey(r.Context(), keyID, time.Duration(request.GraceSeconds)*time.Second, reason)
+	if err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, issued)
+}
+
+func writeAPIKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "API key not found")
+	case errors.Is(err, store.ErrInvalidStateTransition):
+		writeError(w, http.StatusConflict, "invalid_state", "Only active keys that have not been rotated can be rotated")
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// maxReasonLength bounds the free-text reason stored with an audit entry.
+const maxReasonLength = 1000
+
+type reasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+// decodeOptionalJSON decodes a JSON body that may be absent.
+func decodeOptionalJSON(r *http.Request, out any) error {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// decodeReason reads the optional {"reason": "..."} body of an action. When it
+// reports false the error response has been written.
+func decodeReason(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var request reasonRequest
+	if err := decodeOptionalJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return "", false
+	}
+	return checkReason(w, request.Reason)
+}
+
+func checkReason(w http.ResponseWriter, reason string) (string, bool) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxReasonLength {
+		writeError(w, http.StatusBadRequest, "validation_error", fmt.Sprintf("reason must be at most %d bytes", maxReasonLength))
+		return "", false
+	}
+	return reason, true
+}
+
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	page, err := s.service.ListAudit(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func parseAuditFilter(query url.Values) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		TenantID:   query.Get("tenant"),
+		ActorID:    query.Get("actor"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+	}
+	for name, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return models.AuditFilter{}, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+			*target = &parsed
+		}
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor <= 0 {
+			return models.AuditFilter{}, errors.New("cursor is invalid")
+		}
+		filter.BeforeID = cursor
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > 500 {
+			return models.AuditFilter{}, errors.New("limit must be between 1 and 500")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -0,0 +1,230 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/jwtauth"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+// AuthConfig controls request authentication.
+type AuthConfig struct {
+	// Required rejects requests without a valid credential. When false every
+	// caller may act for any tenant, which only suits local development.
+	Required bool
+	// AdminKey is a bootstrap credential with the operator role, for issuing
+	// the first API keys.
+	AdminKey string
+	// Tokens, when set, accepts JWT bearer tokens from an identity provider
+	// alongside API keys.
+	Tokens *jwtauth.Verifier
+	// TrustProxy takes the audited source IP from the last X-Forwarded-For
+	// entry, as added by a reverse proxy in front of the API.
+	TrustProxy bool
+}
+
+// principal is the authenticated caller of a request.
+type principal struct {
+	// kind is admin_key, api_key or jwt, as recorded in the audit log.
+	kind string
+	// id is the API key ID or the JWT subject.
+	id string
+	// tenantID confines the caller to one tenant; when empty the caller may
+	// act for any tenant its role allows.
+	tenantID string
+	role     models.Role
+}
+
+type principalKey struct{}
+
+func principalFrom(ctx context.Context) (principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(principal)
+	return p, ok
+}
+
+// withAuth authenticates every request except /healthz with an API key, sent
+// as a bearer token or X-API-Key header, or with a JWT bearer token when
+// Tokens is set. What the caller may do is checked per route by authorize.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	if !s.auth.Required {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actor := models.Actor{Type: "anonymous", SourceIP: s.sourceIP(r), UserAgent: r.UserAgent()}
+			next.ServeHTTP(w, r.WithContext(jobs.WithActor(r.Context(), actor)))
+		})
+	}
+	adminHash := sha256.Sum256([]byte(s.auth.AdminKey))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		credential := requestCredential(r)
+		if credential == "" {
+			writeUnauthorized(w, "An API key is required")
+			return
+		}
+
+		var caller principal
+		presented := sha256.Sum256([]byte(credential))
+		if s.auth.AdminKey != "" && subtle.ConstantTimeCompare(presented[:], adminHash[:]) == 1 {
+			caller = principal{kind: "admin_key", role: models.RoleOperator}
+		} else if s.auth.Tokens != nil && jwtauth.LooksLikeJWT(credential) {
+			identity, err := s.auth.Tokens.Verify(r.Context(), credential)
+			if errors.Is(err, jwtauth.ErrInvalidToken) {
+				writeUnauthorized(w, err.Error())
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusServiceUnavailable, "auth_unavailable", err.Error())
+				return
+			}
+			caller = principal{kind: "jwt", tenantID: identity.TenantID, id: identity.Subject, role: identity.Role}
+		} else {
+			key, err := s.service.AuthenticateAPIKey(r.Context(), credential)
+			if errors.Is(err, jobs.ErrUnauthenticated) {
+				writeUnauthorized(w, "API key is invalid, revoked or expired")
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+				return
+			}
+			caller = principal{kind: "api_key", tenantID: key.TenantID, id: key.ID, role: key.Role}
+		}
+
+		ctx := context.WithValue(r.Context(), principalKey{}, caller)
+		ctx = jobs.WithActor(ctx, models.Actor{
+			Type:      caller.kind,
+			ID:        caller.id,
+			TenantID:  caller.tenantID,
+			Role:      caller.role,
+			SourceIP:  s.sourceIP(r),
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// sourceIP returns the client address recorded in the audit log.
+func (s *Server) sourceIP(r *http.Request) string {
+	if s.auth.TrustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func requestCredential(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="job-queue"`)
+	writeError(w, http.StatusUnauthorized, "unauthorized", message)
+}
+
+// tenantScope returns the tenant the caller is confined to, or "" when it may
+// act for any tenant.
+func tenantScope(r *http.Request) string {
+	caller, _ := principalFrom(r.Context())
+	return caller.tenantID
+}
+
+// resolveTenant applies the caller's tenant to a tenant_id taken from the
+// request. A tenant key fills in a missing tenant_id and rejects another
+// tenant's; other callers keep what they sent.
+func resolveTenant(r *http.Request, requested string) (string, bool) {
+	scope := tenantScope(r)
+	if scope == "" {
+		return requested, true
+	}
+	if requested != "" && requested != scope {
+		return "", false
+	}
+	return scope, true
+}
+
+func writeTenantMismatch(w http.ResponseWriter) {
+	writeError(w, http.StatusForbidden, "forbidden", "tenant_id does not match the API key")
+}
+
+// visibleTo reports whether a resource owned by tenantID may be seen by the
+// caller. Other tenants' resources are reported as not found.
+func visibleTo(r *http.Request, tenantID string) bool {
+	scope := tenantScope(r)
+	return scope == "" || scope == tenantID
+}
+
+// jobVisible checks that a tenant-scoped caller owns the job. When it does
+// not, it writes the response, 404 for other tenants' jobs, and returns false.
+func (s *Server) jobVisible(w http.ResponseWriter, r *http.Request, jobID string) bool {
+	if tenantScope(r) == "" {
+		return true
+	}
+	snapshot, err := s.service.GetJob(r.Context(), jobID)
+	return checkVisible(w, r, snapshot.Job.TenantID, err, "Job not found")
+}
+
+func (s *Server) groupVisible(w http.ResponseWriter, r *http.Request, groupID string) bool {
+	if tenantScope(r) == "" {
+		return true
+	}
+	group, err := s.service.GetGroup(r.Context(), groupID)
+	return checkVisible(w, r, group.TenantID, err, "Group not found")
+}
+
+func (s *Server) apiKeyVisible(w http.ResponseWriter, r *http.Request, keyID string) bool {
+	if tenantScope(r) == "" {
+		return true
+	}
+	key, err := s.service.GetAPIKey(r.Context(), keyID)
+	return checkVisible(w, r, key.TenantID, err, "API key not found")
+}
+
+func (s *Server) scheduleVisible(w http.ResponseWriter, r *http.Request, scheduleID string) bool {
+	if tenantScope(r) == "" {
+		return true
+	}
+	schedule, err := s.service.GetSchedule(r.Context(), scheduleID)
+	return checkVisible(w, r, schedule.TenantID, err, "Schedule not found")
+}
+
+func checkVisible(w http.ResponseWriter, r *http.Request, ownerTenantID string, lookupErr error, notFound string) bool {
+	switch {
+	case errors.Is(lookupErr, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", notFound)
+		return false
+	case lookupErr != nil:
+		writeError(w, http.StatusInternalServerError, "internal_error", lookupErr.Error())
+		return false
+	case !visibleTo(r, ownerTenantID):
+		writeError(w, http.StatusNotFound, "not_found", notFound)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,343 @@
+package httpapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/jwtauth"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/queue"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+const testAdminKey = "test-admin-key"
+
+var adminHeaders = map[string]string{"Authorization": "Bearer " + testAdminKey}
+
+func newAuthTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	return newAuthTestAPIWith(t, AuthConfig{Required: true, AdminKey: testAdminKey})
+}
+
+func newAuthTestAPIWith(t *testing.T, auth AuthConfig) *testAPI {
+	t.Helper()
+	memStore := store.NewMemoryStore()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := jobs.NewService(memStore, queue.NewMemoryQueue(30*time.Second), nil, 0, logger)
+	return &testAPI{t: t, handler: NewServer(service, 10, auth).Handler(), store: memStore}
+}
+
+// newTestIdP generates a P-256 key, publishes it as a JWKS file and returns a
+// function that signs ES256 tokens with it.
+func newTestIdP(t *testing.T) (*jwtauth.KeySet, func(claims map[string]any) string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "EC", "kid": "test", "crv": "P-256", "x": encode(key.X.Bytes()), "y": encode(key.Y.Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	sign := func(claims map[string]any) string {
+		header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "test"})
+		payload, _ := json.Marshal(claims)
+		signed := encode(header) + "." + encode(payload)
+		sum := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signed + "." + encode(signature)
+	}
+	return jwtauth.NewFileKeySet(path, time.Hour), sign
+}
+
+// issueKey creates an API key for tenantID with role, where empty means the
+// default, and returns headers that use it.
+func (a *testAPI) issueKey(tenantID string, role models.Role) (models.IssuedAPIKey, map[string]string) {
+	a.t.Helper()
+	var issued models.IssuedAPIKey
+	body := `{"tenant_id":"` + tenantID + `","name":"ci","role":"` + string(role) + `"}`
+	if status := a.do(http.MethodPost, "/v1/admin/api-keys", body, adminHeaders, &issued); status != http.StatusCreated {
+		a.t.Fatalf("create api key: status %d", status)
+	}
+	return issued, map[string]string{"X-API-Key": issued.Key}
+}
+
+func TestAuthRequiresCredential(t *testing.T) {
+	api := newAuthTestAPI(t)
+
+	var response errorResponse
+	status := api.do(http.MethodGet, "/v1/jobs", "", nil, &response)
+	expectError(t, status, response, http.StatusUnauthorized, "unauthorized")
+	status = api.do(http.MethodGet, "/v1/jobs", "", map[string]string{"Authorization": "Bearer jq_unknown"}, &response)
+	expectError(t, status, response, http.StatusUnauthorized, "unauthorized")
+	if status := api.do(http.MethodGet, "/healthz", "", nil, nil); status != http.StatusOK {
+		t.Fatalf("healthz: status %d", status)
+	}
+
+}
+
+func TestRolePermissions(t *testing.T) {
+	api := newAuthTestAPI(t)
+	_, viewer := api.issueKey("acme", models.RoleViewer)
+	_, user := api.issueKey("acme", models.RoleTenantUser)
+
+	var created createJobResponse
+	if status := api.do(http.MethodPost, "/v1/jobs", `{"model":"m"}`, user, &created); status != http.StatusCreated {
+		t.Fatalf("tenant-user create job: status %d", status)
+	}
+	job := created.Job
+	if status := api.do(http.MethodGet, "/v1/jobs/"+job.ID, "", viewer, nil); status != http.StatusOK {
+		t.Fatalf("viewer get job: status %d", status)
+	}
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		method     string
+		path       string
+		permission permission
+	}{
+		{name: "viewer cancels", headers: viewer, method: http.MethodPost, path: "/v1/jobs/" + job.ID + "/cancel", permission: permJobsWrite},
+		{name: "viewer creates schedule", headers: viewer, method: http.MethodPost, path: "/v1/schedules", permission: permSchedulesWrite},
+		{name: "user retries", headers: user, method: http.MethodPost, path: "/v1/admin/jobs/" + job.ID + "/retry", permission: permJobsRetry},
+		{name: "user reads queue", headers: user, method: http.MethodGet, path: "/v1/admin/queue/pending", permission: permQueueRead},
+		{name: "user lists keys", headers: user, method: http.MethodGet, path: "/v1/admin/api-keys", permission: permAPIKeysManage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response forbiddenResponse
+			status := api.do(tt.method, tt.path, "", tt.headers, &response)
+			if status != http.StatusForbidden || response.Code != "forbidden" || response.Permission != tt.permission {
+				t.Fatalf("response = %d %+v, want 403 naming %s", status, response, tt.permission)
+			}
+		})
+	}
+
+	var response errorResponse
+	status := api.do(http.MethodPatch, "/v1/jobs", "", user, &response)
+	expectError(t, status, response, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestTenantAdminManagesOwnKeys(t *testing.T) {
+	api := newAuthTestAPI(t)
+	_, admin := api.issueKey("acme", models.RoleTenantAdmin)
+	other, _ := api.issueKey("globex", "")
+
+	var issued models.IssuedAPIKey
+	if status := api.do(http.MethodPost, "/v1/admin/api-keys", `{"name":"dashboard","role":"read-only-viewer"}`, admin, &issued); status != http.StatusCreated {
+		t.Fatalf("create key: status %d", status)
+	}
+	if issued.APIKey.TenantID != "acme" || issued.APIKey.Role != models.RoleViewer {
+		t.Fatalf("issued = %+v, want an acme viewer key", issued.APIKey)
+	}
+
+	var response errorResponse
+	status := api.do(http.MethodPost, "/v1/admin/api-keys", `{"tenant_id":"globex"}`, admin, &response)
+	expectError(t, status, response, http.StatusForbidden, "forbidden")
+	status = api.do(http.MethodPost, "/v1/admin/api-keys", `{"role":"operator"}`, admin, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	status = api.do(http.MethodPost, "/v1/admin/api-keys/"+other.APIKey.ID+"/revoke", "", admin, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+
+	var list listAPIKeysResponse
+	api.do(http.MethodGet, "/v1/admin/api-keys", "", admin, &list)
+	if len(list.APIKeys) != 2 {
+		t.Fatalf("listed %d keys, want acme's 2", len(list.APIKeys))
+	}
+	status = api.do(http.MethodPost, "/v1/admin/api-keys", `{"role":"tenant-user"}`, adminHeaders, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+}
+
+func TestAuthTenantFromKey(t *testing.T) {
+	api := newAuthTestAPI(t)
+	_, acme := api.issueKey("acme", "")
+	_, globex := api.issueKey("globex", "")
+
+	var created createJobResponse
+	if status := api.do(http.MethodPost, "/v1/jobs", `{"model":"m"}`, acme, &created); status != http.StatusCreated {
+		t.Fatalf("create job: status %d", status)
+	}
+	if created.Job.TenantID != "acme" {
+		t.Fatalf("tenant = %q, want acme", created.Job.TenantID)
+	}
+
+	var response errorResponse
+	status := api.do(http.MethodPost, "/v1/jobs", `{"tenant_id":"globex","model":"m"}`, acme, &response)
+	expectError(t, status, response, http.StatusForbidden, "forbidden")
+
+	// Another tenant's job does not exist as far as globex can tell.
+	jobPath := "/v1/jobs/" + created.Job.ID
+	status = api.do(http.MethodGet, jobPath, "", globex, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+	status = api.do(http.MethodPost, jobPath+"/cancel", "", globex, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+	if status := api.do(http.MethodGet, jobPath, "", acme, nil); status != http.StatusOK {
+		t.Fatalf("owner get: status %d", status)
+	}
+
+	status = api.do(http.MethodGet, "/v1/jobs?tenant=acme", "", globex, &response)
+	expectError(t, status, response, http.StatusForbidden, "forbidden")
+	var list listJobsResponse
+	api.do(http.MethodGet, "/v1/jobs", "", globex, &list)
+	if len(list.Jobs) != 0 {
+		t.Fatalf("globex listed %d jobs, want 0", len(list.Jobs))
+	}
+}
+
+func TestAPIKeyRotateAndRevoke(t *testing.T) {
+	api := newAuthTestAPI(t)
+	issued, oldHeaders := api.issueKey("acme", "")
+	if issued.APIKey.Prefix == "" || issued.Key[:len(issued.APIKey.Prefix)] != issued.APIKey.Prefix {
+		t.Fatalf("prefix %q does not match key", issued.APIKey.Prefix)
+	}
+
+	var rotated models.IssuedAPIKey
+	rotatePath := "/v1/admin/api-keys/" + issued.APIKey.ID + "/rotate"
+	if status := api.do(http.MethodPost, rotatePath, `{"grace_seconds":60}`, adminHeaders, &rotated); status != http.StatusCreated {
+		t.Fatalf("rotate: status %d", status)
+	}
+	newHeaders := map[string]string{"X-API-Key": rotated.Key}
+
+	// Both keys work during the grace period.
+	for _, headers := range []map[string]string{oldHeaders, newHeaders} {
+		if status := api.do(http.MethodGet, "/v1/jobs", "", headers, nil); status != http.StatusOK {
+			t.Fatalf("list jobs during grace: status %d", status)
+		}
+	}
+	var response errorResponse
+	status := api.do(http.MethodPost, rotatePath, "", adminHeaders, &response)
+	expectError(t, status, response, http.StatusConflict, "invalid_state")
+
+	if status := api.do(http.MethodPost, "/v1/admin/api-keys/"+rotated.APIKey.ID+"/revoke", "", adminHeaders, nil); status != http.StatusOK {
+		t.Fatalf("revoke: status %d", status)
+	}
+	status = api.do(http.MethodGet, "/v1/jobs", "", newHeaders, &response)
+	expectError(t, status, response, http.StatusUnauthorized, "unauthorized")
+
+	var list listAPIKeysResponse
+	api.do(http.MethodGet, "/v1/admin/api-keys?tenant=acme", "", adminHeaders, &list)
+	if len(list.APIKeys) != 2 {
+		t.Fatalf("listed %d keys, want 2", len(list.APIKeys))
+	}
+	status = api.do(http.MethodPost, "/v1/admin/api-keys/missing/revoke", "", adminHeaders, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+}
+
+func TestJWTBearerTokens(t *testing.T) {
+	keys, sign := newTestIdP(t)
+	verifier := jwtauth.NewVerifier(jwtauth.Config{Issuer: "https://idp.test", Audience: "job-queue", TenantClaim: "org"}, keys)
+	api := newAuthTestAPIWith(t, AuthConfig{Required: true, Tokens: verifier})
+
+	token := func(role string, expiresIn time.Duration) map[string]string {
+		return map[string]string{"Authorization": "Bearer " + sign(map[string]any{
+			"iss":  "https://idp.test",
+			"aud":  "job-queue",
+			"sub":  "reporting-service",
+			"exp":  time.Now().Add(expiresIn).Unix(),
+			"org":  "acme",
+			"role": role,
+		})}
+	}
+
+	var created createJobResponse
+	if status := api.do(http.MethodPost, "/v1/jobs", `{"model":"m"}`, token("tenant-user", time.Minute), &created); status != http.StatusCreated {
+		t.Fatalf("create job with jwt: status %d", status)
+	}
+	if created.Job.TenantID != "acme" {
+		t.Fatalf("tenant = %q, want acme from the org claim", created.Job.TenantID)
+	}
+
+	var forbidden forbiddenResponse
+	status := api.do(http.MethodGet, "/v1/admin/queue/pending", "", token("tenant-user", time.Minute), &forbidden)
+	if status != http.StatusForbidden || forbidden.Permission != permQueueRead {
+		t.Fatalf("queue pending as tenant-user = %d %+v", status, forbidden)
+	}
+
+	var response errorResponse
+	status = api.do(http.MethodGet, "/v1/jobs", "", token("tenant-user", -time.Hour), &response)
+	expectError(t, status, response, http.StatusUnauthorized, "unauthorized")
+	status = api.do(http.MethodGet, "/v1/jobs", "", map[string]string{"Authorization": "Bearer a.b.c"}, &response)
+	expectError(t, status, response, http.StatusUnauthorized, "unauthorized")
+}
+
+func TestAuditLog(t *testing.T) {
+	api := newAuthTestAPIWith(t, AuthConfig{Required: true, AdminKey: testAdminKey, TrustProxy: true})
+	issued, acme := api.issueKey("acme", "")
+	var created createJobResponse
+	api.do(http.MethodPost, "/v1/jobs", `{"model":"m"}`, acme, &created)
+	job := created.Job
+
+	headers := map[string]string{
+		"X-API-Key":       issued.Key,
+		"User-Agent":      "ops-cli/1.2",
+		"X-Forwarded-For": "203.0.113.9, 198.51.100.7",
+	}
+	var cancelled actionJobResponse
+	if status := api.do(http.MethodPost, "/v1/jobs/"+job.ID+"/cancel", `{"reason":"duplicate submission"}`, headers, &cancelled); status != http.StatusOK {
+		t.Fatalf("cancel: status %d", status)
+	}
+	if cancelled.Job.ErrorMessage != "duplicate submission" {
+		t.Fatalf("error message = %q, want the reason", cancelled.Job.ErrorMessage)
+	}
+	if status := api.do(http.MethodPost, "/v1/admin/jobs/"+job.ID+"/retry", "", adminHeaders, nil); status != http.StatusOK {
+		t.Fatalf("retry: status %d", status)
+	}
+
+	var response forbiddenResponse
+	if status := api.do(http.MethodGet, "/v1/admin/audit", "", acme, &response); status != http.StatusForbidden || response.Permission != permAuditRead {
+		t.Fatalf("tenant audit read = %d %+v", status, response)
+	}
+
+	var list models.AuditPage
+	if status := api.do(http.MethodGet, "/v1/admin/audit?target_id="+job.ID, "", adminHeaders, &list); status != http.StatusOK {
+		t.Fatalf("list audit: status %d", status)
+	}
+	if len(list.Entries) != 2 || list.Entries[0].Action != jobs.AuditJobRetry || list.Entries[1].Action != jobs.AuditJobCancel {
+		t.Fatalf("entries = %+v, want retry then cancel", list.Entries)
+	}
+	cancel := list.Entries[1]
+	want := models.Actor{Type: "api_key", ID: issued.APIKey.ID, TenantID: "acme", Role: models.RoleTenantUser, SourceIP: "198.51.100.7", UserAgent: "ops-cli/1.2"}
+	if cancel.Actor != want || cancel.Reason != "duplicate submission" || cancel.TenantID != "acme" {
+		t.Fatalf("cancel entry = %+v, want actor %+v", cancel, want)
+	}
+	if retry := list.Entries[0]; retry.Actor.Type != "admin_key" || retry.Actor.Role != models.RoleOperator {
+		t.Fatalf("retry actor = %+v, want the admin key", retry.Actor)
+	}
+
+	var page models.AuditPage
+	api.do(http.MethodGet, "/v1/admin/audit?limit=2", "", adminHeaders, &page)
+	if len(page.Entries) != 2 || page.NextCursor == "" {
+		t.Fatalf("first page = %+v", page)
+	}
+	var last models.AuditPage
+	api.do(http.MethodGet, "/v1/admin/audit?limit=2&cursor="+page.NextCursor, "", adminHeaders, &last)
+	if len(last.Entries) != 1 || last.NextCursor != "" || last.Entries[0].Action != jobs.AuditAPIKeyCreate || last.Entries[0].TargetID != issued.APIKey.ID {
+		t.Fatalf("last page = %+v, want the key creation", last)
+	}
+	var errResponse errorResponse
+	status := api.do(http.MethodGet, "/v1/admin/audit?since=yesterday", "", adminHeaders, &errResponse)
+	expectError(t, status, errResponse, http.StatusBadRequest, "validation_error")
+}
@@ -0,0 +1,31 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+type listCircuitBreakersResponse struct {
+	Breakers []models.CircuitBreaker `json:"breakers"`
+}
+
+func (s *Server) handleCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	breakers, err := s.service.ListCircuitBreakers(r.Context())
+	if err != nil {
+		if errors.Is(err, jobs.ErrBreakersOff) {
+			writeError(w, http.StatusNotImplemented, "not_supported", "Circuit breakers are turned off")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, listCircuitBreakersResponse{Breakers: breakers})
+}
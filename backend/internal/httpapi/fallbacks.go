@@ -0,0 +1,110 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+type putFallbackPolicyRequest struct {
+	FallbackModels []string `json:"fallback_models"`
+	FallbackOn     []string `json:"fallback_on"`
+	Reason         string   `json:"reason"`
+}
+
+type fallbackPolicyResponse struct {
+	Policy models.FallbackPolicy `json:"policy"`
+}
+
+type listFallbackPoliciesResponse struct {
+	Policies []models.FallbackPolicy `json:"policies"`
+}
+
+func (s *Server) handleFallbackPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	policies, err := s.service.ListFallbackPolicies(r.Context(), r.URL.Query().Get("tenant"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, listFallbackPoliciesResponse{Policies: policies})
+}
+
+// handleFallbackPolicy serves /v1/admin/fallback-policies/{tenant_id}/{model}.
+// Everything after the tenant is the model, which may itself contain slashes.
+func (s *Server) handleFallbackPolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, model, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/admin/fallback-policies/"), "/")
+	model = strings.Trim(model, "/")
+	if !ok || tenantID == "" || model == "" {
+		writeError(w, http.StatusNotFound, "not_found", "Fallback policy not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := s.service.GetFallbackPolicy(r.Context(), tenantID, model)
+		if err != nil {
+			writeFallbackPolicyError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, fallbackPolicyResponse{Policy: policy})
+	case http.MethodPut:
+		s.handlePutFallbackPolicy(w, r, tenantID, model)
+	case http.MethodDelete:
+		reason, ok := decodeReason(w, r)
+		if !ok {
+			return
+		}
+		if err := s.service.DeleteFallbackPolicy(r.Context(), tenantID, model, reason); err != nil {
+			writeFallbackPolicyError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handlePutFallbackPolicy(w http.ResponseWriter, r *http.Request, tenantID string, model string) {
+	var request putFallbackPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	reason, ok := checkReason(w, request.Reason)
+	if !ok {
+		return
+	}
+
+	saved, err := s.service.SetFallbackPolicy(r.Context(), models.FallbackPolicy{
+		TenantID:       tenantID,
+		Model:          model,
+		FallbackModels: request.FallbackModels,
+		FallbackOn:     request.FallbackOn,
+	}, reason)
+	if err != nil {
+		writeFallbackPolicyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, fallbackPolicyResponse{Policy: saved})
+}
+
+func writeFallbackPolicyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "Fallback policy not found")
+	case errors.Is(err, jobs.ErrInvalidFallbackPolicy):
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
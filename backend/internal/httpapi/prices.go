@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+type createModelPriceRequest struct {
+	Model            string     `json:"model"`
+	EffectiveFrom    *time.Time `json:"effective_from"`
+	InputPer1K       *float64   `json:"input_per_1k_usd"`
+	CachedInputPer1K *float64   `json:"cached_input_per_1k_usd"`
+	OutputPer1K      *float64   `json:"output_per_1k_usd"`
+	Reason           string     `json:"reason"`
+}
+
+type modelPriceResponse struct {
+	Price models.ModelPrice `json:"price"`
+}
+
+type listModelPricesResponse struct {
+	Prices []models.ModelPrice `json:"prices"`
+}
+
+func (s *Server) handleModelPrices(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		prices, err := s.service.ListModelPrices(r.Context(), r.URL.Query().Get("model"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, listModelPricesResponse{Prices: prices})
+	case http.MethodPost:
+		s.handleCreateModelPrice(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handleCreateModelPrice(w http.ResponseWriter, r *http.Request) {
+	var request createModelPriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	if request.InputPer1K == nil || request.OutputPer1K == nil {
+		writeError(w, http.StatusBadRequest, "validation_error", "input_per_1k_usd and output_per_1k_usd are required")
+		return
+	}
+	reason, ok := checkReason(w, request.Reason)
+	if !ok {
+		return
+	}
+
+	price := models.ModelPrice{
+		Model:       request.Model,
+		InputPer1K:  *request.InputPer1K,
+		OutputPer1K: *request.OutputPer1K,
+	}
+	// Without a cached-input price, cached prompt tokens cost the same as the
+	// rest of the prompt.
+	price.CachedInputPer1K = price.InputPer1K
+	if request.CachedInputPer1K != nil {
+		price.CachedInputPer1K = *request.CachedInputPer1K
+	}
+	if request.EffectiveFrom != nil {
+		price.EffectiveFrom = *request.EffectiveFrom
+	}
+
+	created, err := s.service.AddModelPrice(r.Context(), price, reason)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusCreated, modelPriceResponse{Price: created})
+	case errors.Is(err, jobs.ErrInvalidModelPrice):
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, store.ErrPriceExists):
+		writeError(w, http.StatusConflict, "price_exists", "The model already has a price taking effect at effective_from")
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,113 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// permission names what a route lets its caller do.
+type permission string
+
+const (
+	permJobsRead       permission = "jobs:read"
+	permJobsWrite      permission = "jobs:write"
+	permGroupsRead     permission = "groups:read"
+	permGroupsWrite    permission = "groups:write"
+	permSchedulesRead  permission = "schedules:read"
+	permSchedulesWrite permission = "schedules:write"
+	permUsageRead      permission = "usage:read"
+	permAPIKeysManage  permission = "api-keys:manage"
+	permJobsRetry      permission = "admin:jobs:retry"
+	permQueueRead      permission = "admin:queue:read"
+	permRetentionRead  permission = "admin:retention:read"
+	permRetentionWrite permission = "admin:retention:write"
+	permAuditRead      permission = "admin:audit:read"
+	permPricesRead     permission = "admin:prices:read"
+	permPricesWrite    permission = "admin:prices:write"
+	permSpendRead      permission = "admin:spend:read"
+	permSpendWrite     permission = "admin:spend:write"
+	permFallbacksRead  permission = "admin:fallbacks:read"
+	permFallbacksWrite permission = "admin:fallbacks:write"
+	permBreakersRead   permission = "admin:breakers:read"
+)
+
+// rolePermissions lists what each role may do. Roles build on each other, apart
+// from operator, which holds every permission.
+var rolePermissions = map[models.Role][]permission{
+	models.RoleViewer: {
+		permJobsRead, permGroupsRead, permSchedulesRead, permUsageRead,
+	},
+	models.RoleTenantUser: {
+		permJobsRead, permGroupsRead, permSchedulesRead, permUsageRead,
+		permJobsWrite, permGroupsWrite, permSchedulesWrite,
+	},
+	models.RoleTenantAdmin: {
+		permJobsRead, permGroupsRead, permSchedulesRead, permUsageRead,
+		permJobsWrite, permGroupsWrite, permSchedulesWrite,
+		permAPIKeysManage,
+	},
+	models.RoleOperator: {
+		permJobsRead, permGroupsRead, permSchedulesRead, permUsageRead,
+		permJobsWrite, permGroupsWrite, permSchedulesWrite,
+		permAPIKeysManage,
+		permJobsRetry, permQueueRead, permRetentionRead, permRetentionWrite,
+		permAuditRead, permPricesRead, permPricesWrite, permSpendRead, permSpendWrite,
+		permFallbacksRead, permFallbacksWrite, permBreakersRead,
+	},
+}
+
+func roleAllows(role models.Role, required permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == required {
+			return true
+		}
+	}
+	return false
+}
+
+// methodPermissions maps each method a route accepts to the permission it
+// needs. Methods that are not listed are rejected with 405.
+type methodPermissions map[string]permission
+
+// handle registers a route together with its permission check.
+func (s *Server) handle(pattern string, permissions methodPermissions, handler http.HandlerFunc) {
+	s.mux.Handle(pattern, s.authorize(permissions, handler))
+}
+
+// authorize rejects callers whose role lacks the permission the request
+// method needs on this route.
+func (s *Server) authorize(permissions methodPermissions, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		required, ok := permissions[r.Method]
+		if !ok {
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			return
+		}
+		if s.auth.Required {
+			caller, _ := principalFrom(r.Context())
+			if !roleAllows(caller.role, required) {
+				writeForbidden(w, caller.role, required)
+				return
+			}
+		}
+		next(w, r)
+	})
+}
+
+type forbiddenResponse struct {
+	Code       string      `json:"code"`
+	Message    string      `json:"message"`
+	Permission permission  `json:"permission"`
+	Role       models.Role `json:"role"`
+}
+
+func writeForbidden(w http.ResponseWriter, role models.Role, missing permission) {
+	writeJSON(w, http.StatusForbidden, forbiddenResponse{
+		Code:       "forbidden",
+		Message:    fmt.Sprintf("Role %s lacks permission %s", role, missing),
+		Permission: missing,
+		Role:       role,
+	})
+}
@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+type putRetentionPolicyRequest struct {
+	JobsRetentionDays *int   `json:"jobs_retention_days"`
+	Archive           *bool  `json:"archive"`
+	Reason            string `json:"reason"`
+}
+
+type retentionPolicyResponse struct {
+	Policy models.RetentionPolicy `json:"policy"`
+}
+
+type listRetentionPoliciesResponse struct {
+	Policies []models.RetentionPolicy `json:"policies"`
+}
+
+func (s *Server) handleRetentionPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	policies, err := s.service.ListRetentionPolicies(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, listRetentionPoliciesResponse{Policies: policies})
+}
+
+func (s *Server) handleRetentionPolicyByTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, action, ok := parsePathTail(r.URL.Path, "/v1/admin/retention/")
+	if !ok || action != "" {
+		writeError(w, http.StatusNotFound, "not_found", "Retention policy not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := s.service.GetRetentionPolicy(r.Context(), tenantID)
+		if err != nil {
+			writeRetentionPolicyError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, retentionPolicyResponse{Policy: policy})
+	case http.MethodPut:
+		s.handlePutRetentionPolicy(w, r, tenantID)
+	case http.MethodDelete:
+		reason, ok := decodeReason(w, r)
+		if !ok {
+			return
+		}
+		if err := s.service.DeleteRetentionPolicy(r.Context(), tenantID, reason); err != nil {
+			writeRetentionPolicyError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handlePutRetentionPolicy(w http.ResponseWriter, r *http.Request, tenantID string) {
+	var request putRetentionPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	if request.JobsRetentionDays == nil {
+		writeError(w, http.StatusBadRequest, "validation_error", "jobs_retention_days is required")
+		return
+	}
+	reason, ok := checkReason(w, request.Reason)
+	if !ok {
+		return
+	}
+
+	policy := models.RetentionPolicy{
+		TenantID:          tenantID,
+		JobsRetentionDays: *request.JobsRetentionDays,
+		Archive:           true,
+	}
+	if request.Archive != nil {
+		policy.Archive = *request.Archive
+	}
+
+	saved, err := s.service.SetRetentionPolicy(r.Context(), policy, reason)
+	if err != nil {
+		writeRetentionPolicyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, retentionPolicyResponse{Policy: saved})
+}
+
+func writeRetentionPolicyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "Retention policy not found")
+	case errors.Is(err, jobs.ErrInvalidRetentionPolicy):
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,158 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+type createScheduleRequest struct {
+	TenantID      string          `json:"tenant_id"`
+	Name          string          `json:"name"`
+	Cron          string          `json:"cron"`
+	Timezone      string          `json:"timezone"`
+	Model         string          `json:"model"`
+	Payload       json.RawMessage `json:"payload"`
+	Priority      int             `json:"priority"`
+	MaxAttempts   int             `json:"max_attempts"`
+	OverlapPolicy string          `json:"overlap_policy"`
+}
+
+type scheduleResponse struct {
+	Schedule models.Schedule `json:"schedule"`
+}
+
+type listSchedulesResponse struct {
+	Schedules []models.Schedule `json:"schedules"`
+}
+
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tenantID, ok := resolveTenant(r, r.URL.Query().Get("tenant"))
+		if !ok {
+			writeTenantMismatch(w)
+			return
+		}
+		schedules, err := s.service.ListSchedules(r.Context(), tenantID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, listSchedulesResponse{Schedules: schedules})
+	case http.MethodPost:
+		s.handleCreateSchedule(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var request createScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	var ok bool
+	if request.TenantID, ok = resolveTenant(r, request.TenantID); !ok {
+		writeTenantMismatch(w)
+		return
+	}
+	if request.TenantID == "" || request.Model == "" || request.Cron == "" {
+		writeError(w, http.StatusBadRequest, "validation_error", "tenant_id, model and cron are required")
+		return
+	}
+
+	schedule, err := s.service.CreateSchedule(r.Context(), models.CreateScheduleInput{
+		TenantID:      request.TenantID,
+		Name:          request.Name,
+		CronExpr:      request.Cron,
+		Timezone:      request.Timezone,
+		Model:         request.Model,
+		PayloadJSON:   request.Payload,
+		Priority:      request.Priority,
+		MaxAttempts:   request.MaxAttempts,
+		OverlapPolicy: request.OverlapPolicy,
+	})
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, scheduleResponse{Schedule: schedule})
+}
+
+func (s *Server) handleScheduleByID(w http.ResponseWriter, r *http.Request) {
+	scheduleID, action, ok := parsePathTail(r.URL.Path, "/v1/schedules/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "Schedule not found")
+		return
+	}
+	if !s.scheduleVisible(w, r, scheduleID) {
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		schedule, err := s.service.GetSchedule(r.Context(), scheduleID)
+		if err != nil {
+			writeScheduleError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, scheduleResponse{Schedule: schedule})
+	case action == "" && r.Method == http.MethodDelete:
+		reason, ok := decodeReason(w, r)
+		if !ok {
+			return
+		}
+		if err := s.service.DeleteSchedule(r.Context(), scheduleID, reason); err != nil {
+			writeScheduleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case action == "pause" && r.Method == http.MethodPost:
+		s.handleScheduleAction(w, r, scheduleID, s.service.PauseSchedule)
+	case action == "resume" && r.Method == http.MethodPost:
+		s.handleScheduleAction(w, r, scheduleID, s.service.ResumeSchedule)
+	case action != "" && action != "pause" && action != "resume":
+		writeError(w, http.StatusNotFound, "not_found", "Schedule not found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handleScheduleAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	scheduleID string,
+	action func(ctx context.Context, scheduleID string, reason string) (models.Schedule, error),
+) {
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+	schedule, err := action(r.Context(), scheduleID, reason)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, scheduleResponse{Schedule: schedule})
+}
+
+func writeScheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "Schedule not found")
+	case errors.Is(err, jobs.ErrScheduleNeverFires):
+		writeError(w, http.StatusUnprocessableEntity, "schedule_never_fires", err.Error())
+	case errors.Is(err, jobs.ErrInvalidSchedule):
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -1,11 +1,15 @@
 package httpapi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"job-queue-llm-orchestrator/backend/internal/jobs"
 	"job-queue-llm-orchestrator/backend/internal/models"
@@ -13,28 +17,71 @@ import (
 )
 
 type Server struct {
-	service *jobs.Service
-	mux     *http.ServeMux
+	service      *jobs.Service
+	mux          *http.ServeMux
+	batchMaxJobs int
+	auth         AuthConfig
 }
 
-func NewServer(service *jobs.Service) *Server {
+func NewServer(service *jobs.Service, batchMaxJobs int, auth AuthConfig) *Server {
 	server := &Server{
-		service: service,
-		mux:     http.NewServeMux(),
+		service:      service,
+		mux:          http.NewServeMux(),
+		batchMaxJobs: batchMaxJobs,
+		auth:         auth,
 	}
 	server.routes()
 	return server
 }
 
 func (s *Server) Handler() http.Handler {
-	return s.mux
+	return withTracing(s.withAuth(s.mux))
 }
 
 func (s *Server) routes() {
 	s.mux.HandleFunc("/healthz", s.handleHealthz)
-	s.mux.HandleFunc("/v1/jobs", s.handleJobs)
-	s.mux.HandleFunc("/v1/jobs/", s.handleJobByID)
-	s.mux.HandleFunc("/v1/admin/jobs/", s.handleAdminJobs)
+
+	jobsAccess := methodPermissions{http.MethodGet: permJobsRead, http.MethodPost: permJobsWrite}
+	s.handle("/v1/jobs", jobsAccess, s.handleJobs)
+	s.handle("/v1/jobs/batch", methodPermissions{http.MethodPost: permJobsWrite}, s.handleCreateJobsBatch)
+	s.handle("/v1/jobs/", jobsAccess, s.handleJobByID)
+	s.handle("/v1/groups", methodPermissions{http.MethodPost: permGroupsWrite}, s.handleCreateGroup)
+	s.handle("/v1/groups/", methodPermissions{http.MethodGet: permGroupsRead, http.MethodPost: permGroupsWrite}, s.handleGroupByID)
+	s.handle("/v1/schedules", methodPermissions{http.MethodGet: permSchedulesRead, http.MethodPost: permSchedulesWrite}, s.handleSchedules)
+	s.handle("/v1/schedules/", methodPermissions{
+		http.MethodGet:    permSchedulesRead,
+		http.MethodPost:   permSchedulesWrite,
+		http.MethodDelete: permSchedulesWrite,
+	}, s.handleScheduleByID)
+
+	s.handle("/v1/usage", methodPermissions{http.MethodGet: permUsageRead}, s.handleUsage)
+	s.handle("/v1/admin/jobs/", methodPermissions{http.MethodPost: permJobsRetry}, s.handleAdminJobs)
+	s.handle("/v1/admin/queue/pending", methodPermissions{http.MethodGet: permQueueRead}, s.handleQueuePending)
+	s.handle("/v1/admin/retention", methodPermissions{http.MethodGet: permRetentionRead}, s.handleRetentionPolicies)
+	s.handle("/v1/admin/retention/", methodPermissions{
+		http.MethodGet:    permRetentionRead,
+		http.MethodPut:    permRetentionWrite,
+		http.MethodDelete: permRetentionWrite,
+	}, s.handleRetentionPolicyByTenant)
+	apiKeys := methodPermissions{http.MethodGet: permAPIKeysManage, http.MethodPost: permAPIKeysManage}
+	s.handle("/v1/admin/api-keys", apiKeys, s.handleAPIKeys)
+	s.handle("/v1/admin/api-keys/", apiKeys, s.handleAPIKeyByID)
+	s.handle("/v1/admin/audit", methodPermissions{http.MethodGet: permAuditRead}, s.handleAudit)
+	s.handle("/v1/admin/model-prices", methodPermissions{http.MethodGet: permPricesRead, http.MethodPost: permPricesWrite}, s.handleModelPrices)
+	s.handle("/v1/admin/spend-caps", methodPermissions{http.MethodGet: permSpendRead}, s.handleSpendCaps)
+	s.handle("/v1/admin/spend-caps/", methodPermissions{
+		http.MethodGet:    permSpendRead,
+		http.MethodPut:    permSpendWrite,
+		http.MethodPost:   permSpendWrite,
+		http.MethodDelete: permSpendWrite,
+	}, s.handleSpendCapByTenant)
+	s.handle("/v1/admin/fallback-policies", methodPermissions{http.MethodGet: permFallbacksRead}, s.handleFallbackPolicies)
+	s.handle("/v1/admin/fallback-policies/", methodPermissions{
+		http.MethodGet:    permFallbacksRead,
+		http.MethodPut:    permFallbacksWrite,
+		http.MethodDelete: permFallbacksWrite,
+	}, s.handleFallbackPolicy)
+	s.handle("/v1/admin/circuit-breakers", methodPermissions{http.MethodGet: permBreakersRead}, s.handleCircuitBreakers)
 }
 
 func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
@@ -57,28 +104,143 @@ func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	status := strings.TrimSpace(query.Get("status"))
-	tenant := strings.TrimSpace(query.Get("tenant"))
-	model := strings.TrimSpace(query.Get("model"))
+	filter, err := parseListJobsFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	var ok bool
+	if filter.TenantID, ok = resolveTenant(r, filter.TenantID); !ok {
+		writeTenantMismatch(w)
+		return
+	}
+
+	page, err := s.service.ListJobs(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "cursor is invalid or does not match the requested sort")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, listJobsResponse{
+		Jobs:       page.Jobs,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	})
+}
+
+func parseListJobsFilter(query url.Values) (models.ListJobsFilter, error) {
+	filter := models.ListJobsFilter{
+		TenantID:       strings.TrimSpace(query.Get("tenant")),
+		Model:          strings.TrimSpace(query.Get("model")),
+		ErrorCode:      strings.TrimSpace(query.Get("error_code")),
+		IdempotencyKey: strings.TrimSpace(query.Get("idempotency_key")),
+		TraceID:        strings.TrimSpace(query.Get("trace_id")),
+		Cursor:         strings.TrimSpace(query.Get("cursor")),
+		Limit:          100,
+	}
+
+	// status accepts both repeated parameters and comma separated values.
+	for _, rawStatus := range query["status"] {
+		for _, status := range strings.Split(rawStatus, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				continue
+			}
+			if !isKnownJobStatus(models.JobStatus(status)) {
+				return models.ListJobsFilter{}, fmt.Errorf("unknown status %q", status)
+			}
+			filter.Statuses = append(filter.Statuses, models.JobStatus(status))
+		}
+	}
 
-	limit := 100
 	if rawLimit := strings.TrimSpace(query.Get("limit")); rawLimit != "" {
 		parsedLimit, err := strconv.Atoi(rawLimit)
 		if err != nil || parsedLimit <= 0 {
-			writeError(w, http.StatusBadRequest, "validation_error", "limit must be a positive integer")
-			return
+			return models.ListJobsFilter{}, errors.New("limit must be a positive integer")
 		}
-		limit = parsedLimit
+		filter.Limit = parsedLimit
 	}
 
-	jobsList, err := s.service.ListJobs(r.Context(), status, tenant, model, limit)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
-		return
+	timeParams := []struct {
+		name   string
+		target **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"finished_after", &filter.FinishedAfter},
+		{"finished_before", &filter.FinishedBefore},
+	}
+	for _, param := range timeParams {
+		raw := strings.TrimSpace(query.Get(param.name))
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return models.ListJobsFilter{}, fmt.Errorf("%s must be an RFC 3339 timestamp", param.name)
+		}
+		*param.target = &parsed
+	}
+
+	intParams := []struct {
+		name   string
+		target **int
+	}{
+		{"priority_min", &filter.MinPriority},
+		{"priority_max", &filter.MaxPriority},
+	}
+	for _, param := range intParams {
+		raw := strings.TrimSpace(query.Get(param.name))
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return models.ListJobsFilter{}, fmt.Errorf("%s must be an integer", param.name)
+		}
+		*param.target = &parsed
+	}
+	if filter.MinPriority != nil && filter.MaxPriority != nil && *filter.MinPriority > *filter.MaxPriority {
+		return models.ListJobsFilter{}, errors.New("priority_min must not be greater than priority_max")
+	}
+
+	switch sort := strings.TrimSpace(query.Get("sort")); sort {
+	case "", models.JobSortCreatedDesc, models.JobSortCreatedAsc, models.JobSortPriorityDesc:
+		filter.Sort = sort
+	default:
+		return models.ListJobsFilter{}, fmt.Errorf("sort must be one of %s, %s, %s", models.JobSortCreatedDesc, models.JobSortCreatedAsc, models.JobSortPriorityDesc)
 	}
 
-	writeJSON(w, http.StatusOK, listJobsResponse{Jobs: jobsList})
+	if rawTotal := strings.TrimSpace(query.Get("include_total")); rawTotal != "" {
+		includeTotal, err := strconv.ParseBool(rawTotal)
+		if err != nil {
+			return models.ListJobsFilter{}, errors.New("include_total must be a boolean")
+		}
+		filter.IncludeTotal = includeTotal
+	}
+
+	return filter, nil
+}
+
+func isKnownJobStatus(status models.JobStatus) bool {
+	switch status {
+	case models.JobStatusQueued,
+		models.JobStatusRunning,
+		models.JobStatusSucceeded,
+		models.JobStatusFailed,
+		models.JobStatusRetryScheduled,
+		models.JobStatusDLQ,
+		models.JobStatusCancelled,
+		models.JobStatusBlocked,
+		models.JobStatusScheduled:
+		return true
+	default:
+		return false
+	}
 }
 
 func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
@@ -88,8 +250,13 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if request.TenantID == "" || request.Model == "" {
-		writeError(w, http.StatusBadRequest, "validation_error", "tenant_id and model are required")
+	var ok bool
+	if request.TenantID, ok = resolveTenant(r, request.TenantID); !ok {
+		writeTenantMismatch(w)
+		return
+	}
+	if err := request.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
 
@@ -98,17 +265,26 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 		idempotencyKey = request.IdempotencyKey
 	}
 
-	input := models.CreateJobInput{
-		TenantID:       request.TenantID,
-		Priority:       request.Priority,
-		Model:          request.Model,
-		PayloadJSON:    request.Payload,
-		IdempotencyKey: idempotencyKey,
-		MaxAttempts:    request.MaxAttempts,
-	}
+	input := request.toInput(idempotencyKey)
 
 	job, existing, err := s.service.CreateJob(r.Context(), input)
 	if err != nil {
+		if errors.Is(err, jobs.ErrGroupNotFound) {
+			writeError(w, http.StatusUnprocessableEntity, "group_not_found", "group_id does not reference a group of this tenant")
+			return
+		}
+		if errors.Is(err, jobs.ErrSpendCapExceeded) {
+			writeError(w, http.StatusPaymentRequired, "spend_cap_exceeded", err.Error())
+			return
+		}
+		if errors.Is(err, store.ErrDependencyNotFound) {
+			writeError(w, http.StatusUnprocessableEntity, "dependency_not_found", "depends_on references a job that does not exist for this tenant")
+			return
+		}
+		if errors.Is(err, store.ErrIdempotencyMismatch) {
+			writeError(w, http.StatusUnprocessableEntity, "idempotency_mismatch", "Idempotency key was already used for a different request")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
@@ -124,12 +300,146 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleCreateJobsBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	var request createJobsBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+
+	switch request.Mode {
+	case "":
+		request.Mode = batchModePartial
+	case batchModePartial, batchModeAtomic:
+	default:
+		writeError(w, http.StatusBadRequest, "validation_error", "mode must be partial or atomic")
+		return
+	}
+	if len(request.Jobs) == 0 {
+		writeError(w, http.StatusBadRequest, "validation_error", "jobs must contain at least one job")
+		return
+	}
+	if s.batchMaxJobs > 0 && len(request.Jobs) > s.batchMaxJobs {
+		writeError(w, http.StatusRequestEntityTooLarge, "batch_too_large", fmt.Sprintf("a batch may contain at most %d jobs", s.batchMaxJobs))
+		return
+	}
+
+	response := createJobsBatchResponse{
+		Mode:    request.Mode,
+		Results: make([]batchJobResult, len(request.Jobs)),
+	}
+	inputs := make([]models.CreateJobInput, 0, len(request.Jobs))
+	inputIndexes := make([]int, 0, len(request.Jobs))
+	for index, item := range request.Jobs {
+		response.Results[index].Index = index
+		tenantID, ok := resolveTenant(r, item.TenantID)
+		if !ok {
+			writeTenantMismatch(w)
+			return
+		}
+		item.TenantID = tenantID
+		err := item.validate()
+		if err == nil && len(item.DependsOn) > 0 {
+			err = errors.New("depends_on is not supported in batch submissions")
+		}
+		if err != nil {
+			response.Results[index].Status = batchResultInvalid
+			response.Results[index].Error = &errorResponse{Code: "validation_error", Message: err.Error()}
+			response.Invalid++
+			continue
+		}
+		if item.GroupID == "" {
+			item.GroupID = request.GroupID
+		}
+		inputs = append(inputs, item.toInput(item.IdempotencyKey))
+		inputIndexes = append(inputIndexes, index)
+	}
+
+	if response.Invalid > 0 && request.Mode == batchModeAtomic {
+		writeJSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	results, err := s.service.CreateJobsBatch(r.Context(), inputs, request.Mode == batchModeAtomic)
+	if errors.Is(err, store.ErrIdempotencyMismatch) {
+		// Atomic batch rolled back: report which inputs conflicted.
+		for i, result := range results {
+			if result.Mismatch {
+				markIdempotencyMismatch(&response, inputIndexes[i])
+			}
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, jobs.ErrGroupNotFound) {
+			writeError(w, http.StatusUnprocessableEntity, "group_not_found", "group_id does not reference a group of this tenant")
+			return
+		}
+		if errors.Is(err, jobs.ErrSpendCapExceeded) {
+			writeError(w, http.StatusPaymentRequired, "spend_cap_exceeded", err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	for i, result := range results {
+		if result.Mismatch {
+			markIdempotencyMismatch(&response, inputIndexes[i])
+			continue
+		}
+		item := &response.Results[inputIndexes[i]]
+		if result.Rejected != nil {
+			item.Status = batchResultInvalid
+			item.Error = &errorResponse{Code: "spend_cap_exceeded", Message: result.Rejected.Error()}
+			response.Invalid++
+			continue
+		}
+		job := result.Job
+		item.Job = &job
+		if result.Existing {
+			item.Status = batchResultReplayed
+			response.Replayed++
+		} else {
+			item.Status = batchResultCreated
+			response.Created++
+		}
+	}
+
+	statusCode := http.StatusOK
+	switch {
+	case response.Invalid > 0:
+		statusCode = http.StatusMultiStatus
+	case response.Created > 0:
+		statusCode = http.StatusCreated
+	}
+	writeJSON(w, statusCode, response)
+}
+
+func markIdempotencyMismatch(response *createJobsBatchResponse, index int) {
+	response.Results[index].Status = batchResultInvalid
+	response.Results[index].Error = &errorResponse{
+		Code:    "idempotency_mismatch",
+		Message: "Idempotency key was already used for a different request",
+	}
+	response.Invalid++
+}
+
 func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
 	jobID, action, ok := parsePathTail(r.URL.Path, "/v1/jobs/")
 	if !ok {
 		writeError(w, http.StatusNotFound, "not_found", "Job not found")
 		return
 	}
+	if !s.jobVisible(w, r, jobID) {
+		return
+	}
 
 	if action == "" && r.Method == http.MethodGet {
 		s.handleGetJobByID(w, r, jobID)
@@ -141,7 +451,17 @@ func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if action != "" && action != "cancel" {
+	if action == "attempts" && r.Method == http.MethodGet {
+		s.handleListJobAttempts(w, r, jobID)
+		return
+	}
+
+	if action == "reschedule" && r.Method == http.MethodPost {
+		s.handleRescheduleJob(w, r, jobID)
+		return
+	}
+
+	if action != "" && action != "cancel" && action != "attempts" && action != "reschedule" {
 		writeError(w, http.StatusNotFound, "not_found", "Job not found")
 		return
 	}
@@ -163,8 +483,63 @@ func (s *Server) handleGetJobByID(w http.ResponseWriter, r *http.Request, jobID
 	writeJSON(w, http.StatusOK, snapshot)
 }
 
+func (s *Server) handleListJobAttempts(w http.ResponseWriter, r *http.Request, jobID string) {
+	history, err := s.service.ListJobAttempts(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Job not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (s *Server) handleRescheduleJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	var request rescheduleJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+
+	runAt, err := resolveRunAt(request.RunAt, request.DelaySeconds)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if runAt == nil {
+		writeError(w, http.StatusBadRequest, "validation_error", "run_at or delay_seconds is required")
+		return
+	}
+	reason, ok := checkReason(w, request.Reason)
+	if !ok {
+		return
+	}
+
+	job, err := s.service.RescheduleJob(r.Context(), jobID, *runAt, reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			writeError(w, http.StatusNotFound, "not_found", "Job not found")
+		case errors.Is(err, store.ErrInvalidStateTransition):
+			writeError(w, http.StatusConflict, "invalid_state", "Only scheduled or queued jobs can be rescheduled")
+		default:
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, actionJobResponse{Job: job})
+}
+
 func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request, jobID string) {
-	job, err := s.service.CancelJob(r.Context(), jobID)
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+	job, err := s.service.CancelJob(r.Context(), jobID, reason)
 	if err != nil {
 		switch {
 		case errors.Is(err, store.ErrNotFound):
@@ -180,6 +555,108 @@ func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request, jobID s
 	writeJSON(w, http.StatusOK, actionJobResponse{Job: job})
 }
 
+func (s *Server) handleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	var request createGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	var ok bool
+	if request.TenantID, ok = resolveTenant(r, request.TenantID); !ok {
+		writeTenantMismatch(w)
+		return
+	}
+	if request.TenantID == "" {
+		writeError(w, http.StatusBadRequest, "validation_error", "tenant_id is required")
+		return
+	}
+	if request.WebhookURL != "" {
+		parsed, err := url.Parse(request.WebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			writeError(w, http.StatusBadRequest, "validation_error", "webhook_url must be an absolute http(s) URL")
+			return
+		}
+	}
+
+	group, err := s.service.CreateGroup(r.Context(), models.CreateJobGroupInput{
+		TenantID:   request.TenantID,
+		Name:       request.Name,
+		WebhookURL: request.WebhookURL,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, groupResponse{Group: group})
+}
+
+func (s *Server) handleGroupByID(w http.ResponseWriter, r *http.Request) {
+	groupID, action, ok := parsePathTail(r.URL.Path, "/v1/groups/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "Group not found")
+		return
+	}
+	if !s.groupVisible(w, r, groupID) {
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		s.handleGetGroup(w, r, groupID)
+	case action == "cancel" && r.Method == http.MethodPost:
+		s.handleGroupAction(w, r, groupID, s.service.CancelGroup)
+	case action == "retry-failed" && r.Method == http.MethodPost:
+		s.handleGroupAction(w, r, groupID, s.service.RetryFailedGroup)
+	case action != "" && action != "cancel" && action != "retry-failed":
+		writeError(w, http.StatusNotFound, "not_found", "Group not found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handleGetGroup(w http.ResponseWriter, r *http.Request, groupID string) {
+	summary, err := s.service.GetGroupSummary(r.Context(), groupID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Group not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func (s *Server) handleGroupAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	groupID string,
+	action func(ctx context.Context, groupID string, reason string) ([]string, error),
+) {
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+	jobIDs, err := action(r.Context(), groupID, reason)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Group not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, groupActionResponse{GroupID: groupID, JobIDs: jobIDs, Affected: len(jobIDs)})
+}
+
 func (s *Server) handleAdminJobs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
@@ -191,8 +668,12 @@ func (s *Server) handleAdminJobs(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "not_found", "Job not found")
 		return
 	}
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
 
-	job, err := s.service.RetryJob(r.Context(), jobID)
+	job, err := s.service.RetryJob(r.Context(), jobID, reason)
 	if err != nil {
 		switch {
 		case errors.Is(err, store.ErrNotFound):
@@ -208,13 +689,194 @@ func (s *Server) handleAdminJobs(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, actionJobResponse{Job: job})
 }
 
+func (s *Server) handleQueuePending(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	consumers, err := s.service.QueuePendingByConsumer(r.Context())
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotSupported) {
+			writeError(w, http.StatusNotImplemented, "not_supported", "The configured queue backend does not track pending entries")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	var total int64
+	for _, count := range consumers {
+		total += count
+	}
+	writeJSON(w, http.StatusOK, queuePendingResponse{Consumers: consumers, Total: total})
+}
+
 type createJobRequest struct {
 	TenantID       string          `json:"tenant_id"`
 	Priority       int             `json:"priority"`
 	Model          string          `json:"model"`
+	FallbackModels []string        `json:"fallback_models"`
+	FallbackOn     []string        `json:"fallback_on"`
 	Payload        json.RawMessage `json:"payload"`
 	IdempotencyKey string          `json:"idempotency_key"`
 	MaxAttempts    int             `json:"max_attempts"`
+	GroupID        string          `json:"group_id"`
+	DependsOn      []string        `json:"depends_on"`
+	RunAt          *time.Time      `json:"run_at"`
+	DelaySeconds   *int            `json:"delay_seconds"`
+	Deadline       *time.Time      `json:"deadline"`
+	TTLSeconds     *int            `json:"ttl_seconds"`
+}
+
+type rescheduleJobRequest struct {
+	RunAt        *time.Time `json:"run_at"`
+	DelaySeconds *int       `json:"delay_seconds"`
+	Reason       string     `json:"reason"`
+}
+
+const maxDependencies = 50
+
+func (request createJobRequest) validate() error {
+	if request.TenantID == "" || request.Model == "" {
+		return errors.New("tenant_id and model are required")
+	}
+	if len(request.DependsOn) > maxDependencies {
+		return fmt.Errorf("depends_on may list at most %d jobs", maxDependencies)
+	}
+	if err := jobs.ValidateFallbackChain(request.Model, request.FallbackModels, request.FallbackOn); err != nil {
+		return err
+	}
+	runAt, err := resolveRunAt(request.RunAt, request.DelaySeconds)
+	if err != nil {
+		return err
+	}
+	if _, err := resolveDeadline(request.Deadline, request.TTLSeconds, runAt); err != nil {
+		return err
+	}
+	seen := make(map[string]struct{}, len(request.DependsOn))
+	for _, parentID := range request.DependsOn {
+		if strings.TrimSpace(parentID) == "" {
+			return errors.New("depends_on must not contain empty job IDs")
+		}
+		if _, ok := seen[parentID]; ok {
+			return fmt.Errorf("depends_on lists job %s more than once", parentID)
+		}
+		seen[parentID] = struct{}{}
+	}
+	return nil
+}
+
+// resolveRunAt turns the mutually exclusive run_at / delay_seconds fields into
+// an absolute run time. It returns nil when neither is set.
+func resolveRunAt(runAt *time.Time, delaySeconds *int) (*time.Time, error) {
+	if runAt != nil && delaySeconds != nil {
+		return nil, errors.New("run_at and delay_seconds are mutually exclusive")
+	}
+	if delaySeconds != nil {
+		if *delaySeconds < 0 {
+			return nil, errors.New("delay_seconds must not be negative")
+		}
+		resolved := time.Now().Add(time.Duration(*delaySeconds) * time.Second)
+		return &resolved, nil
+	}
+	return runAt, nil
+}
+
+// resolveDeadline turns the mutually exclusive deadline / ttl_seconds fields
+// into an absolute deadline. The TTL counts from run_at for delayed jobs and
+// from now otherwise. It returns nil when neither is set.
+func resolveDeadline(deadline *time.Time, ttlSeconds *int, runAt *time.Time) (*time.Time, error) {
+	if deadline != nil && ttlSeconds != nil {
+		return nil, errors.New("deadline and ttl_seconds are mutually exclusive")
+	}
+	if ttlSeconds != nil {
+		if *ttlSeconds <= 0 {
+			return nil, errors.New("ttl_seconds must be positive")
+		}
+		start := time.Now()
+		if runAt != nil && runAt.After(start) {
+			start = *runAt
+		}
+		resolved := start.Add(time.Duration(*ttlSeconds) * time.Second)
+		return &resolved, nil
+	}
+	if deadline == nil {
+		return nil, nil
+	}
+	if !deadline.After(time.Now()) {
+		return nil, errors.New("deadline must be in the future")
+	}
+	if runAt != nil && !deadline.After(*runAt) {
+		return nil, errors.New("deadline must be after run_at")
+	}
+	return deadline, nil
+}
+
+func (request createJobRequest) toInput(idempotencyKey string) models.CreateJobInput {
+	// validate has already rejected conflicting scheduling fields.
+	runAt, _ := resolveRunAt(request.RunAt, request.DelaySeconds)
+	deadline, _ := resolveDeadline(request.Deadline, request.TTLSeconds, runAt)
+	return models.CreateJobInput{
+		TenantID:       request.TenantID,
+		Priority:       request.Priority,
+		Model:          request.Model,
+		FallbackModels: request.FallbackModels,
+		FallbackOn:     request.FallbackOn,
+		PayloadJSON:    request.Payload,
+		IdempotencyKey: idempotencyKey,
+		MaxAttempts:    request.MaxAttempts,
+		GroupID:        request.GroupID,
+		DependsOn:      request.DependsOn,
+		RunAt:          runAt,
+		Deadline:       deadline,
+	}
+}
+
+const (
+	batchModePartial = "partial"
+	batchModeAtomic  = "atomic"
+
+	batchResultCreated  = "created"
+	batchResultReplayed = "replayed"
+	batchResultInvalid  = "invalid"
+)
+
+type createJobsBatchRequest struct {
+	Mode    string             `json:"mode"`
+	GroupID string             `json:"group_id"`
+	Jobs    []createJobRequest `json:"jobs"`
+}
+
+type batchJobResult struct {
+	Index  int            `json:"index"`
+	Status string         `json:"status"`
+	Job    *models.Job    `json:"job,omitempty"`
+	Error  *errorResponse `json:"error,omitempty"`
+}
+
+type createJobsBatchResponse struct {
+	Mode     string           `json:"mode"`
+	Created  int              `json:"created"`
+	Replayed int              `json:"replayed"`
+	Invalid  int              `json:"invalid"`
+	Results  []batchJobResult `json:"results"`
+}
+
+type createGroupRequest struct {
+	TenantID   string `json:"tenant_id"`
+	Name       string `json:"name"`
+	WebhookURL string `json:"webhook_url"`
+}
+
+type groupResponse struct {
+	Group models.JobGroup `json:"group"`
+}
+
+type groupActionResponse struct {
+	GroupID  string   `json:"group_id"`
+	Affected int      `json:"affected"`
+	JobIDs   []string `json:"job_ids"`
 }
 
 type createJobResponse struct {
@@ -227,7 +889,14 @@ type actionJobResponse struct {
 }
 
 type listJobsResponse struct {
-	Jobs []models.Job `json:"jobs"`
+	Jobs       []models.Job `json:"jobs"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	Total      *int64       `json:"total,omitempty"`
+}
+
+type queuePendingResponse struct {
+	Consumers map[string]int64 `json:"consumers"`
+	Total     int64            `json:"total"`
 }
 
 type errorResponse struct {
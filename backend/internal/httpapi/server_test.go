@@ -0,0 +1,677 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/breaker"
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/queue"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+type testAPI struct {
+	t       *testing.T
+	handler http.Handler
+	store   *store.MemoryStore
+}
+
+func newTestAPI(t *testing.T, batchMaxJobs int) *testAPI {
+	t.Helper()
+	memStore := store.NewMemoryStore()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := jobs.NewService(memStore, queue.NewMemoryQueue(30*time.Second), nil, 0, logger)
+	return &testAPI{t: t, handler: NewServer(service, batchMaxJobs, AuthConfig{}).Handler(), store: memStore}
+}
+
+// do sends a request and decodes the JSON response into out when it is set.
+func (a *testAPI) do(method string, path string, body string, headers map[string]string, out any) int {
+	a.t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+	request := httptest.NewRequest(method, path, reader)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	recorder := httptest.NewRecorder()
+	a.handler.ServeHTTP(recorder, request)
+
+	if out != nil && recorder.Body.Len() > 0 {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			a.t.Fatalf("%s %s: decode %q: %v", method, path, recorder.Body.String(), err)
+		}
+	}
+	return recorder.Code
+}
+
+func (a *testAPI) createJob(body string) models.Job {
+	a.t.Helper()
+	var response createJobResponse
+	if code := a.do(http.MethodPost, "/v1/jobs", body, nil, &response); code != http.StatusCreated {
+		a.t.Fatalf("create job: status %d", code)
+	}
+	return response.Job
+}
+
+func expectError(t *testing.T, gotStatus int, got errorResponse, wantStatus int, wantCode string) {
+	t.Helper()
+	if gotStatus != wantStatus || got.Code != wantCode {
+		t.Fatalf("response = %d %q (%s), want %d %q", gotStatus, got.Code, got.Message, wantStatus, wantCode)
+	}
+}
+
+func TestCreateJobValidation(t *testing.T) {
+	api := newTestAPI(t, 10)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	soon := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	later := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{name: "malformed json", body: `{"tenant_id":`, code: "invalid_json"},
+		{name: "missing model", body: `{"tenant_id":"acme"}`, code: "validation_error"},
+		{name: "run_at and delay", body: `{"tenant_id":"acme","model":"m","run_at":"` + later + `","delay_seconds":5}`, code: "validation_error"},
+		{name: "negative delay", body: `{"tenant_id":"acme","model":"m","delay_seconds":-1}`, code: "validation_error"},
+		{name: "deadline and ttl", body: `{"tenant_id":"acme","model":"m","deadline":"` + later + `","ttl_seconds":5}`, code: "validation_error"},
+		{name: "zero ttl", body: `{"tenant_id":"acme","model":"m","ttl_seconds":0}`, code: "validation_error"},
+		{name: "deadline in the past", body: `{"tenant_id":"acme","model":"m","deadline":"` + past + `"}`, code: "validation_error"},
+		{name: "deadline before run_at", body: `{"tenant_id":"acme","model":"m","run_at":"` + later + `","deadline":"` + soon + `"}`, code: "validation_error"},
+		{name: "duplicate dependency", body: `{"tenant_id":"acme","model":"m","depends_on":["a","a"]}`, code: "validation_error"},
+		{name: "empty dependency", body: `{"tenant_id":"acme","model":"m","depends_on":[" "]}`, code: "validation_error"},
+		{name: "fallback repeats model", body: `{"tenant_id":"acme","model":"m","fallback_models":["m"]}`, code: "validation_error"},
+		{name: "fallback_on without models", body: `{"tenant_id":"acme","model":"m","fallback_on":["PROVIDER_TIMEOUT"]}`, code: "validation_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response errorResponse
+			status := api.do(http.MethodPost, "/v1/jobs", tt.body, nil, &response)
+			expectError(t, status, response, http.StatusBadRequest, tt.code)
+		})
+	}
+}
+
+func TestCreateJobReferenceErrors(t *testing.T) {
+	api := newTestAPI(t, 10)
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{name: "unknown group", body: `{"tenant_id":"acme","model":"m","group_id":"missing"}`, code: "group_not_found"},
+		{name: "unknown dependency", body: `{"tenant_id":"acme","model":"m","depends_on":["missing"]}`, code: "dependency_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response errorResponse
+			status := api.do(http.MethodPost, "/v1/jobs", tt.body, nil, &response)
+			expectError(t, status, response, http.StatusUnprocessableEntity, tt.code)
+		})
+	}
+}
+
+func TestCreateJobIdempotencyHeader(t *testing.T) {
+	api := newTestAPI(t, 10)
+	headers := map[string]string{"Idempotency-Key": "demo-1"}
+	body := `{"tenant_id":"acme","model":"m","payload":{"prompt":"hi","n":1}}`
+
+	var created createJobResponse
+	if status := api.do(http.MethodPost, "/v1/jobs", body, headers, &created); status != http.StatusCreated {
+		t.Fatalf("create: status %d", status)
+	}
+	if created.IdempotentReplay || created.Job.IdempotencyKey != "demo-1" {
+		t.Fatalf("created = %+v", created)
+	}
+
+	var replay createJobResponse
+	reordered := `{"tenant_id":"acme","model":"m","payload":{"n":1,"prompt":"hi"}}`
+	if status := api.do(http.MethodPost, "/v1/jobs", reordered, headers, &replay); status != http.StatusOK {
+		t.Fatalf("replay: status %d", status)
+	}
+	if !replay.IdempotentReplay || replay.Job.ID != created.Job.ID {
+		t.Fatalf("replay = %+v, want job %s", replay, created.Job.ID)
+	}
+
+	var mismatch errorResponse
+	status := api.do(http.MethodPost, "/v1/jobs", `{"tenant_id":"acme","model":"other"}`, headers, &mismatch)
+	expectError(t, status, mismatch, http.StatusUnprocessableEntity, "idempotency_mismatch")
+}
+
+func TestCreateJobScheduling(t *testing.T) {
+	api := newTestAPI(t, 10)
+
+	delayed := api.createJob(`{"tenant_id":"acme","model":"m","delay_seconds":3600,"ttl_seconds":60}`)
+	if delayed.Status != models.JobStatusScheduled || delayed.RunAt == nil || delayed.Deadline == nil {
+		t.Fatalf("delayed job = %+v", delayed)
+	}
+	// The TTL of a delayed job counts from run_at.
+	if got := delayed.Deadline.Sub(*delayed.RunAt); got != time.Minute {
+		t.Fatalf("deadline - run_at = %v, want 1m", got)
+	}
+
+	var rescheduled actionJobResponse
+	if status := api.do(http.MethodPost, "/v1/jobs/"+delayed.ID+"/reschedule", `{"delay_seconds":0}`, nil, &rescheduled); status != http.StatusOK {
+		t.Fatalf("reschedule: status %d", status)
+	}
+	if rescheduled.Job.Status != models.JobStatusQueued {
+		t.Fatalf("rescheduled status = %s, want queued", rescheduled.Job.Status)
+	}
+
+	var response errorResponse
+	status := api.do(http.MethodPost, "/v1/jobs/"+delayed.ID+"/reschedule", `{}`, nil, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	status = api.do(http.MethodPost, "/v1/jobs/missing/reschedule", `{"delay_seconds":5}`, nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+}
+
+func TestJobLifecycleEndpoints(t *testing.T) {
+	api := newTestAPI(t, 10)
+	parent := api.createJob(`{"tenant_id":"acme","model":"m"}`)
+	child := api.createJob(`{"tenant_id":"acme","model":"m","depends_on":["` + parent.ID + `"]}`)
+	if child.Status != models.JobStatusBlocked {
+		t.Fatalf("child status = %s, want blocked", child.Status)
+	}
+
+	var snapshot models.JobSnapshot
+	if status := api.do(http.MethodGet, "/v1/jobs/"+child.ID, "", nil, &snapshot); status != http.StatusOK {
+		t.Fatalf("get: status %d", status)
+	}
+	if len(snapshot.DependsOn) != 1 || snapshot.DependsOn[0] != parent.ID {
+		t.Fatalf("depends_on = %v, want [%s]", snapshot.DependsOn, parent.ID)
+	}
+
+	var response errorResponse
+	status := api.do(http.MethodGet, "/v1/jobs/missing", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+	status = api.do(http.MethodGet, "/v1/jobs/"+parent.ID+"/unknown", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+	status = api.do(http.MethodDelete, "/v1/jobs/"+parent.ID, "", nil, &response)
+	expectError(t, status, response, http.StatusMethodNotAllowed, "method_not_allowed")
+
+	var cancelled actionJobResponse
+	if status := api.do(http.MethodPost, "/v1/jobs/"+parent.ID+"/cancel", "", nil, &cancelled); status != http.StatusOK {
+		t.Fatalf("cancel: status %d", status)
+	}
+	if cancelled.Job.Status != models.JobStatusCancelled {
+		t.Fatalf("cancelled status = %s", cancelled.Job.Status)
+	}
+	status = api.do(http.MethodPost, "/v1/jobs/"+parent.ID+"/cancel", "", nil, &response)
+	expectError(t, status, response, http.StatusConflict, "invalid_state")
+
+	// The cancellation cascaded to the blocked child.
+	if status := api.do(http.MethodGet, "/v1/jobs/"+child.ID, "", nil, &snapshot); status != http.StatusOK {
+		t.Fatalf("get child: status %d", status)
+	}
+	if snapshot.Job.Status != models.JobStatusCancelled || snapshot.Job.ErrorCode != "DEPENDENCY_FAILED" {
+		t.Fatalf("child = %s/%s, want cancelled/DEPENDENCY_FAILED", snapshot.Job.Status, snapshot.Job.ErrorCode)
+	}
+
+	var retried actionJobResponse
+	if status := api.do(http.MethodPost, "/v1/admin/jobs/"+parent.ID+"/retry", "", nil, &retried); status != http.StatusOK {
+		t.Fatalf("retry: status %d", status)
+	}
+	if retried.Job.Status != models.JobStatusQueued {
+		t.Fatalf("retried status = %s, want queued", retried.Job.Status)
+	}
+	status = api.do(http.MethodPost, "/v1/admin/jobs/missing/retry", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+
+	ctx := context.Background()
+	if _, _, err := api.store.MarkJobRunning(ctx, parent.ID, "worker-1"); err != nil {
+		t.Fatalf("mark running: %v", err)
+	}
+	if err := api.store.MarkJobSucceeded(ctx, parent.ID, "worker-1", models.Usage{PromptTokens: 80, CompletionTokens: 40}, 0.0012, nil, json.RawMessage(`{"text":"ok"}`)); err != nil {
+		t.Fatalf("mark succeeded: %v", err)
+	}
+	var history models.JobAttemptHistory
+	if status := api.do(http.MethodGet, "/v1/jobs/"+parent.ID+"/attempts", "", nil, &history); status != http.StatusOK {
+		t.Fatalf("attempts: status %d", status)
+	}
+	if len(history.Attempts) != 1 || history.Attempts[0].WorkerID != "worker-1" || history.Totals.Tokens != 120 {
+		t.Fatalf("history = %+v", history)
+	}
+}
+
+func TestListJobsEndpoint(t *testing.T) {
+	api := newTestAPI(t, 10)
+	for i := 0; i < 3; i++ {
+		api.createJob(`{"tenant_id":"acme","model":"m"}`)
+	}
+	api.createJob(`{"tenant_id":"globex","model":"m"}`)
+
+	var page listJobsResponse
+	if status := api.do(http.MethodGet, "/v1/jobs?tenant=acme&limit=2&include_total=true&sort=created_asc", "", nil, &page); status != http.StatusOK {
+		t.Fatalf("list: status %d", status)
+	}
+	if len(page.Jobs) != 2 || page.NextCursor == "" || page.Total == nil || *page.Total != 3 {
+		t.Fatalf("first page = %d jobs, cursor %q, total %v", len(page.Jobs), page.NextCursor, page.Total)
+	}
+	firstIDs := map[string]bool{page.Jobs[0].ID: true, page.Jobs[1].ID: true}
+
+	var next listJobsResponse
+	if status := api.do(http.MethodGet, "/v1/jobs?tenant=acme&limit=2&sort=created_asc&cursor="+page.NextCursor, "", nil, &next); status != http.StatusOK {
+		t.Fatalf("next page: status %d", status)
+	}
+	if len(next.Jobs) != 1 || firstIDs[next.Jobs[0].ID] || next.NextCursor != "" {
+		t.Fatalf("second page = %+v", next)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{name: "cursor of another sort", query: "sort=created_desc&cursor=" + page.NextCursor, code: "invalid_cursor"},
+		{name: "garbage cursor", query: "cursor=not-a-cursor", code: "invalid_cursor"},
+		{name: "unknown status", query: "status=finished", code: "validation_error"},
+		{name: "unknown sort", query: "sort=random", code: "validation_error"},
+		{name: "inverted priority range", query: "priority_min=5&priority_max=1", code: "validation_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response errorResponse
+			status := api.do(http.MethodGet, "/v1/jobs?"+tt.query, "", nil, &response)
+			expectError(t, status, response, http.StatusBadRequest, tt.code)
+		})
+	}
+}
+
+func TestCreateJobsBatchEndpoint(t *testing.T) {
+	api := newTestAPI(t, 3)
+
+	var partial createJobsBatchResponse
+	body := `{"jobs":[
+		{"tenant_id":"acme","model":"m","idempotency_key":"row-1"},
+		{"tenant_id":"acme"},
+		{"tenant_id":"acme","model":"m","idempotency_key":"row-1"}
+	]}`
+	if status := api.do(http.MethodPost, "/v1/jobs/batch", body, nil, &partial); status != http.StatusMultiStatus {
+		t.Fatalf("partial batch: status %d", status)
+	}
+	if partial.Created != 1 || partial.Replayed != 1 || partial.Invalid != 1 {
+		t.Fatalf("partial counts = %+v", partial)
+	}
+	if partial.Results[1].Status != batchResultInvalid || partial.Results[2].Job.ID != partial.Results[0].Job.ID {
+		t.Fatalf("partial results = %+v", partial.Results)
+	}
+
+	var atomic createJobsBatchResponse
+	body = `{"mode":"atomic","jobs":[
+		{"tenant_id":"acme","model":"m","idempotency_key":"row-2"},
+		{"tenant_id":"acme","model":"other","idempotency_key":"row-1"}
+	]}`
+	if status := api.do(http.MethodPost, "/v1/jobs/batch", body, nil, &atomic); status != http.StatusUnprocessableEntity {
+		t.Fatalf("atomic batch: status %d", status)
+	}
+	if atomic.Results[1].Error == nil || atomic.Results[1].Error.Code != "idempotency_mismatch" || atomic.Created != 0 {
+		t.Fatalf("atomic results = %+v", atomic)
+	}
+	var page listJobsResponse
+	api.do(http.MethodGet, "/v1/jobs?idempotency_key=row-2", "", nil, &page)
+	if len(page.Jobs) != 0 {
+		t.Fatalf("atomic batch created %d jobs", len(page.Jobs))
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "too large", body: `{"jobs":[{},{},{},{}]}`, status: http.StatusRequestEntityTooLarge, code: "batch_too_large"},
+		{name: "empty", body: `{"jobs":[]}`, status: http.StatusBadRequest, code: "validation_error"},
+		{name: "unknown mode", body: `{"mode":"some","jobs":[{}]}`, status: http.StatusBadRequest, code: "validation_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response errorResponse
+			status := api.do(http.MethodPost, "/v1/jobs/batch", tt.body, nil, &response)
+			expectError(t, status, response, tt.status, tt.code)
+		})
+	}
+}
+
+func TestGroupEndpoints(t *testing.T) {
+	api := newTestAPI(t, 10)
+
+	var response errorResponse
+	status := api.do(http.MethodPost, "/v1/groups", `{"tenant_id":"acme","webhook_url":"ftp://example.com"}`, nil, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+
+	var created groupResponse
+	if status := api.do(http.MethodPost, "/v1/groups", `{"tenant_id":"acme","name":"nightly"}`, nil, &created); status != http.StatusCreated {
+		t.Fatalf("create group: status %d", status)
+	}
+	groupID := created.Group.ID
+
+	var batch createJobsBatchResponse
+	body := `{"group_id":"` + groupID + `","jobs":[{"tenant_id":"acme","model":"m"},{"tenant_id":"acme","model":"m"}]}`
+	if status := api.do(http.MethodPost, "/v1/jobs/batch", body, nil, &batch); status != http.StatusCreated {
+		t.Fatalf("batch into group: status %d", status)
+	}
+
+	var summary models.JobGroupSummary
+	if status := api.do(http.MethodGet, "/v1/groups/"+groupID, "", nil, &summary); status != http.StatusOK {
+		t.Fatalf("get group: status %d", status)
+	}
+	if summary.TotalJobs != 2 || summary.Counts[models.JobStatusQueued] != 2 {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	var action groupActionResponse
+	if status := api.do(http.MethodPost, "/v1/groups/"+groupID+"/cancel", "", nil, &action); status != http.StatusOK {
+		t.Fatalf("cancel group: status %d", status)
+	}
+	if action.Affected != 2 {
+		t.Fatalf("cancel affected = %d, want 2", action.Affected)
+	}
+	api.do(http.MethodGet, "/v1/groups/"+groupID, "", nil, &summary)
+	if summary.Group.CompletedAt == nil || summary.PercentComplete != 100 {
+		t.Fatalf("summary after cancel = %+v", summary)
+	}
+
+	status = api.do(http.MethodGet, "/v1/groups/missing", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+	status = api.do(http.MethodPost, "/v1/groups/missing/retry-failed", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+	status = api.do(http.MethodPost, "/v1/jobs", `{"tenant_id":"globex","model":"m","group_id":"`+groupID+`"}`, nil, &response)
+	expectError(t, status, response, http.StatusUnprocessableEntity, "group_not_found")
+}
+
+func TestScheduleEndpoints(t *testing.T) {
+	api := newTestAPI(t, 10)
+
+	var response errorResponse
+	status := api.do(http.MethodPost, "/v1/schedules", `{"tenant_id":"acme","model":"m","cron":"61 * * * *"}`, nil, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	status = api.do(http.MethodPost, "/v1/schedules", `{"tenant_id":"acme","model":"m"}`, nil, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+
+	var created scheduleResponse
+	body := `{"tenant_id":"acme","model":"m","cron":"@hourly","timezone":"Europe/Berlin","overlap_policy":"queue"}`
+	if status := api.do(http.MethodPost, "/v1/schedules", body, nil, &created); status != http.StatusCreated {
+		t.Fatalf("create schedule: status %d", status)
+	}
+	scheduleID := created.Schedule.ID
+
+	var list listSchedulesResponse
+	api.do(http.MethodGet, "/v1/schedules?tenant=acme", "", nil, &list)
+	if len(list.Schedules) != 1 || list.Schedules[0].ID != scheduleID {
+		t.Fatalf("list = %+v", list)
+	}
+
+	var paused scheduleResponse
+	if status := api.do(http.MethodPost, "/v1/schedules/"+scheduleID+"/pause", "", nil, &paused); status != http.StatusOK || !paused.Schedule.Paused {
+		t.Fatalf("pause: status %d, %+v", status, paused.Schedule)
+	}
+	var resumed scheduleResponse
+	if status := api.do(http.MethodPost, "/v1/schedules/"+scheduleID+"/resume", "", nil, &resumed); status != http.StatusOK || resumed.Schedule.Paused {
+		t.Fatalf("resume: status %d, %+v", status, resumed.Schedule)
+	}
+
+	if status := api.do(http.MethodDelete, "/v1/schedules/"+scheduleID, "", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d", status)
+	}
+	status = api.do(http.MethodGet, "/v1/schedules/"+scheduleID, "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+	status = api.do(http.MethodPut, "/v1/schedules", "", nil, &response)
+	expectError(t, status, response, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestRetentionPolicyEndpoints(t *testing.T) {
+	api := newTestAPI(t, 10)
+
+	var response errorResponse
+	status := api.do(http.MethodPut, "/v1/admin/retention/acme", `{"archive":false}`, nil, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	status = api.do(http.MethodPut, "/v1/admin/retention/acme", `{"jobs_retention_days":-1}`, nil, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	status = api.do(http.MethodGet, "/v1/admin/retention/acme", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+
+	var saved retentionPolicyResponse
+	if status := api.do(http.MethodPut, "/v1/admin/retention/acme", `{"jobs_retention_days":30}`, nil, &saved); status != http.StatusOK {
+		t.Fatalf("put policy: status %d", status)
+	}
+	if saved.Policy.TenantID != "acme" || saved.Policy.JobsRetentionDays != 30 || !saved.Policy.Archive {
+		t.Fatalf("policy = %+v, want 30 days with archiving", saved.Policy)
+	}
+	api.do(http.MethodPut, "/v1/admin/retention/globex", `{"jobs_retention_days":7,"archive":false}`, nil, &saved)
+
+	var list listRetentionPoliciesResponse
+	api.do(http.MethodGet, "/v1/admin/retention", "", nil, &list)
+	if len(list.Policies) != 2 || list.Policies[0].TenantID != "acme" || list.Policies[1].Archive {
+		t.Fatalf("list = %+v", list.Policies)
+	}
+
+	if status := api.do(http.MethodDelete, "/v1/admin/retention/acme", "", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d", status)
+	}
+	status = api.do(http.MethodDelete, "/v1/admin/retention/acme", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+}
+
+func TestUsageEndpoint(t *testing.T) {
+	api := newTestAPI(t, 10)
+	ctx := context.Background()
+	run := func(tenantID string, model string, usage models.Usage, cost float64, succeed bool) {
+		t.Helper()
+		job := api.createJob(`{"tenant_id":"` + tenantID + `","model":"` + model + `"}`)
+		if _, _, err := api.store.MarkJobRunning(ctx, job.ID, "worker-1"); err != nil {
+			t.Fatalf("mark running: %v", err)
+		}
+		var err error
+		if succeed {
+			err = api.store.MarkJobSucceeded(ctx, job.ID, "worker-1", usage, cost, nil, json.RawMessage(`{}`))
+		} else {
+			err = api.store.MarkJobFailed(ctx, job.ID, "worker-1", "PROVIDER_TIMEOUT", "timeout")
+		}
+		if err != nil {
+			t.Fatalf("finish job: %v", err)
+		}
+	}
+	run("acme", "model-a", models.Usage{PromptTokens: 100, CachedPromptTokens: 40, CompletionTokens: 50}, 0.25, true)
+	run("acme", "model-b", models.Usage{PromptTokens: 10, CompletionTokens: 5}, 0.5, true)
+	run("acme", "model-b", models.Usage{}, 0, false)
+	run("globex", "model-a", models.Usage{PromptTokens: 1, CompletionTokens: 1}, 1, true)
+
+	var report models.UsageReport
+	if status := api.do(http.MethodGet, "/v1/usage?tenant=acme&group_by=model", "", nil, &report); status != http.StatusOK {
+		t.Fatalf("usage: status %d", status)
+	}
+	if len(report.Rows) != 2 || report.Rows[0].Model != "model-a" || report.Rows[1].Model != "model-b" {
+		t.Fatalf("rows = %+v", report.Rows)
+	}
+	if b := report.Rows[1]; b.Attempts != 2 || b.FailedAttempts != 1 || b.Tokens != 15 || b.CostUSD != 0.5 {
+		t.Fatalf("model-b = %+v", b)
+	}
+	if totals := report.Totals; totals.Attempts != 3 || totals.CachedPromptTokens != 40 || totals.Tokens != 165 || totals.CostUSD != 0.75 {
+		t.Fatalf("totals = %+v", totals)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	if status := api.do(http.MethodGet, "/v1/usage?from="+today+"&to="+today, "", nil, &report); status != http.StatusOK {
+		t.Fatalf("usage by day: status %d", status)
+	}
+	if len(report.Rows) != 2 || report.Rows[0].TenantID != "acme" || report.Rows[0].Day != today || report.Rows[1].TenantID != "globex" {
+		t.Fatalf("rows by day = %+v", report.Rows)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/v1/usage?tenant=acme&group_by=model&format=csv", nil)
+	recorder := httptest.NewRecorder()
+	api.handler.ServeHTTP(recorder, request)
+	want := "tenant_id,model,attempts,failed_attempts,prompt_tokens,cached_prompt_tokens,completion_tokens,tokens,cost_usd,failed_cost_usd\n" +
+		"acme,model-a,1,0,100,40,50,150,0.250000,0.000000\n" +
+		"acme,model-b,2,1,10,0,5,15,0.500000,0.000000\n"
+	if recorder.Code != http.StatusOK || recorder.Body.String() != want {
+		t.Fatalf("csv = %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	var response errorResponse
+	for _, query := range []string{"group_by=week", "from=2026-02-01&to=2026-01-01", "from=2024-01-01&to=2026-01-01", "from=yesterday", "format=xml"} {
+		status := api.do(http.MethodGet, "/v1/usage?"+query, "", nil, &response)
+		expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	}
+}
+
+func TestSpendCapEndpoints(t *testing.T) {
+	api := newTestAPI(t, 10)
+
+	var response errorResponse
+	for _, body := range []string{`{}`, `{"cap_usd":-5}`} {
+		status := api.do(http.MethodPut, "/v1/admin/spend-caps/acme", body, nil, &response)
+		expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	}
+	status := api.do(http.MethodPost, "/v1/admin/spend-caps/acme/override", `{"cap_usd":5}`, nil, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	status = api.do(http.MethodGet, "/v1/admin/spend-caps/acme/limits", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+
+	var saved spendCapResponse
+	if status := api.do(http.MethodPut, "/v1/admin/spend-caps/acme", `{"cap_usd":0,"reason":"trial ended"}`, nil, &saved); status != http.StatusOK {
+		t.Fatalf("put cap: status %d", status)
+	}
+	if saved.SpendCap.MonthlyCapUSD == nil || *saved.SpendCap.MonthlyCapUSD != 0 {
+		t.Fatalf("spend cap = %+v, want $0", saved.SpendCap)
+	}
+
+	var spend spendStatusResponse
+	api.do(http.MethodGet, "/v1/admin/spend-caps/acme", "", nil, &spend)
+	if !spend.Spend.OverCap || spend.Spend.EffectiveCapUSD == nil {
+		t.Fatalf("spend = %+v, want over its cap", spend.Spend)
+	}
+	status = api.do(http.MethodPost, "/v1/jobs", `{"tenant_id":"acme","model":"m"}`, nil, &response)
+	expectError(t, status, response, http.StatusPaymentRequired, "spend_cap_exceeded")
+	status = api.do(http.MethodPost, "/v1/jobs/batch", `{"mode":"atomic","jobs":[{"tenant_id":"acme","model":"m"}]}`, nil, &response)
+	expectError(t, status, response, http.StatusPaymentRequired, "spend_cap_exceeded")
+
+	// A partial batch reports the over-cap items and creates the rest.
+	var batch createJobsBatchResponse
+	status = api.do(http.MethodPost, "/v1/jobs/batch", `{"jobs":[{"tenant_id":"acme","model":"m"},{"tenant_id":"globex","model":"m"}]}`, nil, &batch)
+	if status != http.StatusMultiStatus || batch.Created != 1 || batch.Invalid != 1 {
+		t.Fatalf("partial batch = %d, %+v", status, batch)
+	}
+	if rejected := batch.Results[0]; rejected.Status != batchResultInvalid || rejected.Error == nil || rejected.Error.Code != "spend_cap_exceeded" {
+		t.Fatalf("over cap item = %+v", rejected)
+	}
+
+	until := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if status := api.do(http.MethodPost, "/v1/admin/spend-caps/acme/override", `{"cap_usd":10,"until":"`+until+`"}`, nil, &saved); status != http.StatusOK {
+		t.Fatalf("override: status %d", status)
+	}
+	api.createJob(`{"tenant_id":"acme","model":"m"}`)
+	var cleared spendCapResponse
+	if status := api.do(http.MethodDelete, "/v1/admin/spend-caps/acme/override", "", nil, &cleared); status != http.StatusOK || cleared.SpendCap.OverrideUntil != nil {
+		t.Fatalf("clear override: status %d, %+v", status, cleared.SpendCap)
+	}
+	var deleted spendCapResponse
+	if status := api.do(http.MethodDelete, "/v1/admin/spend-caps/acme", "", nil, &deleted); status != http.StatusOK || deleted.SpendCap.MonthlyCapUSD != nil {
+		t.Fatalf("delete cap: status %d, %+v", status, deleted.SpendCap)
+	}
+	api.createJob(`{"tenant_id":"acme","model":"m"}`)
+
+	var list listSpendStatusesResponse
+	api.do(http.MethodGet, "/v1/admin/spend-caps", "", nil, &list)
+	if len(list.Tenants) != 1 || list.Tenants[0].TenantID != "acme" || list.Tenants[0].OverCap {
+		t.Fatalf("list = %+v", list.Tenants)
+	}
+}
+
+func TestFallbackPolicyEndpoints(t *testing.T) {
+	api := newTestAPI(t, 10)
+
+	var response errorResponse
+	status := api.do(http.MethodPut, "/v1/admin/fallback-policies/acme/gpt-4.1-mini", `{"fallback_models":[]}`, nil, &response)
+	expectError(t, status, response, http.StatusBadRequest, "validation_error")
+	status = api.do(http.MethodGet, "/v1/admin/fallback-policies/acme/gpt-4.1-mini", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+	status = api.do(http.MethodGet, "/v1/admin/fallback-policies/acme", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+
+	var saved fallbackPolicyResponse
+	body := `{"fallback_models":["gpt-4o-mini"],"fallback_on":["PROVIDER_TIMEOUT","RATE_LIMITED"],"reason":"primary degraded"}`
+	if status := api.do(http.MethodPut, "/v1/admin/fallback-policies/acme/gpt-4.1-mini", body, nil, &saved); status != http.StatusOK {
+		t.Fatalf("put policy: status %d", status)
+	}
+	if saved.Policy.TenantID != "acme" || saved.Policy.Model != "gpt-4.1-mini" || len(saved.Policy.FallbackOn) != 2 {
+		t.Fatalf("policy = %+v", saved.Policy)
+	}
+	// Models with slashes in their name are addressed by the rest of the path.
+	if status := api.do(http.MethodPut, "/v1/admin/fallback-policies/globex/openai/gpt-4o", `{"fallback_models":["gpt-4o-mini"]}`, nil, &saved); status != http.StatusOK || saved.Policy.Model != "openai/gpt-4o" {
+		t.Fatalf("put slashed model: status %d, %+v", status, saved.Policy)
+	}
+
+	var list listFallbackPoliciesResponse
+	api.do(http.MethodGet, "/v1/admin/fallback-policies?tenant=acme", "", nil, &list)
+	if len(list.Policies) != 1 || list.Policies[0].FallbackModels[0] != "gpt-4o-mini" {
+		t.Fatalf("list = %+v", list.Policies)
+	}
+
+	if status := api.do(http.MethodDelete, "/v1/admin/fallback-policies/acme/gpt-4.1-mini", "", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d", status)
+	}
+	status = api.do(http.MethodDelete, "/v1/admin/fallback-policies/acme/gpt-4.1-mini", "", nil, &response)
+	expectError(t, status, response, http.StatusNotFound, "not_found")
+
+	job := api.createJob(`{"tenant_id":"acme","model":"gpt-4.1-mini","fallback_models":["gpt-4o-mini"]}`)
+	if len(job.FallbackModels) != 1 || job.ModelUsed != "" {
+		t.Fatalf("job = fallback_models %v, model_used %q", job.FallbackModels, job.ModelUsed)
+	}
+}
+
+func TestQueuePendingNotSupported(t *testing.T) {
+	api := newTestAPI(t, 10)
+	var response errorResponse
+	status := api.do(http.MethodGet, "/v1/admin/queue/pending", "", nil, &response)
+	expectError(t, status, response, http.StatusNotImplemented, "not_supported")
+}
+
+func TestCircuitBreakerEndpoint(t *testing.T) {
+	var response errorResponse
+	status := newTestAPI(t, 10).do(http.MethodGet, "/v1/admin/circuit-breakers", "", nil, &response)
+	expectError(t, status, response, http.StatusNotImplemented, "not_supported")
+
+	breakers := breaker.New(breaker.NewMemoryBackend(), breaker.Config{Window: time.Minute, MinRequests: 1, ErrorRate: 0.5, OpenFor: time.Minute})
+	ctx := context.Background()
+	if _, err := breakers.Record(ctx, "mock-llm", "gpt-4o-mini", false); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if _, err := breakers.Record(ctx, "mock-llm", "gpt-4.1-mini", true); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := jobs.NewService(store.NewMemoryStore(), queue.NewMemoryQueue(30*time.Second), breakers, 0, logger)
+	api := &testAPI{t: t, handler: NewServer(service, 10, AuthConfig{}).Handler()}
+
+	var list listCircuitBreakersResponse
+	if status := api.do(http.MethodGet, "/v1/admin/circuit-breakers", "", nil, &list); status != http.StatusOK {
+		t.Fatalf("list breakers: status %d", status)
+	}
+	if len(list.Breakers) != 2 {
+		t.Fatalf("breakers = %+v", list.Breakers)
+	}
+	open, closed := list.Breakers[0], list.Breakers[1]
+	if open.Model != "gpt-4.1-mini" || open.State != models.BreakerOpen || open.RetryAt == nil || closed.State != models.BreakerClosed {
+		t.Fatalf("breakers = %+v", list.Breakers)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	api := newTestAPI(t, 10)
+	if status := api.do(http.MethodGet, "/healthz", "", nil, nil); status != http.StatusOK {
+		t.Fatalf("healthz: status %d", status)
+	}
+}
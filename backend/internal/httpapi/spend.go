@@ -0,0 +1,150 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+type putSpendCapRequest struct {
+	CapUSD *float64 `json:"cap_usd"`
+	Reason string   `json:"reason"`
+}
+
+// overrideSpendCapRequest grants a temporary cap. Leaving cap_usd out lifts
+// the cap until the override ends.
+type overrideSpendCapRequest struct {
+	CapUSD *float64   `json:"cap_usd"`
+	Until  *time.Time `json:"until"`
+	Reason string     `json:"reason"`
+}
+
+type spendCapResponse struct {
+	SpendCap models.SpendCap `json:"spend_cap"`
+}
+
+type spendStatusResponse struct {
+	Spend models.SpendStatus `json:"spend"`
+}
+
+type listSpendStatusesResponse struct {
+	Tenants []models.SpendStatus `json:"tenants"`
+}
+
+func (s *Server) handleSpendCaps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	statuses, err := s.service.ListSpendStatuses(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, listSpendStatusesResponse{Tenants: statuses})
+}
+
+func (s *Server) handleSpendCapByTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, action, ok := parsePathTail(r.URL.Path, "/v1/admin/spend-caps/")
+	if !ok || (action != "" && action != "override") {
+		writeError(w, http.StatusNotFound, "not_found", "Spend cap not found")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		status, err := s.service.GetSpendStatus(r.Context(), tenantID)
+		if err != nil {
+			writeSpendCapError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, spendStatusResponse{Spend: status})
+	case action == "" && r.Method == http.MethodPut:
+		s.handlePutSpendCap(w, r, tenantID)
+	case action == "" && r.Method == http.MethodDelete:
+		reason, ok := decodeReason(w, r)
+		if !ok {
+			return
+		}
+		spendCap, err := s.service.DeleteSpendCap(r.Context(), tenantID, reason)
+		if err != nil {
+			writeSpendCapError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, spendCapResponse{SpendCap: spendCap})
+	case action == "override" && r.Method == http.MethodPost:
+		s.handleOverrideSpendCap(w, r, tenantID)
+	case action == "override" && r.Method == http.MethodDelete:
+		reason, ok := decodeReason(w, r)
+		if !ok {
+			return
+		}
+		spendCap, err := s.service.ClearSpendCapOverride(r.Context(), tenantID, reason)
+		if err != nil {
+			writeSpendCapError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, spendCapResponse{SpendCap: spendCap})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+func (s *Server) handlePutSpendCap(w http.ResponseWriter, r *http.Request, tenantID string) {
+	var request putSpendCapRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	if request.CapUSD == nil {
+		writeError(w, http.StatusBadRequest, "validation_error", "cap_usd is required")
+		return
+	}
+	reason, ok := checkReason(w, request.Reason)
+	if !ok {
+		return
+	}
+
+	spendCap, err := s.service.SetSpendCap(r.Context(), tenantID, *request.CapUSD, reason)
+	if err != nil {
+		writeSpendCapError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, spendCapResponse{SpendCap: spendCap})
+}
+
+func (s *Server) handleOverrideSpendCap(w http.ResponseWriter, r *http.Request, tenantID string) {
+	var request overrideSpendCapRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Request body must be valid JSON")
+		return
+	}
+	if request.Until == nil {
+		writeError(w, http.StatusBadRequest, "validation_error", "until is required")
+		return
+	}
+	reason, ok := checkReason(w, request.Reason)
+	if !ok {
+		return
+	}
+
+	spendCap, err := s.service.OverrideSpendCap(r.Context(), tenantID, request.CapUSD, *request.Until, reason)
+	if err != nil {
+		writeSpendCapError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, spendCapResponse{SpendCap: spendCap})
+}
+
+func writeSpendCapError(w http.ResponseWriter, err error) {
+	if errors.Is(err, jobs.ErrInvalidSpendCap) {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+}
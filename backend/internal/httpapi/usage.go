@@ -0,0 +1,107 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// usageDayLayout is the format of the from and to query parameters.
+const usageDayLayout = "2006-01-02"
+
+// handleUsage reports a tenant's token and cost usage as JSON or, with
+// format=csv or an Accept header asking for text/csv, as a CSV download.
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	tenantID, ok := resolveTenant(r, query.Get("tenant"))
+	if !ok {
+		writeTenantMismatch(w)
+		return
+	}
+	filter := models.UsageFilter{TenantID: tenantID, GroupBy: query.Get("group_by")}
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse(usageDayLayout, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "validation_error", name+" must be a YYYY-MM-DD day")
+			return
+		}
+		*target = day
+	}
+
+	format := query.Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "validation_error", "format must be json or csv")
+		return
+	}
+
+	report, err := s.service.Usage(r.Context(), filter)
+	if errors.Is(err, jobs.ErrInvalidUsageQuery) {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if format == "csv" {
+		writeUsageCSV(w, report)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// writeUsageCSV writes one row per tenant and day or model. Totals are left to
+// the spreadsheet.
+func writeUsageCSV(w http.ResponseWriter, report models.UsageReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="usage-%s-%s.csv"`, report.From, report.To))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{
+		"tenant_id", report.GroupBy, "attempts", "failed_attempts", "prompt_tokens", "cached_prompt_tokens",
+		"completion_tokens", "tokens", "cost_usd", "failed_cost_usd",
+	})
+	for _, row := range report.Rows {
+		group := row.Day
+		if report.GroupBy == models.UsageGroupByModel {
+			group = row.Model
+		}
+		_ = writer.Write([]string{
+			csvText(row.TenantID),
+			csvText(group),
+			strconv.Itoa(row.Attempts),
+			strconv.Itoa(row.FailedAttempts),
+			strconv.Itoa(row.PromptTokens),
+			strconv.Itoa(row.CachedPromptTokens),
+			strconv.Itoa(row.CompletionTokens),
+			strconv.Itoa(row.Tokens),
+			strconv.FormatFloat(row.CostUSD, 'f', 6, 64),
+			strconv.FormatFloat(row.FailedCostUSD, 'f', 6, 64),
+		})
+	}
+	writer.Flush()
+}
+
+// csvText keeps spreadsheets from evaluating a tenant ID or model name as a
+// formula.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+// apiKeyPrefix marks plaintext keys issued by this service.
+const apiKeyPrefix = "jq_"
+
+// apiKeyDisplayLength is how much of the plaintext key is kept as its prefix
+// so operators can tell keys apart without the secret.
+const apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+// ErrUnauthenticated is returned for unknown, revoked or expired API keys.
+var ErrUnauthenticated = errors.New("invalid or inactive api key")
+
+// ErrInvalidAPIKey wraps validation failures of a new API key.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// CreateAPIKey issues a key. An empty role issues a tenant-user key.
+func (s *Service) CreateAPIKey(ctx context.Context, tenantID string, name string, role models.Role, expiresAt *time.Time, reason string) (models.IssuedAPIKey, error) {
+	if role == "" {
+		role = models.RoleTenantUser
+	}
+	switch {
+	case !role.Valid():
+		return models.IssuedAPIKey{}, fmt.Errorf("%w: role must be read-only-viewer, tenant-user, tenant-admin or operator", ErrInvalidAPIKey)
+	case role == models.RoleOperator && tenantID != "":
+		return models.IssuedAPIKey{}, fmt.Errorf("%w: operator keys cannot be limited to a tenant", ErrInvalidAPIKey)
+	case tenantID == "" && (role == models.RoleTenantUser || role == models.RoleTenantAdmin):
+		return models.IssuedAPIKey{}, fmt.Errorf("%w: tenant_id is required for %s keys", ErrInvalidAPIKey, role)
+	}
+
+	plaintext, err := generateAPIKey()
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	var key models.APIKey
+	err = s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		key, err = s.store.CreateAPIKey(ctx, models.APIKey{
+			TenantID:  tenantID,
+			Name:      name,
+			Role:      role,
+			Prefix:    plaintext[:apiKeyDisplayLength],
+			ExpiresAt: expiresAt,
+		}, HashAPIKey(plaintext))
+		if err != nil {
+			return err
+		}
+		return s.auditAPIKey(ctx, AuditAPIKeyCreate, key.ID, key, reason)
+	})
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	return models.IssuedAPIKey{APIKey: key, Key: plaintext}, nil
+}
+
+func (s *Service) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, error) {
+	return s.store.GetAPIKey(ctx, keyID)
+}
+
+func (s *Service) ListAPIKeys(ctx context.Context, tenantID string) ([]models.APIKey, error) {
+	return s.store.ListAPIKeys(ctx, tenantID)
+}
+
+// RotateAPIKey issues a replacement key. The old key keeps working for grace
+// so callers can roll over; zero grace retires it immediately.
+func (s *Service) RotateAPIKey(ctx context.Context, keyID string, grace time.Duration, reason string) (models.IssuedAPIKey, error) {
+	plaintext, err := generateAPIKey()
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	var key models.APIKey
+	err = s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if key, err = s.store.RotateAPIKey(ctx, keyID, plaintext[:apiKeyDisplayLength], HashAPIKey(plaintext), time.Now().Add(grace)); err != nil {
+			return err
+		}
+		return s.auditAPIKey(ctx, AuditAPIKeyRotate, keyID, key, reason)
+	})
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	return models.IssuedAPIKey{APIKey: key, Key: plaintext}, nil
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, keyID string, reason string) (models.APIKey, error) {
+	var key models.APIKey
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if key, err = s.store.RevokeAPIKey(ctx, keyID); err != nil {
+			return err
+		}
+		return s.auditAPIKey(ctx, AuditAPIKeyRevoke, keyID, key, reason)
+	})
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+// auditAPIKey records a key action. The details describe the resulting key,
+// which for a rotation is the replacement, and never include the secret.
+func (s *Service) auditAPIKey(ctx context.Context, action string, keyID string, key models.APIKey, reason string) error {
+	return s.audit(ctx, models.AuditEntry{Action: action, TargetType: "api_key", TargetID: keyID, TenantID: key.TenantID, Reason: reason}, key)
+}
+
+// AuthenticateAPIKey resolves a plaintext key to the active key record.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, plaintext string) (models.APIKey, error) {
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return models.APIKey{}, ErrUnauthenticated
+	}
+	key, err := s.store.GetAPIKeyByHash(ctx, HashAPIKey(plaintext))
+	if errors.Is(err, store.ErrNotFound) {
+		return models.APIKey{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if !key.Active(time.Now()) {
+		return models.APIKey{}, ErrUnauthenticated
+	}
+	return key, nil
+}
+
+// HashAPIKey returns the stored form of a plaintext key. Keys carry 256 bits
+// of randomness, so a plain SHA-256 is enough to make the stored hash useless
+// for authentication.
+func HashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
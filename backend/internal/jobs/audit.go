@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// Audit actions recorded by the service.
+const (
+	AuditJobCancel        = "job.cancel"
+	AuditJobReschedule    = "job.reschedule"
+	AuditJobRetry         = "job.retry"
+	AuditGroupCancel      = "group.cancel"
+	AuditGroupRetryFailed = "group.retry_failed"
+	AuditSchedulePause    = "schedule.pause"
+	AuditScheduleResume   = "schedule.resume"
+	AuditScheduleDelete   = "schedule.delete"
+	AuditRetentionSet     = "retention.set"
+	AuditRetentionDelete  = "retention.delete"
+	AuditAPIKeyCreate     = "api_key.create"
+	AuditAPIKeyRotate     = "api_key.rotate"
+	AuditAPIKeyRevoke     = "api_key.revoke"
+	AuditModelPriceCreate = "model_price.create"
+
+	AuditSpendCapSet           = "spend_cap.set"
+	AuditSpendCapDelete        = "spend_cap.delete"
+	AuditSpendCapOverride      = "spend_cap.override"
+	AuditSpendCapOverrideClear = "spend_cap.override_clear"
+	AuditFallbackSet           = "fallback_policy.set"
+	AuditFallbackDelete        = "fallback_policy.delete"
+)
+
+const (
+	defaultAuditListLimit = 100
+	maxAuditListLimit     = 500
+)
+
+type actorKey struct{}
+
+// WithActor attaches the caller of a request to ctx. Actions the service
+// audits are attributed to it.
+func WithActor(ctx context.Context, actor models.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor attached by WithActor, or the system actor for
+// background work.
+func ActorFrom(ctx context.Context) models.Actor {
+	if actor, ok := ctx.Value(actorKey{}).(models.Actor); ok {
+		return actor
+	}
+	return models.Actor{Type: "system"}
+}
+
+// ListAudit returns a page of audit entries, newest first. The limit defaults
+// to 100 and is capped at 500.
+func (s *Service) ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditListLimit
+	}
+	if filter.Limit > maxAuditListLimit {
+		filter.Limit = maxAuditListLimit
+	}
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := s.store.ListAudit(ctx, filter)
+	if err != nil {
+		return models.AuditPage{}, err
+	}
+
+	page := models.AuditPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextCursor = strconv.FormatInt(page.Entries[limit-1].ID, 10)
+	}
+	return page, nil
+}
+
+// audit records an action. Callers run it with the store context of the
+// transaction that applies the action, so an action that cannot be audited
+// is rolled back and the request fails.
+func (s *Service) audit(ctx context.Context, entry models.AuditEntry, details any) error {
+	entry.Actor = ActorFrom(ctx)
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("encode audit details: %w", err)
+		}
+		entry.Details = raw
+	}
+	if _, err := s.store.AppendAudit(ctx, entry); err != nil {
+		return fmt.Errorf("record audit entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// ErrBreakersOff is returned when circuit breakers are turned off.
+var ErrBreakersOff = errors.New("circuit breakers are turned off")
+
+// ListCircuitBreakers returns the state of every provider/model circuit
+// breaker that has seen a call.
+func (s *Service) ListCircuitBreakers(ctx context.Context) ([]models.CircuitBreaker, error) {
+	if s.breakers == nil {
+		return nil, ErrBreakersOff
+	}
+	return s.breakers.List(ctx)
+}
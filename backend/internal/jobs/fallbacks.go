@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// ErrInvalidFallbackPolicy wraps validation failures of a fallback policy.
+var ErrInvalidFallbackPolicy = errors.New("invalid fallback policy")
+
+// ValidateFallbackChain checks the fallback models and trigger error codes of
+// a job or policy requesting model. An empty chain is valid.
+func ValidateFallbackChain(model string, fallbackModels []string, fallbackOn []string) error {
+	if len(fallbackModels) > models.MaxFallbackModels {
+		return fmt.Errorf("fallback_models may list at most %d models", models.MaxFallbackModels)
+	}
+	if len(fallbackModels) == 0 && len(fallbackOn) > 0 {
+		return errors.New("fallback_on requires fallback_models")
+	}
+	seen := map[string]struct{}{model: {}}
+	for _, fallback := range fallbackModels {
+		if strings.TrimSpace(fallback) == "" {
+			return errors.New("fallback_models must not contain empty model names")
+		}
+		if _, ok := seen[fallback]; ok {
+			return fmt.Errorf("fallback_models lists model %s more than once or repeats the requested model", fallback)
+		}
+		seen[fallback] = struct{}{}
+	}
+	for _, code := range fallbackOn {
+		if strings.TrimSpace(code) == "" {
+			return errors.New("fallback_on must not contain empty error codes")
+		}
+	}
+	return nil
+}
+
+// SetFallbackPolicy sets the tenant's fallback chain for jobs requesting
+// policy.Model. Jobs that list fallback models of their own ignore it.
+func (s *Service) SetFallbackPolicy(ctx context.Context, policy models.FallbackPolicy, reason string) (models.FallbackPolicy, error) {
+	if policy.TenantID == "" || policy.Model == "" {
+		return models.FallbackPolicy{}, fmt.Errorf("%w: tenant_id and model are required", ErrInvalidFallbackPolicy)
+	}
+	if len(policy.FallbackModels) == 0 {
+		return models.FallbackPolicy{}, fmt.Errorf("%w: fallback_models is required", ErrInvalidFallbackPolicy)
+	}
+	if err := ValidateFallbackChain(policy.Model, policy.FallbackModels, policy.FallbackOn); err != nil {
+		return models.FallbackPolicy{}, fmt.Errorf("%w: %v", ErrInvalidFallbackPolicy, err)
+	}
+	var saved models.FallbackPolicy
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if saved, err = s.store.UpsertFallbackPolicy(ctx, policy); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditFallbackSet, TargetType: "fallback_policy", TargetID: saved.Model, TenantID: saved.TenantID, Reason: reason}, saved)
+	})
+	if err != nil {
+		return models.FallbackPolicy{}, err
+	}
+	return saved, nil
+}
+
+func (s *Service) GetFallbackPolicy(ctx context.Context, tenantID string, model string) (models.FallbackPolicy, error) {
+	return s.store.GetFallbackPolicy(ctx, tenantID, model)
+}
+
+func (s *Service) ListFallbackPolicies(ctx context.Context, tenantID string) ([]models.FallbackPolicy, error) {
+	return s.store.ListFallbackPolicies(ctx, tenantID)
+}
+
+func (s *Service) DeleteFallbackPolicy(ctx context.Context, tenantID string, model string, reason string) error {
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.DeleteFallbackPolicy(ctx, tenantID, model); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditFallbackDelete, TargetType: "fallback_policy", TargetID: model, TenantID: tenantID, Reason: reason}, nil)
+	})
+}
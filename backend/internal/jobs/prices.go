@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// ErrInvalidModelPrice wraps validation failures of a model price.
+var ErrInvalidModelPrice = errors.New("invalid model price")
+
+// AddModelPrice adds a price to the catalog. A zero EffectiveFrom takes effect
+// now. Attempts keep the cost computed when they ran, so a new price only
+// affects attempts that start after it takes effect.
+func (s *Service) AddModelPrice(ctx context.Context, price models.ModelPrice, reason string) (models.ModelPrice, error) {
+	price.Model = strings.TrimSpace(price.Model)
+	if price.Model == "" {
+		return models.ModelPrice{}, fmt.Errorf("%w: model is required", ErrInvalidModelPrice)
+	}
+	for _, value := range []float64{price.InputPer1K, price.CachedInputPer1K, price.OutputPer1K} {
+		if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			return models.ModelPrice{}, fmt.Errorf("%w: prices must be 0 or greater", ErrInvalidModelPrice)
+		}
+	}
+	if price.EffectiveFrom.IsZero() {
+		price.EffectiveFrom = time.Now()
+	}
+	price.EffectiveFrom = price.EffectiveFrom.UTC().Truncate(time.Microsecond)
+
+	var created models.ModelPrice
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.CreateModelPrice(ctx, price); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditModelPriceCreate, TargetType: "model_price", TargetID: created.Model, Reason: reason}, created)
+	})
+	if err != nil {
+		return models.ModelPrice{}, err
+	}
+	return created, nil
+}
+
+func (s *Service) ListModelPrices(ctx context.Context, model string) ([]models.ModelPrice, error) {
+	return s.store.ListModelPrices(ctx, model)
+}
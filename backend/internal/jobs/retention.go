@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// ErrInvalidRetentionPolicy wraps validation failures of a retention policy.
+var ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+
+func (s *Service) SetRetentionPolicy(ctx context.Context, policy models.RetentionPolicy, reason string) (models.RetentionPolicy, error) {
+	if policy.TenantID == "" {
+		return models.RetentionPolicy{}, fmt.Errorf("%w: tenant_id is required", ErrInvalidRetentionPolicy)
+	}
+	if policy.JobsRetentionDays < 0 {
+		return models.RetentionPolicy{}, fmt.Errorf("%w: jobs_retention_days must be 0 or greater", ErrInvalidRetentionPolicy)
+	}
+	var saved models.RetentionPolicy
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if saved, err = s.store.UpsertRetentionPolicy(ctx, policy); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditRetentionSet, TargetType: "retention_policy", TargetID: saved.TenantID, TenantID: saved.TenantID, Reason: reason}, saved)
+	})
+	if err != nil {
+		return models.RetentionPolicy{}, err
+	}
+	return saved, nil
+}
+
+func (s *Service) GetRetentionPolicy(ctx context.Context, tenantID string) (models.RetentionPolicy, error) {
+	return s.store.GetRetentionPolicy(ctx, tenantID)
+}
+
+func (s *Service) ListRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	return s.store.ListRetentionPolicies(ctx)
+}
+
+func (s *Service) DeleteRetentionPolicy(ctx context.Context, tenantID string, reason string) error {
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.DeleteRetentionPolicy(ctx, tenantID); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditRetentionDelete, TargetType: "retention_policy", TargetID: tenantID, TenantID: tenantID, Reason: reason}, nil)
+	})
+}
@@ -0,0 +1,119 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/cronexpr"
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// ErrInvalidSchedule wraps validation failures of a schedule definition.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ErrScheduleNeverFires is returned, wrapped together with ErrInvalidSchedule,
+// for a cron expression that parses but matches no future time, such as
+// "0 0 30 2 *".
+var ErrScheduleNeverFires = errors.New("cron expression never fires")
+
+func (s *Service) CreateSchedule(ctx context.Context, input models.CreateScheduleInput) (models.Schedule, error) {
+	if input.Timezone == "" {
+		input.Timezone = "UTC"
+	}
+	if input.OverlapPolicy == "" {
+		input.OverlapPolicy = models.OverlapSkip
+	}
+	switch input.OverlapPolicy {
+	case models.OverlapSkip, models.OverlapQueue, models.OverlapAllow:
+	default:
+		return models.Schedule{}, fmt.Errorf("%w: overlap_policy must be skip, queue or allow", ErrInvalidSchedule)
+	}
+	if input.Priority <= 0 {
+		input.Priority = 3
+	}
+	if input.MaxAttempts <= 0 {
+		input.MaxAttempts = 3
+	}
+	if len(input.PayloadJSON) == 0 {
+		input.PayloadJSON = json.RawMessage(`{}`)
+	}
+
+	spec, err := cronexpr.Parse(input.CronExpr, input.Timezone)
+	if err != nil {
+		return models.Schedule{}, fmt.Errorf("%w: %s", ErrInvalidSchedule, err.Error())
+	}
+
+	next := spec.Next(time.Now())
+	if next.IsZero() {
+		return models.Schedule{}, fmt.Errorf("%w: %w", ErrInvalidSchedule, ErrScheduleNeverFires)
+	}
+	return s.store.CreateSchedule(ctx, input, next)
+}
+
+func (s *Service) GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	return s.store.GetSchedule(ctx, scheduleID)
+}
+
+func (s *Service) ListSchedules(ctx context.Context, tenantID string) ([]models.Schedule, error) {
+	return s.store.ListSchedules(ctx, tenantID)
+}
+
+func (s *Service) PauseSchedule(ctx context.Context, scheduleID string, reason string) (models.Schedule, error) {
+	var schedule models.Schedule
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if schedule, err = s.store.SetSchedulePaused(ctx, scheduleID, true, nil); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditSchedulePause, TargetType: "schedule", TargetID: scheduleID, TenantID: schedule.TenantID, Reason: reason}, nil)
+	})
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// ResumeSchedule re-enables a schedule from the next slot after now; slots
+// that passed while it was paused are not fired.
+func (s *Service) ResumeSchedule(ctx context.Context, scheduleID string, reason string) (models.Schedule, error) {
+	schedule, err := s.store.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	spec, err := cronexpr.Parse(schedule.CronExpr, schedule.Timezone)
+	if err != nil {
+		return models.Schedule{}, fmt.Errorf("%w: %s", ErrInvalidSchedule, err.Error())
+	}
+	next := spec.Next(time.Now())
+	if next.IsZero() {
+		return models.Schedule{}, fmt.Errorf("%w: %w", ErrInvalidSchedule, ErrScheduleNeverFires)
+	}
+	var resumed models.Schedule
+	err = s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if resumed, err = s.store.SetSchedulePaused(ctx, scheduleID, false, &next); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditScheduleResume, TargetType: "schedule", TargetID: scheduleID, TenantID: resumed.TenantID, Reason: reason}, nil)
+	})
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	return resumed, nil
+}
+
+func (s *Service) DeleteSchedule(ctx context.Context, scheduleID string, reason string) error {
+	schedule, err := s.store.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+	return s.store.InTx(ctx, func(ctx context.Context) error {
+		if err := s.store.DeleteSchedule(ctx, scheduleID); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditScheduleDelete, TargetType: "schedule", TargetID: scheduleID, TenantID: schedule.TenantID, Reason: reason}, schedule)
+	})
+}
@@ -2,60 +2,204 @@ package jobs
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
+	"time"
 
+	"job-queue-llm-orchestrator/backend/internal/breaker"
 	"job-queue-llm-orchestrator/backend/internal/models"
 	"job-queue-llm-orchestrator/backend/internal/queue"
 	"job-queue-llm-orchestrator/backend/internal/store"
+	"job-queue-llm-orchestrator/backend/internal/telemetry"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("job-queue-llm-orchestrator/backend/internal/jobs")
+
+// ErrGroupNotFound is returned when a job references a group that does not
+// exist or belongs to another tenant.
+var ErrGroupNotFound = errors.New("group not found")
+
+// ErrNotSupported is returned when the configured queue backend lacks a
+// requested capability.
+var ErrNotSupported = errors.New("not supported by the queue backend")
+
 type Service struct {
-	store  *store.PostgresStore
-	queue  *queue.RedisQueue
-	logger *slog.Logger
+	store          store.Store
+	queue          queue.Queue
+	breakers       *breaker.Breakers
+	idempotencyTTL time.Duration
+	logger         *slog.Logger
 }
 
-func NewService(store *store.PostgresStore, queue *queue.RedisQueue, logger *slog.Logger) *Service {
+// NewService builds the job service. Idempotency keys become reusable
+// idempotencyTTL after the job that holds them was created; zero keeps them
+// reserved forever. breakers is nil when circuit breakers are turned off.
+func NewService(store store.Store, queue queue.Queue, breakers *breaker.Breakers, idempotencyTTL time.Duration, logger *slog.Logger) *Service {
 	return &Service{
-		store:  store,
-		queue:  queue,
-		logger: logger,
+		store:          store,
+		queue:          queue,
+		breakers:       breakers,
+		idempotencyTTL: idempotencyTTL,
+		logger:         logger,
 	}
 }
 
 func (s *Service) CreateJob(ctx context.Context, input models.CreateJobInput) (models.Job, bool, error) {
-	job, existing, err := s.store.CreateJob(ctx, input)
+	ctx, span := tracer.Start(ctx, "jobs.create", trace.WithAttributes(
+		attribute.String("job.tenant_id", input.TenantID),
+		attribute.String("job.model", input.Model),
+	))
+	defer span.End()
+
+	if err := s.checkGroups(ctx, []models.CreateJobInput{input}); err != nil {
+		return models.Job{}, false, err
+	}
+	capErrors, err := s.checkSpendCaps(ctx, []models.CreateJobInput{input})
 	if err != nil {
 		return models.Job{}, false, err
 	}
+	if capErrors[0] != nil {
+		return models.Job{}, false, capErrors[0]
+	}
 
-	if !existing {
-		if err := s.queue.EnqueueJob(ctx, job.ID); err != nil {
-			return models.Job{}, false, fmt.Errorf("enqueue job: %w", err)
-		}
+	// The stored traceparent points at this span so the worker can continue
+	// the trace when it picks the job up.
+	input.TraceID = telemetry.TraceID(ctx)
+	input.Traceparent, input.Tracestate = telemetry.InjectTraceparent(ctx)
+	input.IdempotencyExpiresAt = s.idempotencyExpiry(input.IdempotencyKey)
+
+	dbCtx, dbSpan := tracer.Start(ctx, "db.create_job")
+	job, existing, err := s.store.CreateJob(dbCtx, input)
+	endSpan(dbSpan, err)
+	if err != nil {
+		recordSpanError(span, err)
+		return models.Job{}, false, err
 	}
+	span.SetAttributes(
+		attribute.String("job.id", job.ID),
+		attribute.Bool("job.idempotent_replay", existing),
+	)
 
+	// Queued jobs reach the ready queue through the outbox written in the
+	// same transaction; blocked and scheduled jobs are written there once
+	// their parents succeed or run_at passes.
 	return job, existing, nil
 }
 
+// CreateJobsBatch stores all inputs in one transaction; the outbox relay
+// publishes the newly queued jobs. In atomic mode a reused
+// idempotency key with a different request aborts the batch with
+// store.ErrIdempotencyMismatch; the returned results mark the offending inputs.
+// New jobs of a tenant over its spend cap fail an atomic batch with
+// ErrSpendCapExceeded; in partial mode only their results are Rejected.
+func (s *Service) CreateJobsBatch(ctx context.Context, inputs []models.CreateJobInput, atomic bool) ([]models.CreateJobResult, error) {
+	ctx, span := tracer.Start(ctx, "jobs.create_batch", trace.WithAttributes(
+		attribute.Int("batch.size", len(inputs)),
+	))
+	defer span.End()
+
+	if err := s.checkGroups(ctx, inputs); err != nil {
+		return nil, err
+	}
+	capErrors, err := s.checkSpendCaps(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]models.CreateJobResult, len(inputs))
+	admitted := make([]models.CreateJobInput, 0, len(inputs))
+	admittedIndexes := make([]int, 0, len(inputs))
+	for i, capErr := range capErrors {
+		if capErr == nil {
+			admitted = append(admitted, inputs[i])
+			admittedIndexes = append(admittedIndexes, i)
+			continue
+		}
+		if atomic {
+			return nil, capErr
+		}
+		results[i] = models.CreateJobResult{Rejected: capErr}
+	}
+	inputs = admitted
+
+	traceID := telemetry.TraceID(ctx)
+	traceparent, tracestate := telemetry.InjectTraceparent(ctx)
+	for i := range inputs {
+		inputs[i].TraceID = traceID
+		inputs[i].Traceparent = traceparent
+		inputs[i].Tracestate = tracestate
+		inputs[i].IdempotencyExpiresAt = s.idempotencyExpiry(inputs[i].IdempotencyKey)
+	}
+
+	dbCtx, dbSpan := tracer.Start(ctx, "db.create_jobs_batch")
+	stored, err := s.store.CreateJobsBatch(dbCtx, inputs, atomic)
+	endSpan(dbSpan, err)
+	for i, result := range stored {
+		results[admittedIndexes[i]] = result
+	}
+	if err != nil {
+		recordSpanError(span, err)
+		return results, err
+	}
+
+	created := 0
+	for _, result := range stored {
+		if !result.Existing {
+			created++
+		}
+	}
+	span.SetAttributes(attribute.Int("batch.created", created))
+
+	return results, nil
+}
+
+func (s *Service) idempotencyExpiry(key string) *time.Time {
+	if key == "" || s.idempotencyTTL <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(s.idempotencyTTL)
+	return &expiresAt
+}
+
 func (s *Service) GetJob(ctx context.Context, jobID string) (models.JobSnapshot, error) {
 	job, latestAttempt, err := s.store.GetJobByID(ctx, jobID)
 	if err != nil {
 		return models.JobSnapshot{}, err
 	}
+	dependsOn, err := s.store.GetDependencyIDs(ctx, jobID)
+	if err != nil {
+		return models.JobSnapshot{}, err
+	}
 	return models.JobSnapshot{
 		Job:           job,
 		LatestAttempt: latestAttempt,
+		DependsOn:     dependsOn,
 	}, nil
 }
 
-func (s *Service) ListJobs(ctx context.Context, status string, tenantID string, model string, limit int) ([]models.Job, error) {
-	return s.store.ListJobs(ctx, status, tenantID, model, limit)
+func (s *Service) ListJobAttempts(ctx context.Context, jobID string) (models.JobAttemptHistory, error) {
+	return s.store.ListJobAttempts(ctx, jobID)
+}
+
+func (s *Service) ListJobs(ctx context.Context, filter models.ListJobsFilter) (models.JobPage, error) {
+	return s.store.ListJobs(ctx, filter)
 }
 
-func (s *Service) CancelJob(ctx context.Context, jobID string) (models.Job, error) {
-	job, err := s.store.CancelJob(ctx, jobID, "Cancelled by operator")
+// CancelJob cancels a job that has not finished. The reason is kept as the
+// job's error message; empty means "Cancelled by operator".
+func (s *Service) CancelJob(ctx context.Context, jobID string, reason string) (models.Job, error) {
+	var job models.Job
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if job, err = s.store.CancelJob(ctx, jobID, reason); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditJobCancel, TargetType: "job", TargetID: jobID, TenantID: job.TenantID, Reason: reason}, nil)
+	})
 	if err != nil {
 		return models.Job{}, err
 	}
@@ -71,23 +215,169 @@ func (s *Service) CancelJob(ctx context.Context, jobID string) (models.Job, erro
 	return job, nil
 }
 
-func (s *Service) RetryJob(ctx context.Context, jobID string) (models.Job, error) {
-	job, err := s.store.RetryJob(ctx, jobID)
+// RescheduleJob changes the run time of a job that has not started yet.
+func (s *Service) RescheduleJob(ctx context.Context, jobID string, runAt time.Time, reason string) (models.Job, error) {
+	var job models.Job
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if job, err = s.store.RescheduleJob(ctx, jobID, runAt); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditJobReschedule, TargetType: "job", TargetID: jobID, TenantID: job.TenantID, Reason: reason},
+			map[string]any{"run_at": runAt.UTC()})
+	})
 	if err != nil {
 		return models.Job{}, err
 	}
 
-	// De-dup before enqueue in case a previous attempt left stale queue entries.
-	if err := s.queue.RemoveQueuedJob(ctx, jobID); err != nil {
-		s.logger.Warn("failed to remove stale queued retry job", "job_id", jobID, "error", err)
-	}
-	if err := s.queue.EnqueueJob(ctx, jobID); err != nil {
-		return models.Job{}, fmt.Errorf("enqueue retry job: %w", err)
+	// A job moved into the future may still sit in the ready queue; the
+	// scheduler re-enqueues it when due. Jobs that stay queued are re-published
+	// through the outbox.
+	if job.Status == models.JobStatusScheduled {
+		if err := s.queue.RemoveQueuedJob(ctx, jobID); err != nil {
+			s.logger.Warn("failed to remove rescheduled job from ready queue", "job_id", jobID, "error", err)
+		}
 	}
 
 	return job, nil
 }
 
-func (s *Service) Store() *store.PostgresStore {
+// RetryJob requeues a job. The outbox relay replaces any stale ready queue
+// entry left by a previous attempt.
+func (s *Service) RetryJob(ctx context.Context, jobID string, reason string) (models.Job, error) {
+	var job models.Job
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if job, err = s.store.RetryJob(ctx, jobID, reason); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: AuditJobRetry, TargetType: "job", TargetID: jobID, TenantID: job.TenantID, Reason: reason}, nil)
+	})
+	if err != nil {
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+func (s *Service) CreateGroup(ctx context.Context, input models.CreateJobGroupInput) (models.JobGroup, error) {
+	return s.store.CreateGroup(ctx, input)
+}
+
+func (s *Service) GetGroup(ctx context.Context, groupID string) (models.JobGroup, error) {
+	return s.store.GetGroup(ctx, groupID)
+}
+
+func (s *Service) GetGroupSummary(ctx context.Context, groupID string) (models.JobGroupSummary, error) {
+	return s.store.GetGroupSummary(ctx, groupID)
+}
+
+// CancelGroup cancels every unfinished job in the group and returns the IDs
+// that were cancelled.
+func (s *Service) CancelGroup(ctx context.Context, groupID string, reason string) ([]string, error) {
+	jobReason := reason
+	if jobReason == "" {
+		jobReason = "Group cancelled by operator"
+	}
+	var jobIDs []string
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if jobIDs, err = s.store.CancelGroup(ctx, groupID, jobReason); err != nil {
+			return err
+		}
+		return s.auditGroup(ctx, AuditGroupCancel, groupID, reason, jobIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Best effort queue/lease cleanup. DB state is the source of truth.
+	for _, jobID := range jobIDs {
+		if err := s.queue.RemoveQueuedJob(ctx, jobID); err != nil {
+			s.logger.Warn("failed to remove cancelled group job from ready queue", "group_id", groupID, "job_id", jobID, "error", err)
+		}
+		if err := s.queue.ReleaseLease(ctx, jobID); err != nil {
+			s.logger.Warn("failed to release cancelled group job lease", "group_id", groupID, "job_id", jobID, "error", err)
+		}
+	}
+
+	return jobIDs, nil
+}
+
+// RetryFailedGroup requeues every failed or dead-lettered job in the group and
+// returns the IDs that were requeued.
+func (s *Service) RetryFailedGroup(ctx context.Context, groupID string, reason string) ([]string, error) {
+	var jobIDs []string
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if jobIDs, err = s.store.RetryFailedGroup(ctx, groupID); err != nil {
+			return err
+		}
+		return s.auditGroup(ctx, AuditGroupRetryFailed, groupID, reason, jobIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobIDs, nil
+}
+
+func (s *Service) auditGroup(ctx context.Context, action string, groupID string, reason string, jobIDs []string) error {
+	group, err := s.store.GetGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	entry := models.AuditEntry{Action: action, TargetType: "group", TargetID: groupID, TenantID: group.TenantID, Reason: reason}
+	return s.audit(ctx, entry, map[string]any{"job_ids": jobIDs})
+}
+
+// QueuePendingByConsumer reports delivered but unacknowledged queue entries
+// per consumer for backends that track them.
+func (s *Service) QueuePendingByConsumer(ctx context.Context) (map[string]int64, error) {
+	reporter, ok := s.queue.(queue.PendingReporter)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return reporter.PendingByConsumer(ctx)
+}
+
+// checkGroups verifies that every referenced group exists and belongs to the
+// tenant submitting the job.
+func (s *Service) checkGroups(ctx context.Context, inputs []models.CreateJobInput) error {
+	checked := make(map[string]string)
+	for _, input := range inputs {
+		if input.GroupID == "" {
+			continue
+		}
+		tenantID, ok := checked[input.GroupID]
+		if !ok {
+			group, err := s.store.GetGroup(ctx, input.GroupID)
+			if errors.Is(err, store.ErrNotFound) {
+				return ErrGroupNotFound
+			}
+			if err != nil {
+				return err
+			}
+			tenantID = group.TenantID
+			checked[input.GroupID] = tenantID
+		}
+		if tenantID != input.TenantID {
+			return ErrGroupNotFound
+		}
+	}
+	return nil
+}
+
+func (s *Service) Store() store.Store {
 	return s.store
 }
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		recordSpanError(span, err)
+	}
+	span.End()
+}
+
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,943 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/queue"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+func newTestService(t *testing.T, idempotencyTTL time.Duration) (*Service, *store.MemoryStore, *queue.MemoryQueue) {
+	t.Helper()
+	memStore := store.NewMemoryStore()
+	memQueue := queue.NewMemoryQueue(30 * time.Second)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewService(memStore, memQueue, nil, idempotencyTTL, logger), memStore, memQueue
+}
+
+// drainOutbox returns the pending outbox entries, removing them.
+func drainOutbox(t *testing.T, memStore *store.MemoryStore) []models.OutboxEntry {
+	t.Helper()
+	var drained []models.OutboxEntry
+	if _, err := memStore.RelayOutbox(context.Background(), 1000, func(_ context.Context, entries []models.OutboxEntry) error {
+		drained = append(drained, entries...)
+		return nil
+	}); err != nil {
+		t.Fatalf("relay outbox: %v", err)
+	}
+	return drained
+}
+
+func outboxJobIDs(entries []models.OutboxEntry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.JobID)
+	}
+	return ids
+}
+
+func jobInput(tenantID string, prompt string) models.CreateJobInput {
+	return models.CreateJobInput{
+		TenantID:    tenantID,
+		Model:       "gpt-4.1-mini",
+		PayloadJSON: json.RawMessage(`{"prompt":"` + prompt + `"}`),
+	}
+}
+
+func mustCreateJob(t *testing.T, service *Service, input models.CreateJobInput) models.Job {
+	t.Helper()
+	job, _, err := service.CreateJob(context.Background(), input)
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	return job
+}
+
+func mustGetJob(t *testing.T, service *Service, jobID string) models.Job {
+	t.Helper()
+	snapshot, err := service.GetJob(context.Background(), jobID)
+	if err != nil {
+		t.Fatalf("get job %s: %v", jobID, err)
+	}
+	return snapshot.Job
+}
+
+// runJob drives a queued job through the worker transitions.
+func runJob(t *testing.T, memStore *store.MemoryStore, jobID string, succeed bool) {
+	t.Helper()
+	ctx := context.Background()
+	if _, updated, err := memStore.MarkJobRunning(ctx, jobID, "worker-test"); err != nil || !updated {
+		t.Fatalf("mark running %s: updated=%v err=%v", jobID, updated, err)
+	}
+	var err error
+	if succeed {
+		err = memStore.MarkJobSucceeded(ctx, jobID, "worker-test", models.Usage{PromptTokens: 60, CompletionTokens: 40}, 0.001, nil, json.RawMessage(`{"text":"done"}`))
+	} else {
+		err = memStore.MarkJobFailed(ctx, jobID, "worker-test", "PROVIDER_TIMEOUT", "mock provider timeout")
+	}
+	if err != nil {
+		t.Fatalf("finish job %s: %v", jobID, err)
+	}
+}
+
+func TestCreateJobAppliesDefaultsAndWritesOutbox(t *testing.T) {
+	service, memStore, memQueue := newTestService(t, 0)
+
+	job := mustCreateJob(t, service, jobInput("acme", "hello"))
+
+	if job.Status != models.JobStatusQueued {
+		t.Fatalf("status = %s, want queued", job.Status)
+	}
+	if job.Priority != 3 || job.MaxAttempts != 3 {
+		t.Fatalf("defaults = priority %d, max_attempts %d; want 3, 3", job.Priority, job.MaxAttempts)
+	}
+	if job.TraceID == "" {
+		t.Fatal("trace_id was not set")
+	}
+	// Publishing is the relay's job; the service never touches the queue.
+	if memQueue.Len() != 0 {
+		t.Fatalf("queue length = %d, want 0", memQueue.Len())
+	}
+	entries := drainOutbox(t, memStore)
+	if len(entries) != 1 || entries[0].JobID != job.ID || entries[0].Dedupe {
+		t.Fatalf("outbox = %+v, want one non-dedupe entry for %s", entries, job.ID)
+	}
+}
+
+func TestCreateJobIdempotency(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, _ := newTestService(t, 0)
+
+	input := jobInput("acme", "hello")
+	input.IdempotencyKey = "key-1"
+	first := mustCreateJob(t, service, input)
+
+	// Key order and whitespace in the payload do not change the fingerprint.
+	replay := input
+	replay.PayloadJSON = json.RawMessage(`{ "prompt" : "hello" }`)
+	job, existing, err := service.CreateJob(ctx, replay)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !existing || job.ID != first.ID {
+		t.Fatalf("replay returned %s (existing=%v), want %s", job.ID, existing, first.ID)
+	}
+
+	// The same key on another tenant is a different job.
+	other := input
+	other.TenantID = "globex"
+	if job := mustCreateJob(t, service, other); job.ID == first.ID {
+		t.Fatal("idempotency key leaked across tenants")
+	}
+
+	mismatch := input
+	mismatch.PayloadJSON = json.RawMessage(`{"prompt":"something else"}`)
+	if _, _, err := service.CreateJob(ctx, mismatch); !errors.Is(err, store.ErrIdempotencyMismatch) {
+		t.Fatalf("mismatch error = %v, want ErrIdempotencyMismatch", err)
+	}
+
+	if entries := drainOutbox(t, memStore); len(entries) != 2 {
+		t.Fatalf("outbox entries = %d, want 2 (one per created job)", len(entries))
+	}
+}
+
+func TestCreateJobIdempotencyKeyExpires(t *testing.T) {
+	service, _, _ := newTestService(t, time.Nanosecond)
+
+	input := jobInput("acme", "hello")
+	input.IdempotencyKey = "key-1"
+	first := mustCreateJob(t, service, input)
+	if first.IdempotencyExpiresAt == nil {
+		t.Fatal("idempotency_expires_at was not set")
+	}
+	time.Sleep(time.Millisecond)
+
+	// An expired key is released and may even carry a different request.
+	input.PayloadJSON = json.RawMessage(`{"prompt":"new work"}`)
+	second, existing, err := service.CreateJob(context.Background(), input)
+	if err != nil {
+		t.Fatalf("create after expiry: %v", err)
+	}
+	if existing || second.ID == first.ID {
+		t.Fatalf("expired key replayed job %s", first.ID)
+	}
+	if got := mustGetJob(t, service, first.ID); got.IdempotencyKey != "key-1" {
+		t.Fatalf("old job key = %q, want it kept", got.IdempotencyKey)
+	}
+}
+
+func TestCreateJobRejectsForeignGroup(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newTestService(t, 0)
+
+	group, err := service.CreateGroup(ctx, models.CreateJobGroupInput{TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		tenant  string
+		groupID string
+	}{
+		{name: "unknown group", tenant: "acme", groupID: "missing"},
+		{name: "group of another tenant", tenant: "globex", groupID: group.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := jobInput(tt.tenant, "hello")
+			input.GroupID = tt.groupID
+			if _, _, err := service.CreateJob(ctx, input); !errors.Is(err, ErrGroupNotFound) {
+				t.Fatalf("error = %v, want ErrGroupNotFound", err)
+			}
+			if _, err := service.CreateJobsBatch(ctx, []models.CreateJobInput{input}, false); !errors.Is(err, ErrGroupNotFound) {
+				t.Fatalf("batch error = %v, want ErrGroupNotFound", err)
+			}
+		})
+	}
+}
+
+func TestDependantIsReleasedWhenParentsSucceed(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, _ := newTestService(t, 0)
+
+	first := mustCreateJob(t, service, jobInput("acme", "first"))
+	second := mustCreateJob(t, service, jobInput("acme", "second"))
+	childInput := jobInput("acme", "child")
+	childInput.DependsOn = []string{first.ID, second.ID}
+	child := mustCreateJob(t, service, childInput)
+	if child.Status != models.JobStatusBlocked {
+		t.Fatalf("child status = %s, want blocked", child.Status)
+	}
+	drainOutbox(t, memStore)
+
+	runJob(t, memStore, first.ID, true)
+	if got := mustGetJob(t, service, child.ID); got.Status != models.JobStatusBlocked {
+		t.Fatalf("child status after first parent = %s, want blocked", got.Status)
+	}
+	if entries := drainOutbox(t, memStore); len(entries) != 0 {
+		t.Fatalf("outbox = %v, want empty while a parent is pending", outboxJobIDs(entries))
+	}
+
+	runJob(t, memStore, second.ID, true)
+	if got := mustGetJob(t, service, child.ID); got.Status != models.JobStatusQueued {
+		t.Fatalf("child status after both parents = %s, want queued", got.Status)
+	}
+	if ids := outboxJobIDs(drainOutbox(t, memStore)); len(ids) != 1 || ids[0] != child.ID {
+		t.Fatalf("outbox = %v, want [%s]", ids, child.ID)
+	}
+
+	snapshot, err := service.GetJob(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("get child: %v", err)
+	}
+	if len(snapshot.DependsOn) != 2 || snapshot.DependsOn[0] != first.ID || snapshot.DependsOn[1] != second.ID {
+		t.Fatalf("depends_on = %v, want [%s %s]", snapshot.DependsOn, first.ID, second.ID)
+	}
+}
+
+func TestParentFailureCancelsDescendants(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, _ := newTestService(t, 0)
+
+	parent := mustCreateJob(t, service, jobInput("acme", "parent"))
+	childInput := jobInput("acme", "child")
+	childInput.DependsOn = []string{parent.ID}
+	child := mustCreateJob(t, service, childInput)
+	grandchildInput := jobInput("acme", "grandchild")
+	grandchildInput.DependsOn = []string{child.ID}
+	grandchild := mustCreateJob(t, service, grandchildInput)
+
+	runJob(t, memStore, parent.ID, false)
+
+	for _, jobID := range []string{child.ID, grandchild.ID} {
+		got := mustGetJob(t, service, jobID)
+		if got.Status != models.JobStatusCancelled || got.ErrorCode != "DEPENDENCY_FAILED" {
+			t.Fatalf("job %s = %s/%s, want cancelled/DEPENDENCY_FAILED", jobID, got.Status, got.ErrorCode)
+		}
+	}
+
+	// New dependants of a failed parent are cancelled on creation.
+	late, _, err := service.CreateJob(ctx, childInput)
+	if err != nil {
+		t.Fatalf("create late child: %v", err)
+	}
+	if late.Status != models.JobStatusCancelled || late.FinishedAt == nil {
+		t.Fatalf("late child = %s (finished %v), want cancelled", late.Status, late.FinishedAt)
+	}
+}
+
+func TestRetriedParentReblocksCancelledDescendants(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, _ := newTestService(t, 0)
+
+	parent := mustCreateJob(t, service, jobInput("acme", "parent"))
+	childInput := jobInput("acme", "child")
+	childInput.DependsOn = []string{parent.ID}
+	child := mustCreateJob(t, service, childInput)
+	grandchildInput := jobInput("acme", "grandchild")
+	grandchildInput.DependsOn = []string{child.ID}
+	grandchild := mustCreateJob(t, service, grandchildInput)
+	// A dependant that also waits on a parent which stays failed is left
+	// cancelled.
+	other := mustCreateJob(t, service, jobInput("acme", "other"))
+	stuckInput := jobInput("acme", "stuck")
+	stuckInput.DependsOn = []string{parent.ID, other.ID}
+	stuck := mustCreateJob(t, service, stuckInput)
+
+	runJob(t, memStore, other.ID, false)
+	runJob(t, memStore, parent.ID, false)
+	if got := mustGetJob(t, service, child.ID); got.Status != models.JobStatusCancelled {
+		t.Fatalf("child status = %s, want cancelled", got.Status)
+	}
+	drainOutbox(t, memStore)
+
+	if _, err := service.RetryJob(ctx, parent.ID, ""); err != nil {
+		t.Fatalf("retry parent: %v", err)
+	}
+	for _, jobID := range []string{child.ID, grandchild.ID} {
+		got := mustGetJob(t, service, jobID)
+		if got.Status != models.JobStatusBlocked || got.ErrorCode != "" || got.FinishedAt != nil {
+			t.Fatalf("job %s = %s/%s, want blocked again", jobID, got.Status, got.ErrorCode)
+		}
+	}
+	if got := mustGetJob(t, service, stuck.ID); got.Status != models.JobStatusCancelled {
+		t.Fatalf("stuck status = %s, want cancelled while its other parent is failed", got.Status)
+	}
+
+	drainOutbox(t, memStore)
+	runJob(t, memStore, parent.ID, true)
+	if got := mustGetJob(t, service, child.ID); got.Status != models.JobStatusQueued {
+		t.Fatalf("child status after parent succeeded = %s, want queued", got.Status)
+	}
+	runJob(t, memStore, child.ID, true)
+	if got := mustGetJob(t, service, grandchild.ID); got.Status != models.JobStatusQueued {
+		t.Fatalf("grandchild status after child succeeded = %s, want queued", got.Status)
+	}
+}
+
+func TestCreateJobRejectsUnknownDependency(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newTestService(t, 0)
+
+	foreign := mustCreateJob(t, service, jobInput("globex", "foreign"))
+	for _, parentID := range []string{"missing", foreign.ID} {
+		input := jobInput("acme", "child")
+		input.DependsOn = []string{parentID}
+		if _, _, err := service.CreateJob(ctx, input); !errors.Is(err, store.ErrDependencyNotFound) {
+			t.Fatalf("depends_on %s: error = %v, want ErrDependencyNotFound", parentID, err)
+		}
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, memQueue := newTestService(t, 0)
+
+	job := mustCreateJob(t, service, jobInput("acme", "hello"))
+	if err := memQueue.EnqueueJob(ctx, job.ID); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	cancelled, err := service.CancelJob(ctx, job.ID, "")
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancelled.Status != models.JobStatusCancelled || cancelled.ErrorCode != "CANCELLED" {
+		t.Fatalf("cancelled job = %s/%s", cancelled.Status, cancelled.ErrorCode)
+	}
+	if memQueue.Len() != 0 {
+		t.Fatalf("queue length = %d, want the cancelled job removed", memQueue.Len())
+	}
+
+	if _, err := service.CancelJob(ctx, job.ID, ""); !errors.Is(err, store.ErrInvalidStateTransition) {
+		t.Fatalf("second cancel error = %v, want ErrInvalidStateTransition", err)
+	}
+	if _, err := service.CancelJob(ctx, "missing", ""); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("cancel missing error = %v, want ErrNotFound", err)
+	}
+
+	// Cancelling a running job closes its open attempt.
+	running := mustCreateJob(t, service, jobInput("acme", "running"))
+	if _, _, err := memStore.MarkJobRunning(ctx, running.ID, "worker-test"); err != nil {
+		t.Fatalf("mark running: %v", err)
+	}
+	if _, err := service.CancelJob(ctx, running.ID, ""); err != nil {
+		t.Fatalf("cancel running: %v", err)
+	}
+	history, err := service.ListJobAttempts(ctx, running.ID)
+	if err != nil {
+		t.Fatalf("list attempts: %v", err)
+	}
+	if history.Totals.Failed != 1 || history.Totals.InFlight != 0 {
+		t.Fatalf("totals = %+v, want one failed attempt", history.Totals)
+	}
+}
+
+func TestRetryJob(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, _ := newTestService(t, 0)
+
+	job := mustCreateJob(t, service, jobInput("acme", "hello"))
+	if _, err := service.RetryJob(ctx, job.ID, ""); err != nil {
+		t.Fatalf("retry queued job: %v", err)
+	}
+	runJob(t, memStore, job.ID, false)
+	if _, err := service.RetryJob(ctx, "missing", ""); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("retry missing error = %v, want ErrNotFound", err)
+	}
+	drainOutbox(t, memStore)
+
+	retried, err := service.RetryJob(ctx, job.ID, "")
+	if err != nil {
+		t.Fatalf("retry failed job: %v", err)
+	}
+	if retried.Status != models.JobStatusQueued || retried.ErrorCode != "" || retried.FinishedAt != nil {
+		t.Fatalf("retried job = %+v, want a clean queued job", retried)
+	}
+	entries := drainOutbox(t, memStore)
+	if len(entries) != 1 || !entries[0].Dedupe {
+		t.Fatalf("outbox = %+v, want one dedupe entry", entries)
+	}
+
+	runJob(t, memStore, job.ID, true)
+	if _, err := service.RetryJob(ctx, job.ID, ""); !errors.Is(err, store.ErrInvalidStateTransition) {
+		t.Fatalf("retry succeeded job error = %v, want ErrInvalidStateTransition", err)
+	}
+	history, err := service.ListJobAttempts(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("list attempts: %v", err)
+	}
+	if history.Totals.Attempts != 2 || history.Totals.Succeeded != 1 || history.Totals.Failed != 1 || history.Totals.Tokens != 100 {
+		t.Fatalf("totals = %+v, want 2 attempts, 1 succeeded, 1 failed, 100 tokens", history.Totals)
+	}
+}
+
+func TestRescheduleAndPromote(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, memQueue := newTestService(t, 0)
+
+	job := mustCreateJob(t, service, jobInput("acme", "hello"))
+	drainOutbox(t, memStore)
+	if err := memQueue.EnqueueJob(ctx, job.ID); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	rescheduled, err := service.RescheduleJob(ctx, job.ID, later, "")
+	if err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if rescheduled.Status != models.JobStatusScheduled {
+		t.Fatalf("status = %s, want scheduled", rescheduled.Status)
+	}
+	if memQueue.Len() != 0 {
+		t.Fatal("scheduled job was left in the ready queue")
+	}
+	if ids, err := memStore.PromoteDueJobs(ctx, 10); err != nil || len(ids) != 0 {
+		t.Fatalf("promote before run_at = %v, %v; want nothing", ids, err)
+	}
+
+	if _, err := service.RescheduleJob(ctx, job.ID, time.Now().Add(-time.Second), ""); err != nil {
+		t.Fatalf("reschedule into the past: %v", err)
+	}
+	if got := mustGetJob(t, service, job.ID); got.Status != models.JobStatusQueued {
+		t.Fatalf("status = %s, want queued", got.Status)
+	}
+	if entries := drainOutbox(t, memStore); len(entries) != 1 || !entries[0].Dedupe {
+		t.Fatalf("outbox = %+v, want one dedupe entry", entries)
+	}
+
+	delayed := jobInput("acme", "delayed")
+	past := time.Now().Add(50 * time.Millisecond)
+	delayed.RunAt = &past
+	scheduled := mustCreateJob(t, service, delayed)
+	if scheduled.Status != models.JobStatusScheduled {
+		t.Fatalf("delayed job status = %s, want scheduled", scheduled.Status)
+	}
+	time.Sleep(60 * time.Millisecond)
+	ids, err := memStore.PromoteDueJobs(ctx, 10)
+	if err != nil || len(ids) != 1 || ids[0] != scheduled.ID {
+		t.Fatalf("promote = %v, %v; want [%s]", ids, err, scheduled.ID)
+	}
+
+	runJob(t, memStore, scheduled.ID, true)
+	if _, err := service.RescheduleJob(ctx, scheduled.ID, later, ""); !errors.Is(err, store.ErrInvalidStateTransition) {
+		t.Fatalf("reschedule finished job error = %v, want ErrInvalidStateTransition", err)
+	}
+}
+
+func TestExpireOverdueJobs(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, _ := newTestService(t, 0)
+
+	group, err := service.CreateGroup(ctx, models.CreateJobGroupInput{TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	input := jobInput("acme", "late")
+	input.GroupID = group.ID
+	deadline := time.Now().Add(20 * time.Millisecond)
+	input.Deadline = &deadline
+	job := mustCreateJob(t, service, input)
+	childInput := jobInput("acme", "child")
+	childInput.DependsOn = []string{job.ID}
+	child := mustCreateJob(t, service, childInput)
+
+	time.Sleep(30 * time.Millisecond)
+	if _, updated, err := memStore.MarkJobRunning(ctx, job.ID, "worker-test"); err != nil || updated {
+		t.Fatalf("mark running past deadline: updated=%v err=%v", updated, err)
+	}
+
+	expired, err := memStore.ExpireOverdueJobs(ctx, 10)
+	if err != nil || len(expired) != 1 || expired[0] != job.ID {
+		t.Fatalf("expire = %v, %v; want [%s]", expired, err, job.ID)
+	}
+	got := mustGetJob(t, service, job.ID)
+	if got.Status != models.JobStatusFailed || got.ErrorCode != store.ExpiredCode {
+		t.Fatalf("expired job = %s/%s", got.Status, got.ErrorCode)
+	}
+	if got := mustGetJob(t, service, child.ID); got.Status != models.JobStatusCancelled {
+		t.Fatalf("child status = %s, want cancelled", got.Status)
+	}
+	summary, err := service.GetGroupSummary(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("group summary: %v", err)
+	}
+	if summary.Group.CompletedAt == nil {
+		t.Fatal("group was not completed by the expiry")
+	}
+
+	// A manual retry clears the passed deadline.
+	retried, err := service.RetryJob(ctx, job.ID, "")
+	if err != nil {
+		t.Fatalf("retry expired job: %v", err)
+	}
+	if retried.Deadline != nil {
+		t.Fatalf("deadline = %v, want cleared", retried.Deadline)
+	}
+}
+
+func TestCreateJobsBatch(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, _ := newTestService(t, 0)
+
+	existingInput := jobInput("acme", "existing")
+	existingInput.IdempotencyKey = "row-1"
+	existing := mustCreateJob(t, service, existingInput)
+	drainOutbox(t, memStore)
+
+	fresh := jobInput("acme", "fresh")
+	fresh.IdempotencyKey = "row-2"
+	duplicate := fresh
+	conflicting := jobInput("acme", "different")
+	conflicting.IdempotencyKey = "row-1"
+
+	t.Run("atomic mismatch rolls back", func(t *testing.T) {
+		results, err := service.CreateJobsBatch(ctx, []models.CreateJobInput{fresh, conflicting}, true)
+		if !errors.Is(err, store.ErrIdempotencyMismatch) {
+			t.Fatalf("error = %v, want ErrIdempotencyMismatch", err)
+		}
+		if results[0].Mismatch || !results[1].Mismatch {
+			t.Fatalf("mismatch flags = %v, %v; want false, true", results[0].Mismatch, results[1].Mismatch)
+		}
+		if entries := drainOutbox(t, memStore); len(entries) != 0 {
+			t.Fatalf("rolled back batch wrote outbox entries %v", outboxJobIDs(entries))
+		}
+		page, err := service.ListJobs(ctx, models.ListJobsFilter{IdempotencyKey: "row-2"})
+		if err != nil || len(page.Jobs) != 0 {
+			t.Fatalf("rolled back batch created jobs: %v, %v", page.Jobs, err)
+		}
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		results, err := service.CreateJobsBatch(ctx, []models.CreateJobInput{existingInput, fresh, duplicate, conflicting}, false)
+		if err != nil {
+			t.Fatalf("batch: %v", err)
+		}
+		if !results[0].Existing || results[0].Job.ID != existing.ID {
+			t.Fatalf("result 0 = %+v, want replay of %s", results[0], existing.ID)
+		}
+		if results[1].Existing || results[1].Job.Status != models.JobStatusQueued {
+			t.Fatalf("result 1 = %+v, want a created queued job", results[1])
+		}
+		if !results[2].Existing || results[2].Job.ID != results[1].Job.ID {
+			t.Fatalf("result 2 = %+v, want in-batch replay of %s", results[2], results[1].Job.ID)
+		}
+		if !results[3].Mismatch {
+			t.Fatalf("result 3 = %+v, want mismatch", results[3])
+		}
+		if ids := outboxJobIDs(drainOutbox(t, memStore)); len(ids) != 1 || ids[0] != results[1].Job.ID {
+			t.Fatalf("outbox = %v, want [%s]", ids, results[1].Job.ID)
+		}
+	})
+}
+
+func TestGroupLifecycle(t *testing.T) {
+	ctx := context.Background()
+	service, memStore, _ := newTestService(t, 0)
+
+	group, err := service.CreateGroup(ctx, models.CreateJobGroupInput{TenantID: "acme", Name: "nightly"})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	jobIDs := make([]string, 3)
+	for i := range jobIDs {
+		input := jobInput("acme", "item")
+		input.GroupID = group.ID
+		jobIDs[i] = mustCreateJob(t, service, input).ID
+	}
+
+	runJob(t, memStore, jobIDs[0], true)
+	runJob(t, memStore, jobIDs[1], false)
+
+	summary, err := service.GetGroupSummary(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if summary.TotalJobs != 3 || summary.TerminalJobs != 2 || summary.Counts[models.JobStatusQueued] != 1 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	if summary.TotalTokens != 100 {
+		t.Fatalf("total tokens = %d, want 100", summary.TotalTokens)
+	}
+	if summary.Group.CompletedAt != nil {
+		t.Fatal("group completed with a queued job left")
+	}
+
+	cancelled, err := service.CancelGroup(ctx, group.ID, "")
+	if err != nil {
+		t.Fatalf("cancel group: %v", err)
+	}
+	if len(cancelled) != 1 || cancelled[0] != jobIDs[2] {
+		t.Fatalf("cancelled = %v, want [%s]", cancelled, jobIDs[2])
+	}
+	summary, _ = service.GetGroupSummary(ctx, group.ID)
+	if summary.Group.CompletedAt == nil || summary.PercentComplete != 100 {
+		t.Fatalf("summary after cancel = %+v, want a completed group", summary)
+	}
+
+	drainOutbox(t, memStore)
+	retried, err := service.RetryFailedGroup(ctx, group.ID, "")
+	if err != nil {
+		t.Fatalf("retry group: %v", err)
+	}
+	if len(retried) != 1 || retried[0] != jobIDs[1] {
+		t.Fatalf("retried = %v, want [%s]", retried, jobIDs[1])
+	}
+	summary, _ = service.GetGroupSummary(ctx, group.ID)
+	if summary.Group.CompletedAt != nil {
+		t.Fatal("retry did not reopen the group")
+	}
+	if entries := drainOutbox(t, memStore); len(entries) != 1 || !entries[0].Dedupe {
+		t.Fatalf("outbox = %+v, want one dedupe entry", entries)
+	}
+
+	if _, err := service.CancelGroup(ctx, "missing", ""); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("cancel missing group error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListJobsPaginates(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newTestService(t, 0)
+
+	created := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		input := jobInput("acme", "item")
+		input.Priority = i + 1
+		created[mustCreateJob(t, service, input).ID] = true
+	}
+	mustCreateJob(t, service, jobInput("globex", "other"))
+
+	for _, sort := range []string{models.JobSortCreatedDesc, models.JobSortCreatedAsc, models.JobSortPriorityDesc} {
+		t.Run(sort, func(t *testing.T) {
+			seen := make(map[string]bool)
+			filter := models.ListJobsFilter{TenantID: "acme", Sort: sort, Limit: 2, IncludeTotal: true}
+			lastPriority := 6
+			for pages := 0; ; pages++ {
+				if pages > 5 {
+					t.Fatal("pagination did not terminate")
+				}
+				page, err := service.ListJobs(ctx, filter)
+				if err != nil {
+					t.Fatalf("list: %v", err)
+				}
+				if page.Total == nil || *page.Total != 5 {
+					t.Fatalf("total = %v, want 5", page.Total)
+				}
+				for _, job := range page.Jobs {
+					if seen[job.ID] || !created[job.ID] {
+						t.Fatalf("unexpected or repeated job %s", job.ID)
+					}
+					seen[job.ID] = true
+					if sort == models.JobSortPriorityDesc {
+						if job.Priority > lastPriority {
+							t.Fatalf("priority %d after %d", job.Priority, lastPriority)
+						}
+						lastPriority = job.Priority
+					}
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				filter.Cursor = page.NextCursor
+			}
+			if len(seen) != 5 {
+				t.Fatalf("saw %d jobs, want 5", len(seen))
+			}
+		})
+	}
+
+	minPriority := 4
+	page, err := service.ListJobs(ctx, models.ListJobsFilter{TenantID: "acme", MinPriority: &minPriority})
+	if err != nil || len(page.Jobs) != 2 {
+		t.Fatalf("priority filter = %d jobs, %v; want 2", len(page.Jobs), err)
+	}
+
+	if _, err := service.ListJobs(ctx, models.ListJobsFilter{Sort: models.JobSortCreatedAsc, Cursor: "not-a-cursor"}); !errors.Is(err, store.ErrInvalidCursor) {
+		t.Fatalf("bad cursor error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestSchedules(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newTestService(t, 0)
+
+	invalid := []models.CreateScheduleInput{
+		{TenantID: "acme", Model: "m", CronExpr: "not a cron"},
+		{TenantID: "acme", Model: "m", CronExpr: "@hourly", Timezone: "Mars/Olympus"},
+		{TenantID: "acme", Model: "m", CronExpr: "@hourly", OverlapPolicy: "sometimes"},
+	}
+	for _, input := range invalid {
+		if _, err := service.CreateSchedule(ctx, input); !errors.Is(err, ErrInvalidSchedule) {
+			t.Fatalf("create %+v error = %v, want ErrInvalidSchedule", input, err)
+		}
+	}
+	// Parses, but February never has a 30th.
+	never := models.CreateScheduleInput{TenantID: "acme", Model: "m", CronExpr: "0 0 30 2 *"}
+	if _, err := service.CreateSchedule(ctx, never); !errors.Is(err, ErrInvalidSchedule) || !errors.Is(err, ErrScheduleNeverFires) {
+		t.Fatalf("create never-firing schedule error = %v, want ErrScheduleNeverFires", err)
+	}
+
+	schedule, err := service.CreateSchedule(ctx, models.CreateScheduleInput{TenantID: "acme", Model: "m", CronExpr: "*/5 * * * *"})
+	if err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	if schedule.Timezone != "UTC" || schedule.OverlapPolicy != models.OverlapSkip || schedule.NextFireAt == nil {
+		t.Fatalf("schedule defaults = %+v", schedule)
+	}
+	if !schedule.NextFireAt.After(time.Now()) || schedule.NextFireAt.Minute()%5 != 0 {
+		t.Fatalf("next fire = %v, want the next 5-minute slot", schedule.NextFireAt)
+	}
+
+	paused, err := service.PauseSchedule(ctx, schedule.ID, "")
+	if err != nil || !paused.Paused {
+		t.Fatalf("pause = %+v, %v", paused, err)
+	}
+	resumed, err := service.ResumeSchedule(ctx, schedule.ID, "")
+	if err != nil || resumed.Paused || resumed.NextFireAt == nil {
+		t.Fatalf("resume = %+v, %v", resumed, err)
+	}
+
+	if schedules, err := service.ListSchedules(ctx, "globex"); err != nil || len(schedules) != 0 {
+		t.Fatalf("list other tenant = %v, %v; want none", schedules, err)
+	}
+	if err := service.DeleteSchedule(ctx, schedule.ID, ""); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := service.GetSchedule(ctx, schedule.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("get deleted error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestQueuePendingByConsumerRequiresSupport(t *testing.T) {
+	service, _, _ := newTestService(t, 0)
+	if _, err := service.QueuePendingByConsumer(context.Background()); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestAddModelPrice(t *testing.T) {
+	service, memStore, _ := newTestService(t, 0)
+	ctx := context.Background()
+
+	for _, price := range []models.ModelPrice{
+		{InputPer1K: 1, OutputPer1K: 1},
+		{Model: "m", InputPer1K: -1, OutputPer1K: 1},
+	} {
+		if _, err := service.AddModelPrice(ctx, price, ""); !errors.Is(err, ErrInvalidModelPrice) {
+			t.Fatalf("add %+v error = %v, want ErrInvalidModelPrice", price, err)
+		}
+	}
+
+	effective := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	price := models.ModelPrice{Model: "m", EffectiveFrom: effective, InputPer1K: 2, CachedInputPer1K: 0.5, OutputPer1K: 8}
+	if _, err := service.AddModelPrice(ctx, price, "launch pricing"); err != nil {
+		t.Fatalf("add price: %v", err)
+	}
+	if _, err := service.AddModelPrice(ctx, price, ""); !errors.Is(err, store.ErrPriceExists) {
+		t.Fatalf("duplicate error = %v, want ErrPriceExists", err)
+	}
+	now, err := service.AddModelPrice(ctx, models.ModelPrice{Model: "m", InputPer1K: 1, OutputPer1K: 4}, "")
+	if err != nil {
+		t.Fatalf("add current price: %v", err)
+	}
+	if time.Since(now.EffectiveFrom) > time.Minute {
+		t.Fatalf("default effective_from = %v, want now", now.EffectiveFrom)
+	}
+
+	// An attempt before the newer price keeps the launch price.
+	old, err := memStore.GetModelPrice(ctx, "m", effective.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("get price: %v", err)
+	}
+	if !old.EffectiveFrom.Equal(effective) {
+		t.Fatalf("price effective from %v, want %v", old.EffectiveFrom, effective)
+	}
+	usage := models.Usage{PromptTokens: 1000, CachedPromptTokens: 400, CompletionTokens: 500}
+	if cost := old.Cost(usage); cost != 0.6*2+0.4*0.5+0.5*8 {
+		t.Fatalf("cost = %v", cost)
+	}
+	if _, err := memStore.GetModelPrice(ctx, "m", effective.Add(-time.Second)); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("price before the first one error = %v, want ErrNotFound", err)
+	}
+
+	prices, err := service.ListModelPrices(ctx, "m")
+	if err != nil || len(prices) != 2 || !prices[0].EffectiveFrom.Equal(now.EffectiveFrom) {
+		t.Fatalf("prices = %+v, %v; want newest first", prices, err)
+	}
+	page, err := service.ListAudit(ctx, models.AuditFilter{Action: AuditModelPriceCreate})
+	if err != nil || len(page.Entries) != 2 || page.Entries[1].Reason != "launch pricing" {
+		t.Fatalf("audit = %+v, %v", page, err)
+	}
+}
+
+func TestSpendCaps(t *testing.T) {
+	service, memStore, _ := newTestService(t, 0)
+	ctx := context.Background()
+	countEvents := func(eventType string) int {
+		count := 0
+		for _, event := range memStore.Events() {
+			if event.Type == eventType {
+				count++
+			}
+		}
+		return count
+	}
+
+	if _, err := service.SetSpendCap(ctx, "acme", -1, ""); !errors.Is(err, ErrInvalidSpendCap) {
+		t.Fatalf("negative cap error = %v, want ErrInvalidSpendCap", err)
+	}
+	if _, err := service.OverrideSpendCap(ctx, "acme", nil, time.Now().Add(-time.Minute), ""); !errors.Is(err, ErrInvalidSpendCap) {
+		t.Fatalf("past override error = %v, want ErrInvalidSpendCap", err)
+	}
+	keyed := jobInput("acme", "keyed")
+	keyed.IdempotencyKey = "before-cap"
+	keyedJob := mustCreateJob(t, service, keyed)
+	if _, err := service.SetSpendCap(ctx, "acme", 0.0024, "pilot budget"); err != nil {
+		t.Fatalf("set cap: %v", err)
+	}
+
+	// Each job costs $0.001: the second crosses 80% of the cap, the third the
+	// cap itself. Each alert fires once.
+	for i := 0; i < 3; i++ {
+		runJob(t, memStore, mustCreateJob(t, service, jobInput("acme", "spend")).ID, true)
+	}
+	if alerts, reached := countEvents("tenant.spend_alert"), countEvents("tenant.spend_cap_reached"); alerts != 1 || reached != 1 {
+		t.Fatalf("spend_alert = %d, spend_cap_reached = %d; want 1 each", alerts, reached)
+	}
+	status, err := service.GetSpendStatus(ctx, "acme")
+	if err != nil || !status.OverCap || status.SoftAlertedAt == nil || status.CapReachedAt == nil {
+		t.Fatalf("status = %+v, %v; want over cap with both alerts", status, err)
+	}
+
+	if _, _, err := service.CreateJob(ctx, jobInput("acme", "over")); !errors.Is(err, ErrSpendCapExceeded) {
+		t.Fatalf("create over cap error = %v, want ErrSpendCapExceeded", err)
+	}
+	// Replays return the existing job whatever the cap.
+	if replay, existing, err := service.CreateJob(ctx, keyed); err != nil || !existing || replay.ID != keyedJob.ID {
+		t.Fatalf("replay over cap = %s, %v, %v; want %s", replay.ID, existing, err, keyedJob.ID)
+	}
+	batch := []models.CreateJobInput{jobInput("globex", "ok"), jobInput("acme", "over"), keyed}
+	if _, err := service.CreateJobsBatch(ctx, batch, true); !errors.Is(err, ErrSpendCapExceeded) {
+		t.Fatalf("atomic batch over cap error = %v, want ErrSpendCapExceeded", err)
+	}
+	results, err := service.CreateJobsBatch(ctx, batch, false)
+	if err != nil {
+		t.Fatalf("partial batch over cap: %v", err)
+	}
+	if results[0].Existing || results[0].Job.ID == "" || results[0].Rejected != nil {
+		t.Fatalf("uncapped tenant result = %+v, want created", results[0])
+	}
+	if !errors.Is(results[1].Rejected, ErrSpendCapExceeded) || results[1].Job.ID != "" {
+		t.Fatalf("over cap result = %+v, want rejected", results[1])
+	}
+	if !results[2].Existing || results[2].Job.ID != keyedJob.ID || results[2].Rejected != nil {
+		t.Fatalf("replayed result = %+v, want %s", results[2], keyedJob.ID)
+	}
+	mustCreateJob(t, service, jobInput("globex", "uncapped"))
+
+	// An override without a cap lifts it until it is cleared.
+	if _, err := service.OverrideSpendCap(ctx, "acme", nil, time.Now().Add(time.Hour), "incident"); err != nil {
+		t.Fatalf("override: %v", err)
+	}
+	mustCreateJob(t, service, jobInput("acme", "overridden"))
+	if _, err := service.ClearSpendCapOverride(ctx, "acme", ""); err != nil {
+		t.Fatalf("clear override: %v", err)
+	}
+	if _, _, err := service.CreateJob(ctx, jobInput("acme", "over")); !errors.Is(err, ErrSpendCapExceeded) {
+		t.Fatalf("create after override error = %v, want ErrSpendCapExceeded", err)
+	}
+
+	// Raising the cap re-arms the alerts.
+	if _, err := service.SetSpendCap(ctx, "acme", 1, ""); err != nil {
+		t.Fatalf("raise cap: %v", err)
+	}
+	status, err = service.GetSpendStatus(ctx, "acme")
+	if err != nil || status.OverCap || status.SoftAlertedAt != nil || status.SpentUSD != 0.003 {
+		t.Fatalf("status after raise = %+v, %v", status, err)
+	}
+
+	statuses, err := service.ListSpendStatuses(ctx)
+	if err != nil || len(statuses) != 1 || statuses[0].TenantID != "acme" {
+		t.Fatalf("statuses = %+v, %v", statuses, err)
+	}
+	page, err := service.ListAudit(ctx, models.AuditFilter{TargetType: "spend_cap"})
+	if err != nil || len(page.Entries) != 4 || page.Entries[3].Reason != "pilot budget" {
+		t.Fatalf("audit = %+v, %v", page, err)
+	}
+}
+
+// failingAuditStore refuses every audit entry.
+type failingAuditStore struct {
+	*store.MemoryStore
+}
+
+var errAuditUnavailable = errors.New("audit log unavailable")
+
+func (failingAuditStore) AppendAudit(context.Context, models.AuditEntry) (models.AuditEntry, error) {
+	return models.AuditEntry{}, errAuditUnavailable
+}
+
+func TestAuditFailureFailsTheAction(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := NewService(failingAuditStore{memStore}, queue.NewMemoryQueue(30*time.Second), nil, 0, logger)
+
+	job := mustCreateJob(t, service, jobInput("acme", "hello"))
+	if _, err := service.CancelJob(ctx, job.ID, "operator request"); !errors.Is(err, errAuditUnavailable) {
+		t.Fatalf("cancel error = %v, want the audit failure", err)
+	}
+	if _, err := service.SetRetentionPolicy(ctx, models.RetentionPolicy{TenantID: "acme", JobsRetentionDays: 7}, ""); !errors.Is(err, errAuditUnavailable) {
+		t.Fatalf("set retention error = %v, want the audit failure", err)
+	}
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// ErrInvalidSpendCap wraps validation failures of a spend cap or override.
+var ErrInvalidSpendCap = errors.New("invalid spend cap")
+
+// ErrSpendCapExceeded is returned for new jobs of a tenant that has spent its
+// monthly cap.
+var ErrSpendCapExceeded = errors.New("tenant has reached its monthly spend cap")
+
+func (s *Service) GetSpendStatus(ctx context.Context, tenantID string) (models.SpendStatus, error) {
+	return s.store.GetSpendStatus(ctx, tenantID)
+}
+
+func (s *Service) ListSpendStatuses(ctx context.Context) ([]models.SpendStatus, error) {
+	return s.store.ListSpendStatuses(ctx)
+}
+
+// SetSpendCap sets the tenant's monthly cap in USD. Alerts already sent this
+// month are re-armed against the new cap.
+func (s *Service) SetSpendCap(ctx context.Context, tenantID string, capUSD float64, reason string) (models.SpendCap, error) {
+	if err := validateSpendCap(tenantID, &capUSD); err != nil {
+		return models.SpendCap{}, err
+	}
+	return s.updateSpendCap(ctx, AuditSpendCapSet, reason, func(ctx context.Context) (models.SpendCap, error) {
+		return s.store.SetSpendCap(ctx, tenantID, &capUSD)
+	})
+}
+
+// DeleteSpendCap removes the tenant's monthly cap. An override in force still
+// applies until it ends.
+func (s *Service) DeleteSpendCap(ctx context.Context, tenantID string, reason string) (models.SpendCap, error) {
+	if err := validateSpendCap(tenantID, nil); err != nil {
+		return models.SpendCap{}, err
+	}
+	return s.updateSpendCap(ctx, AuditSpendCapDelete, reason, func(ctx context.Context) (models.SpendCap, error) {
+		return s.store.SetSpendCap(ctx, tenantID, nil)
+	})
+}
+
+// OverrideSpendCap replaces the tenant's cap with capUSD until until, which
+// must be in the future. A nil capUSD lifts the cap for that time.
+func (s *Service) OverrideSpendCap(ctx context.Context, tenantID string, capUSD *float64, until time.Time, reason string) (models.SpendCap, error) {
+	if err := validateSpendCap(tenantID, capUSD); err != nil {
+		return models.SpendCap{}, err
+	}
+	if !until.After(time.Now()) {
+		return models.SpendCap{}, fmt.Errorf("%w: until must be in the future", ErrInvalidSpendCap)
+	}
+	until = until.UTC().Truncate(time.Microsecond)
+	return s.updateSpendCap(ctx, AuditSpendCapOverride, reason, func(ctx context.Context) (models.SpendCap, error) {
+		return s.store.SetSpendCapOverride(ctx, tenantID, capUSD, &until)
+	})
+}
+
+// ClearSpendCapOverride ends an override early.
+func (s *Service) ClearSpendCapOverride(ctx context.Context, tenantID string, reason string) (models.SpendCap, error) {
+	if err := validateSpendCap(tenantID, nil); err != nil {
+		return models.SpendCap{}, err
+	}
+	return s.updateSpendCap(ctx, AuditSpendCapOverrideClear, reason, func(ctx context.Context) (models.SpendCap, error) {
+		return s.store.SetSpendCapOverride(ctx, tenantID, nil, nil)
+	})
+}
+
+// updateSpendCap applies update and audits the resulting cap in one
+// transaction.
+func (s *Service) updateSpendCap(ctx context.Context, action string, reason string, update func(ctx context.Context) (models.SpendCap, error)) (models.SpendCap, error) {
+	var spendCap models.SpendCap
+	err := s.store.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if spendCap, err = update(ctx); err != nil {
+			return err
+		}
+		return s.audit(ctx, models.AuditEntry{Action: action, TargetType: "spend_cap", TargetID: spendCap.TenantID, TenantID: spendCap.TenantID, Reason: reason}, spendCap)
+	})
+	if err != nil {
+		return models.SpendCap{}, err
+	}
+	return spendCap, nil
+}
+
+func validateSpendCap(tenantID string, capUSD *float64) error {
+	if tenantID == "" {
+		return fmt.Errorf("%w: tenant_id is required", ErrInvalidSpendCap)
+	}
+	if capUSD != nil && (*capUSD < 0 || math.IsNaN(*capUSD) || math.IsInf(*capUSD, 0)) {
+		return fmt.Errorf("%w: cap_usd must be 0 or greater", ErrInvalidSpendCap)
+	}
+	return nil
+}
+
+// checkSpendCaps returns, per input, an ErrSpendCapExceeded error when the
+// input would create a job for a tenant that has reached its cap. Replays of
+// existing jobs pass. Jobs already admitted are held by the worker instead.
+func (s *Service) checkSpendCaps(ctx context.Context, inputs []models.CreateJobInput) ([]error, error) {
+	capErrors := make([]error, len(inputs))
+	byTenant := make(map[string]error)
+	var replayed []bool
+	for index, input := range inputs {
+		capErr, checked := byTenant[input.TenantID]
+		if !checked {
+			status, err := s.store.GetSpendStatus(ctx, input.TenantID)
+			if err != nil {
+				return nil, err
+			}
+			if status.OverCap {
+				capErr = fmt.Errorf("%w: spent $%.2f of $%.2f in %s", ErrSpendCapExceeded, status.SpentUSD, *status.EffectiveCapUSD, status.Month)
+			}
+			byTenant[input.TenantID] = capErr
+		}
+		if capErr == nil {
+			continue
+		}
+		// Replays return a job that already exists, so only look them up once
+		// a tenant turns out to be over its cap.
+		if replayed == nil {
+			var err error
+			if replayed, err = s.store.ReplayedInputs(ctx, inputs); err != nil {
+				return nil, err
+			}
+		}
+		if !replayed[index] {
+			capErrors[index] = capErr
+		}
+	}
+	return capErrors, nil
+}
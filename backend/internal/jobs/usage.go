@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// maxUsageDays bounds the range of one usage report.
+const maxUsageDays = 366
+
+// usageDayLayout is how usage report days are written and parsed.
+const usageDayLayout = "2006-01-02"
+
+// ErrInvalidUsageQuery wraps validation failures of a usage report request.
+var ErrInvalidUsageQuery = errors.New("invalid usage query")
+
+// Usage reports token and cost usage between two UTC days, both inclusive,
+// grouped by day or by model. From defaults to the first day of the current
+// month and To to today.
+func (s *Service) Usage(ctx context.Context, filter models.UsageFilter) (models.UsageReport, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if filter.GroupBy == "" {
+		filter.GroupBy = models.UsageGroupByDay
+	}
+	if filter.GroupBy != models.UsageGroupByDay && filter.GroupBy != models.UsageGroupByModel {
+		return models.UsageReport{}, fmt.Errorf("%w: group_by must be day or model", ErrInvalidUsageQuery)
+	}
+	if filter.To.IsZero() {
+		filter.To = today
+	}
+	if filter.From.IsZero() {
+		filter.From = time.Date(filter.To.Year(), filter.To.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if filter.To.Before(filter.From) {
+		return models.UsageReport{}, fmt.Errorf("%w: to must not be before from", ErrInvalidUsageQuery)
+	}
+	if days := int(filter.To.Sub(filter.From).Hours()/24) + 1; days > maxUsageDays {
+		return models.UsageReport{}, fmt.Errorf("%w: a report covers at most %d days", ErrInvalidUsageQuery, maxUsageDays)
+	}
+
+	rows, err := s.store.UsageReport(ctx, filter)
+	if err != nil {
+		return models.UsageReport{}, err
+	}
+	report := models.UsageReport{
+		TenantID: filter.TenantID,
+		From:     filter.From.Format(usageDayLayout),
+		To:       filter.To.Format(usageDayLayout),
+		GroupBy:  filter.GroupBy,
+		Rows:     rows,
+	}
+	for _, row := range rows {
+		report.Totals.Add(row.UsageAmounts)
+	}
+	return report, nil
+}
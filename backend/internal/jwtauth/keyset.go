@@ -0,0 +1,236 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minRefetchInterval limits how often an unknown key ID triggers a reload, so
+// tokens with made-up kids cannot hammer the identity provider.
+const minRefetchInterval = 30 * time.Second
+
+// maxJWKSBytes bounds the size of a JWKS document.
+const maxJWKSBytes = 1 << 20
+
+// ErrKeyNotFound is returned when no key in the set matches a token's kid.
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// KeySet is a cached JSON Web Key Set loaded from a URL or a local file. Keys
+// are reloaded once they are older than the refresh interval, and early when
+// a token names a key ID the cache does not have, which is how key rotation
+// at the identity provider shows up.
+type KeySet struct {
+	load    func(ctx context.Context) ([]byte, error)
+	source  string
+	refresh time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	loadedAt    time.Time
+	attemptedAt time.Time
+	loadErr     error
+}
+
+// NewRemoteKeySet returns a key set fetched from url, typically the jwks_uri of
+// an OIDC provider.
+func NewRemoteKeySet(url string, refresh time.Duration) *KeySet {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &KeySet{
+		source:  url,
+		refresh: refresh,
+		load: func(ctx context.Context) ([]byte, error) {
+			request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			response, err := client.Do(request)
+			if err != nil {
+				return nil, err
+			}
+			defer response.Body.Close()
+			if response.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("unexpected status %d", response.StatusCode)
+			}
+			return io.ReadAll(io.LimitReader(response.Body, maxJWKSBytes))
+		},
+	}
+}
+
+// NewFileKeySet returns a key set read from a local JWKS file. The file is
+// read again on the same schedule as a remote set.
+func NewFileKeySet(path string, refresh time.Duration) *KeySet {
+	return &KeySet{
+		source:  path,
+		refresh: refresh,
+		load: func(ctx context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+	}
+}
+
+// Key returns the public key for kid. An empty kid matches the only key of a
+// single-key set.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	stale := k.keys == nil || now.Sub(k.loadedAt) >= k.refresh
+	// After a failed reload the next attempt waits minRefetchInterval, so an
+	// unreachable provider does not put a blocking fetch on every request.
+	backoff := now.Sub(k.attemptedAt) < minRefetchInterval
+	failing := k.attemptedAt.After(k.loadedAt)
+	key, found := k.lookup(kid)
+	if (stale && !(failing && backoff)) || (!found && !backoff) {
+		// A failed refresh keeps serving the keys loaded before.
+		k.reload(ctx, now)
+		key, found = k.lookup(kid)
+	}
+	if k.keys == nil {
+		return nil, k.loadErr
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// reload records its outcome in loadErr, which Key returns while no keys
+// have been loaded yet.
+func (k *KeySet) reload(ctx context.Context, now time.Time) {
+	k.attemptedAt = now
+	raw, err := k.load(ctx)
+	if err != nil {
+		k.loadErr = fmt.Errorf("load jwks from %s: %w", k.source, err)
+		return
+	}
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		k.loadErr = fmt.Errorf("parse jwks from %s: %w", k.source, err)
+		return
+	}
+	k.keys = keys
+	k.loadedAt = now
+	k.loadErr = nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS keeps the RSA and EC signing keys of a JWKS document and skips
+// keys of other types or uses.
+func parseJWKS(raw []byte) (map[string]crypto.PublicKey, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaKey()
+		case "EC":
+			key, err = jwk.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := decodeBigInt(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("unsupported exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (jwk jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+	x, err := decodeBigInt(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := decodeBigInt(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,312 @@
+// Package jwtauth validates JWT bearer tokens issued by an OIDC identity
+// provider and maps their claims to a tenant and a role.
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// clockSkew is how far exp, nbf and iat may be off between us and the issuer.
+const clockSkew = time.Minute
+
+// ErrInvalidToken wraps every reason a token is rejected.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config selects which tokens are accepted and how their claims are read.
+type Config struct {
+	// Issuer must equal the iss claim.
+	Issuer string
+	// Audience must be one of the aud claim's values. Empty skips the check.
+	Audience string
+	// TenantClaim and RoleClaim name the claims holding the tenant and role.
+	TenantClaim string
+	RoleClaim   string
+	// RoleMap translates role claim values, such as IdP group names, to roles.
+	// When empty, claim values must be role names themselves.
+	RoleMap map[string]models.Role
+}
+
+// Identity is the caller a valid token describes.
+type Identity struct {
+	Subject  string
+	TenantID string
+	Role     models.Role
+}
+
+// KeySource resolves the key a token was signed with.
+type KeySource interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+type Verifier struct {
+	cfg  Config
+	keys KeySource
+	now  func() time.Time
+}
+
+func NewVerifier(cfg Config, keys KeySource) *Verifier {
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant_id"
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "role"
+	}
+	return &Verifier{cfg: cfg, keys: keys, now: time.Now}
+}
+
+// LooksLikeJWT reports whether a bearer credential has the shape of a JWT
+// rather than an API key.
+func LooksLikeJWT(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+// Verify checks the token's signature, issuer, audience and lifetime and
+// returns the identity its claims describe.
+func (v *Verifier) Verify(ctx context.Context, token string) (Identity, error) {
+	claims, err := v.verifySignature(ctx, token)
+	if err != nil {
+		return Identity{}, err
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Identity{}, err
+	}
+	return v.identity(claims)
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// claims holds the standard claims plus everything else for the mapping.
+type claims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	IssuedAt  *float64        `json:"iat"`
+	all       map[string]any
+}
+
+func (v *Verifier) verifySignature(ctx context.Context, token string) (claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	algorithm, ok := algorithms[header.Alg]
+	if !ok {
+		return claims{}, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+	key, err := v.keys.Key(ctx, header.Kid)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		return claims{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims{}, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	if !algorithm.verify(key, []byte(parts[0]+"."+parts[1]), signature) {
+		return claims{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var parsed claims
+	if err := decodeSegment(parts[1], &parsed); err != nil {
+		return claims{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := decodeSegment(parts[1], &parsed.all); err != nil {
+		return claims{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	return parsed, nil
+}
+
+func (v *Verifier) checkClaims(c claims) error {
+	now := v.now()
+	switch {
+	case c.Issuer != v.cfg.Issuer:
+		return fmt.Errorf("%w: issuer %q is not trusted", ErrInvalidToken, c.Issuer)
+	case c.ExpiresAt == nil:
+		return fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	case now.After(unixTime(*c.ExpiresAt).Add(clockSkew)):
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	case c.NotBefore != nil && now.Add(clockSkew).Before(unixTime(*c.NotBefore)):
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	case c.IssuedAt != nil && now.Add(clockSkew).Before(unixTime(*c.IssuedAt)):
+		return fmt.Errorf("%w: token issued in the future", ErrInvalidToken)
+	}
+	if v.cfg.Audience == "" {
+		return nil
+	}
+	for _, audience := range stringValues(c.Audience) {
+		if audience == v.cfg.Audience {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: audience does not include %q", ErrInvalidToken, v.cfg.Audience)
+}
+
+// identity maps the configured claims. The role claim may hold one value or
+// a list, such as IdP groups; the most privileged mapped role wins. Operators
+// act for every tenant; other roles use the tenant claim, which tenant users
+// and tenant admins must carry.
+func (v *Verifier) identity(c claims) (Identity, error) {
+	identity := Identity{Subject: c.Subject}
+
+	raw, _ := json.Marshal(c.all[v.cfg.RoleClaim])
+	for _, value := range stringValues(raw) {
+		role := models.Role(value)
+		if len(v.cfg.RoleMap) > 0 {
+			role = v.cfg.RoleMap[value]
+		}
+		if role.Valid() && rank(role) > rank(identity.Role) {
+			identity.Role = role
+		}
+	}
+	if identity.Role == "" {
+		return Identity{}, fmt.Errorf("%w: claim %q carries no known role", ErrInvalidToken, v.cfg.RoleClaim)
+	}
+	if identity.Role == models.RoleOperator {
+		return identity, nil
+	}
+
+	if tenant, ok := c.all[v.cfg.TenantClaim].(string); ok {
+		identity.TenantID = tenant
+	}
+	if identity.TenantID == "" && identity.Role != models.RoleViewer {
+		return Identity{}, fmt.Errorf("%w: claim %q is required for %s", ErrInvalidToken, v.cfg.TenantClaim, identity.Role)
+	}
+	return identity, nil
+}
+
+func rank(role models.Role) int {
+	switch role {
+	case models.RoleViewer:
+		return 1
+	case models.RoleTenantUser:
+		return 2
+	case models.RoleTenantAdmin:
+		return 3
+	case models.RoleOperator:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// ParseRoleMap parses "value=role,value=role" as used by JWT_ROLE_MAP.
+func ParseRoleMap(value string) (map[string]models.Role, error) {
+	roles := map[string]models.Role{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		claimValue, role, ok := strings.Cut(entry, "=")
+		if !ok || !models.Role(strings.TrimSpace(role)).Valid() {
+			return nil, fmt.Errorf("invalid role mapping %q", entry)
+		}
+		roles[strings.TrimSpace(claimValue)] = models.Role(strings.TrimSpace(role))
+	}
+	return roles, nil
+}
+
+// stringValues reads a claim that is a string or a list of strings.
+func stringValues(raw json.RawMessage) []string {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	return nil
+}
+
+func decodeSegment(segment string, out any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+type algorithm struct {
+	verify func(key crypto.PublicKey, signed []byte, signature []byte) bool
+}
+
+// algorithms lists the accepted signature algorithms. The key type must match
+// the algorithm, so an RSA key can never be used to check an HMAC or EC token.
+var algorithms = map[string]algorithm{
+	"RS256": rsaPKCS1(crypto.SHA256),
+	"RS384": rsaPKCS1(crypto.SHA384),
+	"RS512": rsaPKCS1(crypto.SHA512),
+	"PS256": rsaPSS(crypto.SHA256),
+	"PS384": rsaPSS(crypto.SHA384),
+	"PS512": rsaPSS(crypto.SHA512),
+	"ES256": ecdsaFixed(crypto.SHA256, 256),
+	"ES384": ecdsaFixed(crypto.SHA384, 384),
+	"ES512": ecdsaFixed(crypto.SHA512, 521),
+}
+
+func digest(hash crypto.Hash, signed []byte) []byte {
+	h := hash.New()
+	h.Write(signed)
+	return h.Sum(nil)
+}
+
+func rsaPKCS1(hash crypto.Hash) algorithm {
+	return algorithm{verify: func(key crypto.PublicKey, signed []byte, signature []byte) bool {
+		rsaKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(rsaKey, hash, digest(hash, signed), signature) == nil
+	}}
+}
+
+func rsaPSS(hash crypto.Hash) algorithm {
+	return algorithm{verify: func(key crypto.PublicKey, signed []byte, signature []byte) bool {
+		rsaKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPSS(rsaKey, hash, digest(hash, signed), signature, nil) == nil
+	}}
+}
+
+// ecdsaFixed checks JWS ECDSA signatures, which are r and s concatenated at
+// the curve's byte size rather than ASN.1. Each algorithm fixes its curve.
+func ecdsaFixed(hash crypto.Hash, curveBits int) algorithm {
+	return algorithm{verify: func(key crypto.PublicKey, signed []byte, signature []byte) bool {
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve.Params().BitSize != curveBits {
+			return false
+		}
+		size := (curveBits + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(ecKey, digest(hash, signed), r, s)
+	}}
+}
@@ -0,0 +1,310 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+const testIssuer = "https://idp.example.test"
+
+// testSigner holds a locally generated key and the JWK publishing it.
+type testSigner struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func newRSASigner(t *testing.T, kid string) testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	return testSigner{kid: kid, alg: "RS256", key: key}
+}
+
+func newECSigner(t *testing.T, kid string) testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+	return testSigner{kid: kid, alg: "ES256", key: key}
+}
+
+func (s testSigner) jwk() map[string]string {
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	switch key := s.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": s.kid, "use": "sig", "n": encode(key.N), "e": encode(big.NewInt(int64(key.E)))}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": s.kid, "crv": "P-256", "x": encode(key.X), "y": encode(key.Y)}
+	}
+	return nil
+}
+
+func (s testSigner) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": s.alg, "kid": s.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := digest(crypto.SHA256, []byte(signed))
+
+	var signature []byte
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, sig, err := ecdsa.Sign(rand.Reader, key, sum)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		sig.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJWKS(t *testing.T, path string, signers ...testSigner) {
+	t.Helper()
+	keys := make([]map[string]string, 0, len(signers))
+	for _, signer := range signers {
+		keys = append(keys, signer.jwk())
+	}
+	raw, _ := json.Marshal(map[string]any{"keys": keys})
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+}
+
+func validClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":       testIssuer,
+		"sub":       "svc-reporting",
+		"aud":       []string{"other", "job-queue"},
+		"iat":       now.Unix(),
+		"exp":       now.Add(5 * time.Minute).Unix(),
+		"tenant_id": "acme",
+		"role":      "tenant-user",
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rsaSigner := newRSASigner(t, "rsa-1")
+	ecSigner := newECSigner(t, "ec-1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaSigner, ecSigner)
+	verifier := NewVerifier(Config{Issuer: testIssuer, Audience: "job-queue"}, NewFileKeySet(path, time.Hour))
+
+	with := func(changes map[string]any) map[string]any {
+		claims := validClaims()
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+	unsigned := func(claims map[string]any) string {
+		header, _ := json.Marshal(map[string]string{"alg": "none"})
+		payload, _ := json.Marshal(claims)
+		return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+	}
+	// An RS256 header over an EC signature must not verify with the EC key.
+	confused := testSigner{kid: "ec-1", alg: "RS256", key: rsaSigner.key}.sign(t, validClaims())
+	// A valid signature over different claims.
+	original := strings.Split(rsaSigner.sign(t, validClaims()), ".")
+	escalated := strings.Split(rsaSigner.sign(t, with(map[string]any{"role": "operator"})), ".")
+	tampered := original[0] + "." + escalated[1] + "." + original[2]
+
+	tests := []struct {
+		name  string
+		token string
+		want  Identity
+	}{
+		{name: "rs256", token: rsaSigner.sign(t, validClaims()), want: Identity{Subject: "svc-reporting", TenantID: "acme", Role: models.RoleTenantUser}},
+		{name: "es256", token: ecSigner.sign(t, validClaims()), want: Identity{Subject: "svc-reporting", TenantID: "acme", Role: models.RoleTenantUser}},
+		{name: "operator ignores tenant", token: rsaSigner.sign(t, with(map[string]any{"role": "operator"})), want: Identity{Subject: "svc-reporting", Role: models.RoleOperator}},
+		{name: "wrong issuer", token: rsaSigner.sign(t, with(map[string]any{"iss": "https://evil.test"}))},
+		{name: "wrong audience", token: rsaSigner.sign(t, with(map[string]any{"aud": "other"}))},
+		{name: "expired", token: rsaSigner.sign(t, with(map[string]any{"exp": time.Now().Add(-2 * time.Minute).Unix()}))},
+		{name: "no exp", token: rsaSigner.sign(t, with(map[string]any{"exp": nil}))},
+		{name: "not yet valid", token: rsaSigner.sign(t, with(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()}))},
+		{name: "unknown role", token: rsaSigner.sign(t, with(map[string]any{"role": "superuser"}))},
+		{name: "tenant role without tenant", token: rsaSigner.sign(t, with(map[string]any{"tenant_id": nil}))},
+		{name: "unknown kid", token: testSigner{kid: "rsa-2", alg: "RS256", key: rsaSigner.key}.sign(t, validClaims())},
+		{name: "alg none", token: unsigned(validClaims())},
+		{name: "alg and key type mismatch", token: confused},
+		{name: "tampered claims", token: tampered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := verifier.Verify(context.Background(), tt.token)
+			if tt.want.Role == "" {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() = %+v, %v, want ErrInvalidToken", identity, err)
+				}
+				return
+			}
+			if err != nil || identity != tt.want {
+				t.Fatalf("Verify() = %+v, %v, want %+v", identity, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleMapPicksMostPrivilegedGroup(t *testing.T) {
+	signer := newECSigner(t, "ec-1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, signer)
+
+	roles, err := ParseRoleMap("jq-viewers=read-only-viewer, jq-admins=tenant-admin")
+	if err != nil {
+		t.Fatalf("ParseRoleMap: %v", err)
+	}
+	if _, err := ParseRoleMap("jq-root=root"); err == nil {
+		t.Fatalf("ParseRoleMap accepted an unknown role")
+	}
+	verifier := NewVerifier(Config{Issuer: testIssuer, TenantClaim: "org", RoleClaim: "groups", RoleMap: roles}, NewFileKeySet(path, time.Hour))
+
+	claims := validClaims()
+	claims["org"] = "globex"
+	claims["groups"] = []string{"everyone", "jq-viewers", "jq-admins"}
+	identity, err := verifier.Verify(context.Background(), signer.sign(t, claims))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if identity.TenantID != "globex" || identity.Role != models.RoleTenantAdmin {
+		t.Fatalf("identity = %+v, want a globex tenant-admin", identity)
+	}
+
+	// Unmapped values are not roles even when they spell one.
+	claims["groups"] = []string{"operator"}
+	if _, err := verifier.Verify(context.Background(), signer.sign(t, claims)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify with unmapped group = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRemoteKeySetRefreshesOnUnknownKid(t *testing.T) {
+	first := newRSASigner(t, "k1")
+	second := newRSASigner(t, "k2")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, first)
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		http.ServeFile(w, r, path)
+	}))
+	defer server.Close()
+
+	keys := NewRemoteKeySet(server.URL, time.Hour)
+	verifier := NewVerifier(Config{Issuer: testIssuer}, keys)
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(context.Background(), first.sign(t, validClaims())); err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("fetches = %d, want 1 while the cache is fresh", got)
+	}
+
+	// The IdP rotates keys: a token with the new kid triggers one reload.
+	writeJWKS(t, path, first, second)
+	keys.attemptedAt = time.Time{}
+	if _, err := verifier.Verify(context.Background(), second.sign(t, validClaims())); err != nil {
+		t.Fatalf("Verify after rotation: %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("fetches = %d, want 2", got)
+	}
+
+	// Unknown kids do not reload again within minRefetchInterval.
+	stranger := testSigner{kid: "k3", alg: "RS256", key: second.key}
+	if _, err := verifier.Verify(context.Background(), stranger.sign(t, validClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify with unknown kid = %v, want ErrInvalidToken", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("fetches = %d, want no reload within the refetch interval", got)
+	}
+}
+
+func TestKeySetBacksOffAfterFailedReload(t *testing.T) {
+	signer := newRSASigner(t, "k1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, signer)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read jwks: %v", err)
+	}
+
+	var loads atomic.Int32
+	var down atomic.Bool
+	keys := &KeySet{source: "test", refresh: time.Nanosecond, load: func(ctx context.Context) ([]byte, error) {
+		loads.Add(1)
+		if down.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return raw, nil
+	}}
+	if _, err := keys.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+
+	// The provider goes away: the stale cache is tried once, then served
+	// without further fetches until minRefetchInterval has passed.
+	down.Store(true)
+	for i := 0; i < 3; i++ {
+		if _, err := keys.Key(context.Background(), "k1"); err != nil {
+			t.Fatalf("Key with a failing loader: %v", err)
+		}
+	}
+	if got := loads.Load(); got != 2 {
+		t.Fatalf("loads = %d, want 2", got)
+	}
+
+	keys.attemptedAt = keys.attemptedAt.Add(-minRefetchInterval)
+	if _, err := keys.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("Key after the refetch interval: %v", err)
+	}
+	if got := loads.Load(); got != 3 {
+		t.Fatalf("loads = %d, want a retry after the refetch interval", got)
+	}
+
+	// Without cached keys the load error is returned, and is not retried
+	// on every call either.
+	empty := &KeySet{source: "test", refresh: time.Hour, load: keys.load}
+	for i := 0; i < 2; i++ {
+		if _, err := empty.Key(context.Background(), "k1"); err == nil || errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("Key without cached keys = %v, want the load error", err)
+		}
+	}
+	if got := loads.Load(); got != 4 {
+		t.Fatalf("loads = %d, want 4", got)
+	}
+}
@@ -0,0 +1,416 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"job-queue-llm-orchestrator/backend/migrations"
+)
+
+// migrateLockKey is the Postgres advisory lock key that serializes migration
+// runs, so two deploys starting at once apply each migration exactly once.
+const migrateLockKey int64 = 0x6a6f626d69677261 // "jobmigra"
+
+const downSuffix = ".down.sql"
+
+var ErrChecksumMismatch = errors.New("applied migration checksum mismatch")
+var ErrSchemaTooOld = errors.New("database schema is older than this binary")
+
+// Migration is one versioned schema change. Files are named NNN_name.sql, with
+// an optional NNN_name.down.sql that reverts it.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status describes one migration known either to the binary or to the
+// database.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the embedded file no longer matches the checksum
+	// recorded when it was applied.
+	Modified bool
+	// Missing is set for applied versions the binary has no file for.
+	Missing bool
+}
+
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Load reads migrations from fsys and returns them ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	downs := map[int]string{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || path.Ext(fileName) != ".sql" {
+			continue
+		}
+		isDown := strings.HasSuffix(fileName, downSuffix)
+		base := strings.TrimSuffix(strings.TrimSuffix(fileName, downSuffix), ".sql")
+
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must look like NNN_name.sql", fileName)
+		}
+
+		body, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", fileName, err)
+		}
+		if isDown {
+			downs[version] = string(body)
+			continue
+		}
+		if existing, ok := byVersion[version]; ok {
+			return nil, fmt.Errorf("migration version %d used by both %s and %s", version, existing.Name, name)
+		}
+		sum := sha256.Sum256(body)
+		byVersion[version] = &Migration{
+			Version:  version,
+			Name:     name,
+			Up:       string(body),
+			Checksum: hex.EncodeToString(sum[:]),
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, down := range downs {
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("down migration for version %d has no up migration", version)
+		}
+		migration.Down = down
+	}
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the highest version in migrations, or 0 when there are none.
+func Latest(migrations []Migration) int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrator applies and reverts migrations, recording each applied version with
+// the checksum of its file in schema_migrations.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func NewMigrator(pool *pgxpool.Pool, migrations []Migration) *Migrator {
+	return &Migrator{pool: pool, migrations: migrations}
+}
+
+// Up applies pending migrations up to and including target, or all of them
+// when target is 0. It refuses to run if an applied file has been edited.
+func (m *Migrator) Up(ctx context.Context, target int) ([]Migration, error) {
+	if target == 0 {
+		target = Latest(m.migrations)
+	}
+
+	var ran []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if record, ok := applied[migration.Version]; ok && record.checksum != migration.Checksum {
+				return fmt.Errorf("%w: %03d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := applyUp(ctx, conn, migration); err != nil {
+				return err
+			}
+			ran = append(ran, migration)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Baseline records every migration up to and including target as applied
+// without running it. It is meant for databases whose schema was created by
+// hand, and refuses a target that is not a known version.
+func (m *Migrator) Baseline(ctx context.Context, target int) ([]Migration, error) {
+	known := false
+	for _, migration := range m.migrations {
+		known = known || migration.Version == target
+	}
+	if !known {
+		return nil, fmt.Errorf("baseline target %d is not a known migration version", target)
+	}
+
+	var recorded []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if _, err := conn.Exec(ctx, insertAppliedSQL, migration.Version, migration.Name, migration.Checksum); err != nil {
+				return fmt.Errorf("record migration %03d: %w", migration.Version, err)
+			}
+			recorded = append(recorded, migration)
+		}
+		return nil
+	})
+	return recorded, err
+}
+
+// Down reverts the most recently applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("down steps must be positive")
+	}
+
+	byVersion := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var ran []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		for _, version := range versions {
+			if len(ran) == steps {
+				break
+			}
+			migration, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migration %03d_%s is applied but unknown to this binary", version, applied[version].name)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %03d_%s has no down file", migration.Version, migration.Name)
+			}
+			if err := applyDown(ctx, conn, migration); err != nil {
+				return err
+			}
+			ran = append(ran, migration)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Status lists every migration the binary or the database knows about.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire migration connection: %w", err)
+	}
+	defer conn.Release()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := loadApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.appliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = record.checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		appliedAt := record.appliedAt
+		statuses = append(statuses, Status{Version: record.version, Name: record.name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// SchemaVersioner reports the newest migration version applied to a
+// database.
+type SchemaVersioner interface {
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+// CheckSchema returns ErrSchemaTooOld unless every migration up to required
+// has been applied. API and worker processes call it at startup so they never
+// run queries against columns that do not exist yet.
+func CheckSchema(ctx context.Context, db SchemaVersioner, required int) error {
+	current, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if current < required {
+		return fmt.Errorf("%w: at version %d, need %d (run cmd/migrate up)", ErrSchemaTooOld, current, required)
+	}
+	return nil
+}
+
+// CheckEmbedded runs CheckSchema against the newest migration embedded in
+// this binary.
+func CheckEmbedded(ctx context.Context, db SchemaVersioner) error {
+	all, err := Load(migrations.Files)
+	if err != nil {
+		return err
+	}
+	return CheckSchema(ctx, db, Latest(all))
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory
+// lock, waiting for any concurrent run to finish first.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire migration connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrateLockKey); err != nil {
+		return fmt.Errorf("take migration lock: %w", err)
+	}
+	defer func() {
+		// Closing the connection releases the lock server-side if unlocking fails.
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrateLockKey); err != nil {
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func loadApplied(ctx context.Context, conn *pgxpool.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var record appliedMigration
+		if err := rows.Scan(&record.version, &record.name, &record.checksum, &record.appliedAt); err != nil {
+			return nil, fmt.Errorf("scan applied migration: %w", err)
+		}
+		applied[record.version] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+const insertAppliedSQL = `
+INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`
+
+func applyUp(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin migration %03d: %w", migration.Version, err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if _, err := tx.Exec(ctx, migration.Up); err != nil {
+		return fmt.Errorf("apply migration %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(ctx, insertAppliedSQL, migration.Version, migration.Name, migration.Checksum); err != nil {
+		return fmt.Errorf("record migration %03d: %w", migration.Version, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit migration %03d: %w", migration.Version, err)
+	}
+	return nil
+}
+
+func applyDown(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin revert %03d: %w", migration.Version, err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if _, err := tx.Exec(ctx, migration.Down); err != nil {
+		return fmt.Errorf("revert migration %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+		return fmt.Errorf("unrecord migration %03d: %w", migration.Version, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit revert %03d: %w", migration.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func file(body string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(body)}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{
+			name:  "no version prefix",
+			files: fstest.MapFS{"init.sql": file("SELECT 1;")},
+			want:  "name must look like NNN_name.sql",
+		},
+		{
+			name:  "no name",
+			files: fstest.MapFS{"001.sql": file("SELECT 1;")},
+			want:  "name must look like NNN_name.sql",
+		},
+		{
+			name:  "version zero",
+			files: fstest.MapFS{"000_init.sql": file("SELECT 1;")},
+			want:  "name must look like NNN_name.sql",
+		},
+		{
+			name:  "bad down name",
+			files: fstest.MapFS{"001_init.sql": file("SELECT 1;"), "x_init.down.sql": file("SELECT 1;")},
+			want:  "name must look like NNN_name.sql",
+		},
+		{
+			name:  "duplicate version",
+			files: fstest.MapFS{"001_init.sql": file("SELECT 1;"), "1_other.sql": file("SELECT 2;")},
+			want:  "migration version 1 used by both init and other",
+		},
+		{
+			name:  "down without up",
+			files: fstest.MapFS{"001_init.sql": file("SELECT 1;"), "002_extra.down.sql": file("SELECT 2;")},
+			want:  "down migration for version 2 has no up migration",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	files := fstest.MapFS{
+		"010_ten.sql":        file("SELECT 10;"),
+		"002_two.sql":        file("SELECT 2;"),
+		"002_two.down.sql":   file("SELECT -2;"),
+		"001_one.sql":        file("SELECT 1;"),
+		"README.md":          file("not a migration"),
+		"fixtures/003_x.sql": file("SELECT 3;"),
+	}
+	migrations, err := Load(files)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	want := []struct {
+		version int
+		name    string
+		down    string
+	}{
+		{1, "one", ""},
+		{2, "two", "SELECT -2;"},
+		{10, "ten", ""},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("loaded %d migrations, want %d", len(migrations), len(want))
+	}
+	for i, w := range want {
+		got := migrations[i]
+		if got.Version != w.version || got.Name != w.name || got.Down != w.down {
+			t.Errorf("migration %d = %d %s down %q, want %d %s down %q", i, got.Version, got.Name, got.Down, w.version, w.name, w.down)
+		}
+	}
+	// printf 'SELECT 1;' | sha256sum
+	if want := "17db4fd369edb9244b9f91d9aeed145c3d04ad8ba6e95d06247f07a63527d11a"; migrations[0].Checksum != want {
+		t.Errorf("checksum = %s, want %s", migrations[0].Checksum, want)
+	}
+	if Latest(migrations) != 10 || Latest(nil) != 0 {
+		t.Errorf("Latest = %d, %d; want 10, 0", Latest(migrations), Latest(nil))
+	}
+}
@@ -15,14 +15,30 @@ const (
 	JobStatusRetryScheduled JobStatus = "retry_scheduled"
 	JobStatusDLQ            JobStatus = "dlq"
 	JobStatusCancelled      JobStatus = "cancelled"
+	JobStatusBlocked        JobStatus = "blocked"
+	JobStatusScheduled      JobStatus = "scheduled"
 )
 
+// IsTerminal reports whether no further work is scheduled for a job in this
+// status without an explicit retry.
+func (s JobStatus) IsTerminal() bool {
+	switch s {
+	case JobStatusSucceeded, JobStatusFailed, JobStatusDLQ, JobStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
 type Job struct {
 	ID             string          `json:"id"`
 	TenantID       string          `json:"tenant_id"`
 	Status         JobStatus       `json:"status"`
 	Priority       int             `json:"priority"`
 	Model          string          `json:"model"`
+	ModelUsed      string          `json:"model_used,omitempty"`
+	FallbackModels []string        `json:"fallback_models,omitempty"`
+	FallbackOn     []string        `json:"fallback_on,omitempty"`
 	PayloadJSON    json.RawMessage `json:"payload_json"`
 	IdempotencyKey string          `json:"idempotency_key,omitempty"`
 	Attempt        int             `json:"attempt"`
@@ -33,31 +49,476 @@ type Job struct {
 	ErrorCode      string          `json:"error_code,omitempty"`
 	ErrorMessage   string          `json:"error_message,omitempty"`
 	TraceID        string          `json:"trace_id"`
+	Traceparent    string          `json:"traceparent,omitempty"`
+	Tracestate     string          `json:"tracestate,omitempty"`
+	GroupID        string          `json:"group_id,omitempty"`
+	ResultJSON     json.RawMessage `json:"result_json,omitempty"`
+	RunAt          *time.Time      `json:"run_at,omitempty"`
+	Deadline       *time.Time      `json:"deadline,omitempty"`
+	// QueuedAt is when the job last became runnable.
+	QueuedAt *time.Time `json:"queued_at,omitempty"`
+
+	IdempotencyExpiresAt *time.Time `json:"idempotency_expires_at,omitempty"`
 }
 
 type JobAttempt struct {
-	JobID        string          `json:"job_id"`
-	Attempt      int             `json:"attempt"`
-	StartedAt    *time.Time      `json:"started_at,omitempty"`
-	FinishedAt   *time.Time      `json:"finished_at,omitempty"`
-	Success      *bool           `json:"success,omitempty"`
-	ErrorCode    string          `json:"error_code,omitempty"`
-	ErrorMessage string          `json:"error_message,omitempty"`
-	Tokens       int             `json:"tokens,omitempty"`
-	CostUSD      float64         `json:"cost_usd,omitempty"`
-	ProviderMeta json.RawMessage `json:"provider_meta,omitempty"`
+	JobID              string          `json:"job_id"`
+	Attempt            int             `json:"attempt"`
+	Model              string          `json:"model,omitempty"`
+	WorkerID           string          `json:"worker_id,omitempty"`
+	StartedAt          *time.Time      `json:"started_at,omitempty"`
+	FinishedAt         *time.Time      `json:"finished_at,omitempty"`
+	DurationMS         *int64          `json:"duration_ms,omitempty"`
+	Success            *bool           `json:"success,omitempty"`
+	ErrorCode          string          `json:"error_code,omitempty"`
+	ErrorMessage       string          `json:"error_message,omitempty"`
+	Tokens             int             `json:"tokens,omitempty"`
+	PromptTokens       int             `json:"prompt_tokens,omitempty"`
+	CachedPromptTokens int             `json:"cached_prompt_tokens,omitempty"`
+	CompletionTokens   int             `json:"completion_tokens,omitempty"`
+	CostUSD            float64         `json:"cost_usd,omitempty"`
+	ProviderMeta       json.RawMessage `json:"provider_meta,omitempty"`
+}
+
+type JobAttemptTotals struct {
+	Attempts   int     `json:"attempts"`
+	Succeeded  int     `json:"succeeded"`
+	Failed     int     `json:"failed"`
+	InFlight   int     `json:"in_flight"`
+	Tokens     int     `json:"tokens"`
+	CostUSD    float64 `json:"cost_usd"`
+	DurationMS int64   `json:"duration_ms"`
+}
+
+type JobAttemptHistory struct {
+	JobID    string           `json:"job_id"`
+	Attempts []JobAttempt     `json:"attempts"`
+	Totals   JobAttemptTotals `json:"totals"`
 }
 
 type JobSnapshot struct {
 	Job           Job         `json:"job"`
 	LatestAttempt *JobAttempt `json:"latest_attempt,omitempty"`
+	DependsOn     []string    `json:"depends_on,omitempty"`
+}
+
+// DependencyOutput is the result of a parent job made available to the
+// payload template of its dependants.
+type DependencyOutput struct {
+	JobID  string          `json:"job_id"`
+	Model  string          `json:"model"`
+	Result json.RawMessage `json:"result"`
 }
 
 type CreateJobInput struct {
 	TenantID       string
 	Priority       int
 	Model          string
+	FallbackModels []string
+	FallbackOn     []string
 	PayloadJSON    json.RawMessage
 	IdempotencyKey string
 	MaxAttempts    int
+	TraceID        string
+	Traceparent    string
+	Tracestate     string
+	GroupID        string
+	DependsOn      []string
+	RunAt          *time.Time
+	Deadline       *time.Time
+
+	// IdempotencyExpiresAt is when IdempotencyKey may be reused; nil keeps
+	// the key reserved for as long as the job exists.
+	IdempotencyExpiresAt *time.Time
+}
+
+type JobGroup struct {
+	ID                 string     `json:"id"`
+	TenantID           string     `json:"tenant_id"`
+	Name               string     `json:"name,omitempty"`
+	WebhookURL         string     `json:"webhook_url,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	WebhookDeliveredAt *time.Time `json:"webhook_delivered_at,omitempty"`
+}
+
+type JobGroupSummary struct {
+	Group           JobGroup          `json:"group"`
+	Counts          map[JobStatus]int `json:"counts"`
+	TotalJobs       int               `json:"total_jobs"`
+	TerminalJobs    int               `json:"terminal_jobs"`
+	PercentComplete float64           `json:"percent_complete"`
+	TotalTokens     int               `json:"total_tokens"`
+	TotalCostUSD    float64           `json:"total_cost_usd"`
+}
+
+type CreateJobGroupInput struct {
+	TenantID   string
+	Name       string
+	WebhookURL string
+}
+
+// OutboxEntry is a pending publish of a job ID to the ready queue.
+type OutboxEntry struct {
+	ID        int64
+	JobID     string
+	Dedupe    bool
+	CreatedAt time.Time
+}
+
+type CreateJobResult struct {
+	Job      Job
+	Existing bool
+	// Mismatch marks an input whose idempotency key belongs to a job created
+	// from a different request; Job is that existing job.
+	Mismatch bool
+	// Rejected is set for inputs of a partial batch that were not stored
+	// because their tenant is over its monthly spend cap.
+	Rejected error
+}
+
+const (
+	JobSortCreatedDesc  = "created_desc"
+	JobSortCreatedAsc   = "created_asc"
+	JobSortPriorityDesc = "priority_desc"
+)
+
+type ListJobsFilter struct {
+	Statuses       []JobStatus
+	TenantID       string
+	Model          string
+	ErrorCode      string
+	IdempotencyKey string
+	TraceID        string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	FinishedAfter  *time.Time
+	FinishedBefore *time.Time
+	MinPriority    *int
+	MaxPriority    *int
+	Sort           string
+	Cursor         string
+	Limit          int
+	IncludeTotal   bool
+}
+
+type JobPage struct {
+	Jobs       []Job  `json:"jobs"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+const (
+	OverlapSkip  = "skip"
+	OverlapQueue = "queue"
+	OverlapAllow = "allow"
+)
+
+type Schedule struct {
+	ID            string          `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	Name          string          `json:"name,omitempty"`
+	CronExpr      string          `json:"cron"`
+	Timezone      string          `json:"timezone"`
+	Model         string          `json:"model"`
+	PayloadJSON   json.RawMessage `json:"payload_json"`
+	Priority      int             `json:"priority"`
+	MaxAttempts   int             `json:"max_attempts"`
+	OverlapPolicy string          `json:"overlap_policy"`
+	Paused        bool            `json:"paused"`
+	NextFireAt    *time.Time      `json:"next_fire_at,omitempty"`
+	LastFiredAt   *time.Time      `json:"last_fired_at,omitempty"`
+	LastJobID     string          `json:"last_job_id,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+type CreateScheduleInput struct {
+	TenantID      string
+	Name          string
+	CronExpr      string
+	Timezone      string
+	Model         string
+	PayloadJSON   json.RawMessage
+	Priority      int
+	MaxAttempts   int
+	OverlapPolicy string
+}
+
+// RetentionPolicy controls how long a tenant's finished jobs are kept. Zero
+// JobsRetentionDays keeps them forever.
+type RetentionPolicy struct {
+	TenantID          string    `json:"tenant_id"`
+	JobsRetentionDays int       `json:"jobs_retention_days"`
+	Archive           bool      `json:"archive"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Role decides which routes a credential may call. See httpapi for the
+// permissions each role carries.
+type Role string
+
+const (
+	// RoleViewer may read jobs, groups and schedules.
+	RoleViewer Role = "read-only-viewer"
+	// RoleTenantUser may also submit and manage jobs, groups and schedules.
+	RoleTenantUser Role = "tenant-user"
+	// RoleTenantAdmin may also manage its tenant's API keys.
+	RoleTenantAdmin Role = "tenant-admin"
+	// RoleOperator runs the service and may call every route for any tenant.
+	RoleOperator Role = "operator"
+)
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleViewer, RoleTenantUser, RoleTenantAdmin, RoleOperator:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKey is a credential with a role. Keys with a TenantID act only for that
+// tenant; operator and viewer keys may have none and then see every tenant.
+// Only a hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         string     `json:"id"`
+	TenantID   string     `json:"tenant_id"`
+	Name       string     `json:"name,omitempty"`
+	Role       Role       `json:"role"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy string     `json:"replaced_by,omitempty"`
+}
+
+// Active reports whether the key may still authenticate at now.
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// IssuedAPIKey carries the plaintext key, which is returned exactly once when
+// the key is created or rotated.
+type IssuedAPIKey struct {
+	APIKey APIKey `json:"api_key"`
+	Key    string `json:"key"`
+}
+
+// Actor is who performed an audited action and from where.
+type Actor struct {
+	// Type is admin_key, api_key, jwt, anonymous (authentication disabled) or
+	// system (background processes).
+	Type string `json:"type"`
+	// ID is the API key ID or the JWT subject.
+	ID        string `json:"id,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	Role      Role   `json:"role,omitempty"`
+	SourceIP  string `json:"source_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// AuditEntry records one mutating action. TenantID is the tenant owning the
+// target, which may differ from the actor's for operators.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Action     string          `json:"action"`
+	Actor      Actor           `json:"actor"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	TenantID   string          `json:"tenant_id,omitempty"`
+	Reason     string          `json:"reason,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+}
+
+// AuditFilter selects audit entries, newest first. BeforeID pages backwards.
+type AuditFilter struct {
+	TenantID   string
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	BeforeID   int64
+	Limit      int
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// Usage is the token usage a provider reports for one call. Cached prompt
+// tokens are the part of PromptTokens served from the provider's prompt cache.
+type Usage struct {
+	PromptTokens       int `json:"prompt_tokens,omitempty"`
+	CachedPromptTokens int `json:"cached_prompt_tokens,omitempty"`
+	CompletionTokens   int `json:"completion_tokens,omitempty"`
+}
+
+// Total is the number of tokens billed, prompt and completion together.
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// ModelPrice is the USD price per 1K tokens of a model from EffectiveFrom
+// until the next price of the same model takes effect.
+type ModelPrice struct {
+	Model            string    `json:"model"`
+	EffectiveFrom    time.Time `json:"effective_from"`
+	InputPer1K       float64   `json:"input_per_1k_usd"`
+	CachedInputPer1K float64   `json:"cached_input_per_1k_usd"`
+	OutputPer1K      float64   `json:"output_per_1k_usd"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Cost prices usage. Cached prompt tokens are charged at CachedInputPer1K and
+// the rest of the prompt at InputPer1K.
+func (p ModelPrice) Cost(usage Usage) float64 {
+	uncached := usage.PromptTokens - usage.CachedPromptTokens
+	return (float64(uncached)*p.InputPer1K +
+		float64(usage.CachedPromptTokens)*p.CachedInputPer1K +
+		float64(usage.CompletionTokens)*p.OutputPer1K) / 1000
+}
+
+const (
+	UsageGroupByDay   = "day"
+	UsageGroupByModel = "model"
+)
+
+// UsageFilter selects attempts finished between the UTC days From and To,
+// both inclusive.
+type UsageFilter struct {
+	TenantID string
+	From     time.Time
+	To       time.Time
+	GroupBy  string
+}
+
+// UsageAmounts sums the tokens and cost of finished attempts. Failed attempts
+// are included in every total and also counted on their own.
+type UsageAmounts struct {
+	Attempts           int     `json:"attempts"`
+	FailedAttempts     int     `json:"failed_attempts"`
+	PromptTokens       int     `json:"prompt_tokens"`
+	CachedPromptTokens int     `json:"cached_prompt_tokens"`
+	CompletionTokens   int     `json:"completion_tokens"`
+	Tokens             int     `json:"tokens"`
+	CostUSD            float64 `json:"cost_usd"`
+	FailedCostUSD      float64 `json:"failed_cost_usd"`
+}
+
+// Add accumulates other into a.
+func (a *UsageAmounts) Add(other UsageAmounts) {
+	a.Attempts += other.Attempts
+	a.FailedAttempts += other.FailedAttempts
+	a.PromptTokens += other.PromptTokens
+	a.CachedPromptTokens += other.CachedPromptTokens
+	a.CompletionTokens += other.CompletionTokens
+	a.Tokens += other.Tokens
+	a.CostUSD += other.CostUSD
+	a.FailedCostUSD += other.FailedCostUSD
+}
+
+// UsageRow is the usage of one tenant on one day or for one model, depending
+// on the report's grouping.
+type UsageRow struct {
+	TenantID string `json:"tenant_id"`
+	Day      string `json:"day,omitempty"`
+	Model    string `json:"model,omitempty"`
+	UsageAmounts
+}
+
+type UsageReport struct {
+	TenantID string       `json:"tenant_id,omitempty"`
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	GroupBy  string       `json:"group_by"`
+	Rows     []UsageRow   `json:"rows"`
+	Totals   UsageAmounts `json:"totals"`
+}
+
+// SpendAlertRatio is the share of a monthly spend cap at which a tenant gets a
+// soft alert.
+const SpendAlertRatio = 0.8
+
+// SpendCap is a tenant's monthly USD budget. A temporary override replaces
+// MonthlyCapUSD until OverrideUntil; an override without OverrideCapUSD lifts
+// the cap for that time.
+type SpendCap struct {
+	TenantID       string     `json:"tenant_id"`
+	MonthlyCapUSD  *float64   `json:"monthly_cap_usd,omitempty"`
+	OverrideCapUSD *float64   `json:"override_cap_usd,omitempty"`
+	OverrideUntil  *time.Time `json:"override_until,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// EffectiveCap returns the cap in force at now, or nil when spend is not
+// capped.
+func (c SpendCap) EffectiveCap(now time.Time) *float64 {
+	if c.OverrideUntil != nil && now.Before(*c.OverrideUntil) {
+		return c.OverrideCapUSD
+	}
+	return c.MonthlyCapUSD
+}
+
+// SpendStatus is a tenant's spend in the current UTC month against its cap.
+type SpendStatus struct {
+	SpendCap
+	Month           string     `json:"month"`
+	SpentUSD        float64    `json:"spent_usd"`
+	EffectiveCapUSD *float64   `json:"effective_cap_usd,omitempty"`
+	SoftAlertedAt   *time.Time `json:"soft_alerted_at,omitempty"`
+	CapReachedAt    *time.Time `json:"cap_reached_at,omitempty"`
+	OverCap         bool       `json:"over_cap"`
+}
+
+// DefaultFallbackOn lists the error codes that move a job to its next
+// fallback model when its chain names none.
+var DefaultFallbackOn = []string{"PROVIDER_TIMEOUT"}
+
+// MaxFallbackModels bounds a fallback chain, not counting the requested model.
+const MaxFallbackModels = 5
+
+// FallbackPolicy is a tenant's fallback chain for jobs requesting Model that
+// do not list fallback models of their own.
+type FallbackPolicy struct {
+	TenantID       string     `json:"tenant_id"`
+	Model          string     `json:"model"`
+	FallbackModels []string   `json:"fallback_models"`
+	FallbackOn     []string   `json:"fallback_on,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// BreakerState is the state of a provider/model circuit breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// CircuitBreaker is the state of the circuit for one provider and model,
+// shared by every worker. Requests and Failures count the calls finished in
+// the window starting at WindowStart. RetryAt is when an open circuit lets a
+// probe through, or when a half-open circuit gives up on probes that never
+// reported back.
+type CircuitBreaker struct {
+	Provider       string       `json:"provider"`
+	Model          string       `json:"model"`
+	State          BreakerState `json:"state"`
+	Requests       int          `json:"requests"`
+	Failures       int          `json:"failures"`
+	WindowStart    time.Time    `json:"window_start"`
+	ProbesInFlight int          `json:"probes_in_flight"`
+	OpenedAt       *time.Time   `json:"opened_at,omitempty"`
+	RetryAt        *time.Time   `json:"retry_at,omitempty"`
+	ChangedAt      time.Time    `json:"changed_at"`
 }
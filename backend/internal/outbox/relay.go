@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/queue"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+const relayBatchSize = 500
+
+// Relay publishes queue outbox entries to the ready queue. A crash between
+// publishing and deleting the entries republishes them; workers drop job IDs
+// that are no longer queued, so duplicates are harmless.
+type Relay struct {
+	store    store.Store
+	queue    queue.Queue
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewRelay(store store.Store, queue queue.Queue, interval time.Duration, logger *slog.Logger) *Relay {
+	return &Relay{
+		store:    store,
+		queue:    queue,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// Drain the backlog before waiting for the next tick.
+		for {
+			relayed, err := r.store.RelayOutbox(ctx, relayBatchSize, r.publish)
+			if err != nil {
+				r.logger.Error("relay queue outbox failed", "error", err)
+				break
+			}
+			if relayed < relayBatchSize {
+				break
+			}
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, entries []models.OutboxEntry) error {
+	jobIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Dedupe {
+			if err := r.queue.RemoveQueuedJob(ctx, entry.JobID); err != nil {
+				return fmt.Errorf("remove stale ready entry for job %s: %w", entry.JobID, err)
+			}
+		}
+		jobIDs = append(jobIDs, entry.JobID)
+	}
+	if err := r.queue.EnqueueJobs(ctx, jobIDs); err != nil {
+		return fmt.Errorf("enqueue outbox jobs: %w", err)
+	}
+	return nil
+}
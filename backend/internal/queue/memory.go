@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryLease struct {
+	workerID  string
+	expiresAt time.Time
+}
+
+// MemoryQueue is an in-process Queue with the semantics of RedisQueue: a FIFO
+// list of job IDs and per-job leases that expire after leaseTTL. It is meant
+// for tests.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	ready    []string
+	leases   map[string]memoryLease
+	leaseTTL time.Duration
+	notify   chan struct{}
+}
+
+func NewMemoryQueue(leaseTTL time.Duration) *MemoryQueue {
+	return &MemoryQueue{
+		leases:   make(map[string]memoryLease),
+		leaseTTL: leaseTTL,
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+func (q *MemoryQueue) Close() error {
+	return nil
+}
+
+func (q *MemoryQueue) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (q *MemoryQueue) EnqueueJob(ctx context.Context, jobID string) error {
+	return q.EnqueueJobs(ctx, []string{jobID})
+}
+
+func (q *MemoryQueue) EnqueueJobs(ctx context.Context, jobIDs []string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	q.mu.Lock()
+	q.ready = append(q.ready, jobIDs...)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *MemoryQueue) RemoveQueuedJob(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kept := q.ready[:0]
+	for _, id := range q.ready {
+		if id != jobID {
+			kept = append(kept, id)
+		}
+	}
+	q.ready = kept
+	return nil
+}
+
+func (q *MemoryQueue) DequeueJob(ctx context.Context, timeout time.Duration) (string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		q.mu.Lock()
+		if len(q.ready) > 0 {
+			jobID := q.ready[0]
+			q.ready = q.ready[1:]
+			more := len(q.ready) > 0
+			q.mu.Unlock()
+			if more {
+				// Pass the wake-up on to other waiting consumers.
+				select {
+				case q.notify <- struct{}{}:
+				default:
+				}
+			}
+			return jobID, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timer.C:
+			return "", nil
+		case <-q.notify:
+		}
+	}
+}
+
+func (q *MemoryQueue) AcquireLease(ctx context.Context, jobID string, workerID string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := q.leases[jobID]; ok && lease.expiresAt.After(now) {
+		return false, nil
+	}
+	q.leases[jobID] = memoryLease{workerID: workerID, expiresAt: now.Add(q.leaseTTL)}
+	return true, nil
+}
+
+func (q *MemoryQueue) ReleaseLease(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.leases, jobID)
+	return nil
+}
+
+// Len returns the number of job IDs waiting in the queue.
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ready)
+}
+
+// LeaseHolder returns the worker holding an unexpired lease on jobID.
+func (q *MemoryQueue) LeaseHolder(jobID string) (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	lease, ok := q.leases[jobID]
+	if !ok || !lease.expiresAt.After(time.Now()) {
+		return "", false
+	}
+	return lease.workerID, true
+}
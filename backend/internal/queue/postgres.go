@@ -0,0 +1,192 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// notifyChannel is signalled whenever job IDs are enqueued so that idle
+// workers wake up instead of polling.
+const notifyChannel = "queue_jobs"
+
+// PostgresQueue is a queue backend for deployments without Redis. Entries are
+// claimed with FOR UPDATE SKIP LOCKED and stay in the table while a worker
+// handles them; an entry whose claim expires before the worker releases the
+// job is delivered again.
+type PostgresQueue struct {
+	pool     *pgxpool.Pool
+	leaseTTL time.Duration
+
+	// listenMu serialises dequeues on the dedicated LISTEN connection.
+	listenMu   sync.Mutex
+	listenConn *pgxpool.Conn
+}
+
+func NewPostgresQueue(ctx context.Context, databaseURL string, leaseTTL time.Duration) (*PostgresQueue, error) {
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("create postgres queue pool: %w", err)
+	}
+	return &PostgresQueue{
+		pool:     pool,
+		leaseTTL: leaseTTL,
+	}, nil
+}
+
+func (q *PostgresQueue) Close() error {
+	q.listenMu.Lock()
+	if q.listenConn != nil {
+		q.listenConn.Release()
+		q.listenConn = nil
+	}
+	q.listenMu.Unlock()
+	q.pool.Close()
+	return nil
+}
+
+func (q *PostgresQueue) Ping(ctx context.Context) error {
+	return q.pool.Ping(ctx)
+}
+
+func (q *PostgresQueue) EnqueueJob(ctx context.Context, jobID string) error {
+	return q.EnqueueJobs(ctx, []string{jobID})
+}
+
+// EnqueueJobs inserts the IDs in order and notifies listeners in the same
+// transaction, so a woken worker always finds the new entries.
+func (q *PostgresQueue) EnqueueJobs(ctx context.Context, jobIDs []string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+
+	tx, err := q.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO queue_jobs (job_id, enqueued_at)
+		 SELECT id, now()
+		 FROM unnest($1::text[]) WITH ORDINALITY AS entry(id, position)
+		 ORDER BY position`,
+		jobIDs,
+	); err != nil {
+		return fmt.Errorf("insert queue jobs: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, notifyChannel); err != nil {
+		return fmt.Errorf("notify queue listeners: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (q *PostgresQueue) RemoveQueuedJob(ctx context.Context, jobID string) error {
+	_, err := q.pool.Exec(ctx, `DELETE FROM queue_jobs WHERE job_id = $1 AND claimed_until IS NULL`, jobID)
+	return err
+}
+
+// DequeueJob claims the oldest available entry. When none is available it
+// waits for an enqueue notification (or the timeout) and tries once more.
+func (q *PostgresQueue) DequeueJob(ctx context.Context, timeout time.Duration) (string, error) {
+	jobID, err := q.claim(ctx)
+	if err != nil || jobID != "" {
+		return jobID, err
+	}
+
+	if err := q.waitForNotification(ctx, timeout); err != nil {
+		return "", err
+	}
+	return q.claim(ctx)
+}
+
+func (q *PostgresQueue) claim(ctx context.Context) (string, error) {
+	var jobID string
+	err := q.pool.QueryRow(
+		ctx,
+		`UPDATE queue_jobs
+		 SET claimed_until = now() + make_interval(secs => $1)
+		 WHERE id = (
+		     SELECT id FROM queue_jobs
+		     WHERE claimed_until IS NULL OR claimed_until <= now()
+		     ORDER BY id
+		     LIMIT 1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING job_id`,
+		q.leaseTTL.Seconds(),
+	).Scan(&jobID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("claim queue job: %w", err)
+	}
+	return jobID, nil
+}
+
+func (q *PostgresQueue) waitForNotification(ctx context.Context, timeout time.Duration) error {
+	q.listenMu.Lock()
+	defer q.listenMu.Unlock()
+
+	if q.listenConn == nil {
+		conn, err := q.pool.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("acquire listen connection: %w", err)
+		}
+		if _, err := conn.Exec(ctx, `LISTEN `+notifyChannel); err != nil {
+			conn.Release()
+			return fmt.Errorf("listen for queue notifications: %w", err)
+		}
+		q.listenConn = conn
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, err := q.listenConn.Conn().WaitForNotification(waitCtx)
+	if err == nil || waitCtx.Err() != nil {
+		// A timeout is the normal idle path.
+		return nil
+	}
+
+	// The connection is unusable after a failed wait; open a new one next time.
+	q.listenConn.Conn().Close(context.Background()) //nolint:errcheck
+	q.listenConn.Release()
+	q.listenConn = nil
+	return fmt.Errorf("wait for queue notification: %w", err)
+}
+
+// AcquireLease behaves like Redis SETNX with a TTL: it succeeds when nobody
+// holds the job's lease or the previous holder's lease has expired.
+func (q *PostgresQueue) AcquireLease(ctx context.Context, jobID string, workerID string) (bool, error) {
+	cmdTag, err := q.pool.Exec(
+		ctx,
+		`INSERT INTO queue_leases (job_id, worker_id, expires_at)
+		 VALUES ($1, $2, now() + make_interval(secs => $3))
+		 ON CONFLICT (job_id) DO UPDATE
+		 SET worker_id = excluded.worker_id, expires_at = excluded.expires_at
+		 WHERE queue_leases.expires_at <= now()`,
+		jobID,
+		workerID,
+		q.leaseTTL.Seconds(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("acquire queue lease: %w", err)
+	}
+	return cmdTag.RowsAffected() == 1, nil
+}
+
+// ReleaseLease drops the lease together with the claimed entries of the job,
+// which acknowledges their delivery.
+func (q *PostgresQueue) ReleaseLease(ctx context.Context, jobID string) error {
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM queue_leases WHERE job_id = $1`, jobID)
+	batch.Queue(`DELETE FROM queue_jobs WHERE job_id = $1 AND claimed_until IS NOT NULL`, jobID)
+	return q.pool.SendBatch(ctx, batch).Close()
+}
@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/config"
+)
+
+const (
+	BackendRedis        = "redis"
+	BackendRedisStreams = "redis-streams"
+	BackendPostgres     = "postgres"
+)
+
+// Queue carries job IDs from the outbox relay to workers. Postgres stays the
+// source of truth for job state; a queue entry only tells a worker which job
+// to look at, so duplicate deliveries are harmless.
+type Queue interface {
+	EnqueueJob(ctx context.Context, jobID string) error
+	EnqueueJobs(ctx context.Context, jobIDs []string) error
+	// RemoveQueuedJob drops entries for jobID that no worker has taken yet.
+	RemoveQueuedJob(ctx context.Context, jobID string) error
+	// DequeueJob waits up to timeout for a job ID and returns "" when none
+	// arrived.
+	DequeueJob(ctx context.Context, timeout time.Duration) (string, error)
+	// AcquireLease reports whether workerID now holds the job's lease.
+	AcquireLease(ctx context.Context, jobID string, workerID string) (bool, error)
+	ReleaseLease(ctx context.Context, jobID string) error
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// PendingReporter is implemented by backends that track delivered but not yet
+// acknowledged entries per consumer.
+type PendingReporter interface {
+	PendingByConsumer(ctx context.Context) (map[string]int64, error)
+}
+
+// New builds the queue backend selected by cfg.QueueBackend.
+func New(ctx context.Context, cfg config.Config) (Queue, error) {
+	switch cfg.QueueBackend {
+	case BackendRedis:
+		return NewRedisQueue(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, cfg.ReadyQueueKey, cfg.LeaseTTL), nil
+	case BackendRedisStreams:
+		return NewRedisStreamQueue(ctx, cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, cfg.StreamKey, cfg.StreamGroup, cfg.WorkerID, cfg.LeaseTTL)
+	case BackendPostgres:
+		return NewPostgresQueue(ctx, cfg.DatabaseURL, cfg.LeaseTTL)
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", cfg.QueueBackend)
+	}
+}
@@ -41,6 +41,20 @@ func (q *RedisQueue) EnqueueJob(ctx context.Context, jobID string) error {
 	return q.client.LPush(ctx, q.readyKey, jobID).Err()
 }
 
+// EnqueueJobs pushes many job IDs in a single round trip, preserving order.
+func (q *RedisQueue) EnqueueJobs(ctx context.Context, jobIDs []string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	_, err := q.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, jobID := range jobIDs {
+			pipe.LPush(ctx, q.readyKey, jobID)
+		}
+		return nil
+	})
+	return err
+}
+
 func (q *RedisQueue) RemoveQueuedJob(ctx context.Context, jobID string) error {
 	return q.client.LRem(ctx, q.readyKey, 0, jobID).Err()
 }
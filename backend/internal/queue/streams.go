@@ -0,0 +1,235 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	streamJobField     = "job_id"
+	streamScanPageSize = 1000
+)
+
+// RedisStreamQueue delivers job IDs through a Redis stream read by a consumer
+// group. An entry stays pending until the worker releases the job, so a worker
+// that crashes after reading keeps nothing lost: once the entry has been idle
+// for the lease TTL another consumer takes it over with XAUTOCLAIM.
+type RedisStreamQueue struct {
+	client   *redis.Client
+	stream   string
+	group    string
+	consumer string
+	leaseTTL time.Duration
+
+	// deliveries maps job IDs to the stream entries this consumer read for
+	// them, so releasing a job can acknowledge those entries.
+	mu         sync.Mutex
+	deliveries map[string][]string
+}
+
+func NewRedisStreamQueue(
+	ctx context.Context,
+	addr string,
+	password string,
+	db int,
+	stream string,
+	group string,
+	consumer string,
+	leaseTTL time.Duration,
+) (*RedisStreamQueue, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	// Start at 0 so entries added before the group existed are delivered too.
+	err := client.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		client.Close() //nolint:errcheck
+		return nil, fmt.Errorf("create consumer group: %w", err)
+	}
+
+	return &RedisStreamQueue{
+		client:     client,
+		stream:     stream,
+		group:      group,
+		consumer:   consumer,
+		leaseTTL:   leaseTTL,
+		deliveries: make(map[string][]string),
+	}, nil
+}
+
+func (q *RedisStreamQueue) Close() error {
+	return q.client.Close()
+}
+
+func (q *RedisStreamQueue) Ping(ctx context.Context) error {
+	return q.client.Ping(ctx).Err()
+}
+
+func (q *RedisStreamQueue) EnqueueJob(ctx context.Context, jobID string) error {
+	return q.EnqueueJobs(ctx, []string{jobID})
+}
+
+func (q *RedisStreamQueue) EnqueueJobs(ctx context.Context, jobIDs []string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	_, err := q.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, jobID := range jobIDs {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: q.stream,
+				Values: []any{streamJobField, jobID},
+			})
+		}
+		return nil
+	})
+	return err
+}
+
+// RemoveQueuedJob deletes the stream entries for jobID. Streams cannot be
+// searched by value, so this scans the stream like LREM scans the list.
+func (q *RedisStreamQueue) RemoveQueuedJob(ctx context.Context, jobID string) error {
+	start := "-"
+	for {
+		messages, err := q.client.XRangeN(ctx, q.stream, start, "+", streamScanPageSize).Result()
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0)
+		for _, message := range messages {
+			if message.Values[streamJobField] == jobID {
+				ids = append(ids, message.ID)
+			}
+		}
+		if len(ids) > 0 {
+			if err := q.client.XDel(ctx, q.stream, ids...).Err(); err != nil {
+				return err
+			}
+		}
+
+		if len(messages) < streamScanPageSize {
+			return nil
+		}
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// DequeueJob first takes over an entry that another consumer left idle for
+// longer than the lease TTL, and otherwise reads a new entry.
+func (q *RedisStreamQueue) DequeueJob(ctx context.Context, timeout time.Duration) (string, error) {
+	claimed, _, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   q.stream,
+		Group:    q.group,
+		MinIdle:  q.leaseTTL,
+		Start:    "0-0",
+		Count:    1,
+		Consumer: q.consumer,
+	}).Result()
+	if err != nil {
+		return "", fmt.Errorf("autoclaim idle entries: %w", err)
+	}
+	if len(claimed) > 0 {
+		return q.track(claimed[0])
+	}
+
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.group,
+		Consumer: q.consumer,
+		Streams:  []string{q.stream, ">"},
+		Count:    1,
+		Block:    timeout,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(streams) == 0 || len(streams[0].Messages) == 0 {
+		return "", nil
+	}
+	return q.track(streams[0].Messages[0])
+}
+
+func (q *RedisStreamQueue) track(message redis.XMessage) (string, error) {
+	jobID, ok := message.Values[streamJobField].(string)
+	if !ok || jobID == "" {
+		// Acknowledge malformed entries so they are not redelivered forever.
+		_ = q.ack(context.Background(), []string{message.ID})
+		return "", fmt.Errorf("stream entry %s has no %s", message.ID, streamJobField)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, id := range q.deliveries[jobID] {
+		if id == message.ID {
+			return jobID, nil
+		}
+	}
+	q.deliveries[jobID] = append(q.deliveries[jobID], message.ID)
+	return jobID, nil
+}
+
+// AcquireLease uses the same lease key as RedisQueue. When another worker
+// already holds the lease, the entries this consumer read for the job are
+// duplicates and are acknowledged right away.
+func (q *RedisStreamQueue) AcquireLease(ctx context.Context, jobID string, workerID string) (bool, error) {
+	acquired, err := q.client.SetNX(ctx, fmt.Sprintf("job:lease:%s", jobID), workerID, q.leaseTTL).Result()
+	if err != nil || acquired {
+		return acquired, err
+	}
+	return false, q.ack(ctx, q.takeDeliveries(jobID))
+}
+
+// ReleaseLease drops the lease and acknowledges the entries this consumer
+// read for the job.
+func (q *RedisStreamQueue) ReleaseLease(ctx context.Context, jobID string) error {
+	if err := q.client.Del(ctx, fmt.Sprintf("job:lease:%s", jobID)).Err(); err != nil {
+		return err
+	}
+	return q.ack(ctx, q.takeDeliveries(jobID))
+}
+
+func (q *RedisStreamQueue) takeDeliveries(jobID string) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ids := q.deliveries[jobID]
+	delete(q.deliveries, jobID)
+	return ids
+}
+
+// ack acknowledges and deletes entries; deleting keeps the stream from
+// growing without bound.
+func (q *RedisStreamQueue) ack(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, q.stream, q.group, ids...)
+		pipe.XDel(ctx, q.stream, ids...)
+		return nil
+	})
+	return err
+}
+
+// PendingByConsumer returns how many delivered but unacknowledged entries
+// each consumer of the group holds.
+func (q *RedisStreamQueue) PendingByConsumer(ctx context.Context) (map[string]int64, error) {
+	pending, err := q.client.XPending(ctx, q.stream, q.group).Result()
+	if err != nil {
+		return nil, fmt.Errorf("read pending summary: %w", err)
+	}
+	if pending.Consumers == nil {
+		return map[string]int64{}, nil
+	}
+	return pending.Consumers, nil
+}
@@ -0,0 +1,222 @@
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/config"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+// archiverLockKey is the Postgres advisory lock key that elects the single
+// process allowed to archive and delete rows.
+const archiverLockKey int64 = 0x6a6f626172636876 // "jobarchv"
+
+// partitionsAhead is how many future months of events partitions are kept
+// ready so inserts never hit a missing partition.
+const partitionsAhead = 2
+
+// Archiver enforces data retention. Finished jobs past their tenant's
+// retention are written with their attempts to gzipped JSONL files and then
+// deleted in batches; monthly events partitions are created ahead of time and
+// exported and dropped once they fall out of EVENTS_RETENTION_MONTHS.
+type Archiver struct {
+	store        *store.PostgresStore
+	interval     time.Duration
+	batchSize    int
+	defaultDays  int
+	eventsMonths int
+	archiveDir   string
+	logger       *slog.Logger
+}
+
+func NewArchiver(store *store.PostgresStore, cfg config.Config, logger *slog.Logger) *Archiver {
+	return &Archiver{
+		store:        store,
+		interval:     cfg.RetentionPoll,
+		batchSize:    cfg.RetentionBatch,
+		defaultDays:  cfg.RetentionDays,
+		eventsMonths: cfg.EventsKeepMonths,
+		archiveDir:   cfg.ArchiveDir,
+		logger:       logger,
+	}
+}
+
+// Run enforces retention every interval while this process is the archiver
+// leader. The first pass runs at startup so partitions exist before the first
+// interval has elapsed.
+func (a *Archiver) Run(ctx context.Context) error {
+	a.store.RunAsLeader(ctx, archiverLockKey, "archiver", a.interval, a.logger, a.runOnce)
+	return nil
+}
+
+func (a *Archiver) runOnce(ctx context.Context) {
+	if err := a.store.EnsureEventPartitions(ctx, time.Now(), partitionsAhead); err != nil {
+		a.logger.Error("creating events partitions failed", "error", err)
+	}
+
+	archived, deleted, err := a.archiveJobs(ctx)
+	if err != nil {
+		a.logger.Error("archiving expired jobs failed", "error", err)
+	}
+	if deleted > 0 {
+		a.logger.Info("deleted expired jobs", "count", deleted, "archived", archived)
+	}
+
+	if err := a.dropEventPartitions(ctx); err != nil {
+		a.logger.Error("dropping events partitions failed", "error", err)
+	}
+}
+
+// archiveJobs drains expired jobs batch by batch. A batch is deleted only after
+// its archive files are on disk, so a crash in between archives those jobs a
+// second time rather than losing them.
+func (a *Archiver) archiveJobs(ctx context.Context) (archived int, deleted int64, err error) {
+	for ctx.Err() == nil {
+		batch, err := a.store.ExpiredJobs(ctx, a.defaultDays, a.batchSize)
+		if err != nil {
+			return archived, deleted, err
+		}
+		if len(batch) == 0 {
+			return archived, deleted, nil
+		}
+
+		byTenant := map[string][]store.ExpiredJob{}
+		tenants := make([]string, 0)
+		jobIDs := make([]string, 0, len(batch))
+		for _, job := range batch {
+			jobIDs = append(jobIDs, job.ID)
+			if !job.Archive {
+				continue
+			}
+			if _, ok := byTenant[job.TenantID]; !ok {
+				tenants = append(tenants, job.TenantID)
+			}
+			byTenant[job.TenantID] = append(byTenant[job.TenantID], job)
+		}
+
+		for _, tenantID := range tenants {
+			jobs := byTenant[tenantID]
+			first := jobs[0]
+			name := fmt.Sprintf("%s-%s.jsonl.gz", first.FinishedAt.UTC().Format("20060102T150405Z"), safeName(first.ID))
+			dir := filepath.Join(a.archiveDir, "jobs", safeName(tenantID))
+			err := writeArchive(dir, name, func(enc *json.Encoder) error {
+				for _, job := range jobs {
+					if err := enc.Encode(job); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return archived, deleted, fmt.Errorf("archive jobs of tenant %s: %w", tenantID, err)
+			}
+			archived += len(jobs)
+		}
+
+		count, err := a.store.DeleteJobs(ctx, jobIDs)
+		if err != nil {
+			return archived, deleted, err
+		}
+		deleted += count
+
+		if len(batch) < a.batchSize {
+			return archived, deleted, nil
+		}
+	}
+	return archived, deleted, nil
+}
+
+// dropEventPartitions exports and drops events partitions that ended more
+// than eventsMonths months before the current month. Zero keeps events.
+func (a *Archiver) dropEventPartitions(ctx context.Context) error {
+	if a.eventsMonths <= 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -a.eventsMonths, 0)
+
+	partitions, err := a.store.EventPartitions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, partition := range partitions {
+		if partition.To.After(cutoff) {
+			break
+		}
+
+		err := writeArchive(filepath.Join(a.archiveDir, "events"), partition.Name+".jsonl.gz", func(enc *json.Encoder) error {
+			return a.store.ExportEventPartition(ctx, partition, func(record store.EventRecord) error {
+				return enc.Encode(record)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("archive events partition %s: %w", partition.Name, err)
+		}
+		if err := a.store.DropEventPartition(ctx, partition); err != nil {
+			return err
+		}
+		a.logger.Info("dropped events partition", "partition", partition.Name)
+	}
+	return nil
+}
+
+// writeArchive writes a gzipped JSONL file through a temporary file, so a
+// file with the final name is always complete.
+func writeArchive(dir string, name string, write func(enc *json.Encoder) error) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create archive dir: %w", err)
+	}
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create archive file: %w", err)
+	}
+	defer os.Remove(tmp) //nolint:errcheck
+
+	gz := gzip.NewWriter(file)
+	if err := write(json.NewEncoder(gz)); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("compress archive file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("sync archive file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close archive file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename archive file: %w", err)
+	}
+	return nil
+}
+
+// safeName makes a tenant or job ID usable as a single path element.
+func safeName(value string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+	if name == "" || strings.HasPrefix(name, ".") {
+		name = "_" + name
+	}
+	return name
+}
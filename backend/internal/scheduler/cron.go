@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/cronexpr"
+	"job-queue-llm-orchestrator/backend/internal/jobs"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+// cronLeaderLockKey is the Postgres advisory lock key that elects the single
+// process allowed to fire cron schedules.
+const cronLeaderLockKey int64 = 0x6a6f6263726f6e // "jobcron"
+
+const dueScheduleBatchSize = 100
+
+// CronRunner materializes jobs from recurring schedules. Only the process
+// holding the leader lock fires schedules; the idempotency key derived from
+// schedule ID and slot time makes a slot fire at most once across failovers.
+type CronRunner struct {
+	store    *store.PostgresStore
+	service  *jobs.Service
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewCronRunner(store *store.PostgresStore, service *jobs.Service, interval time.Duration, logger *slog.Logger) *CronRunner {
+	return &CronRunner{
+		store:    store,
+		service:  service,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run fires due schedules every interval while this process is the cron
+// leader, starting with a pass at startup.
+func (c *CronRunner) Run(ctx context.Context) error {
+	c.store.RunAsLeader(ctx, cronLeaderLockKey, "cron", c.interval, c.logger, func(ctx context.Context) {
+		if err := c.fireDue(ctx); err != nil {
+			c.logger.Error("firing due schedules failed", "error", err)
+		}
+	})
+	return nil
+}
+
+func (c *CronRunner) fireDue(ctx context.Context) error {
+	schedules, err := c.store.DueSchedules(ctx, dueScheduleBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		if err := c.fire(ctx, schedule); err != nil {
+			c.logger.Error("fire schedule failed", "schedule_id", schedule.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (c *CronRunner) fire(ctx context.Context, schedule models.Schedule) error {
+	if schedule.NextFireAt == nil {
+		return nil
+	}
+	slot := *schedule.NextFireAt
+
+	spec, err := cronexpr.Parse(schedule.CronExpr, schedule.Timezone)
+	if err != nil {
+		return err
+	}
+	// Slots missed while no leader was running collapse into the one firing
+	// now instead of being replayed back to back.
+	next := spec.Next(latest(slot, time.Now()))
+	if slot.IsZero() || next.IsZero() {
+		// Advancing to the zero time would make the schedule due on every
+		// tick, so park it until someone fixes the expression.
+		c.logger.Warn("pausing schedule whose cron expression never fires", "schedule_id", schedule.ID, "cron", schedule.CronExpr)
+		_, err := c.service.PauseSchedule(ctx, schedule.ID, "cron expression never fires")
+		return err
+	}
+
+	if schedule.OverlapPolicy != models.OverlapAllow && schedule.LastJobID != "" {
+		running, err := c.jobStillActive(ctx, schedule.LastJobID)
+		if err != nil {
+			return err
+		}
+		if running {
+			if schedule.OverlapPolicy == models.OverlapQueue {
+				// Keep the slot pending; it fires once the previous run ends.
+				return nil
+			}
+			_, err := c.store.AdvanceSchedule(ctx, schedule.ID, slot, next, "")
+			return err
+		}
+	}
+
+	job, _, err := c.service.CreateJob(ctx, models.CreateJobInput{
+		TenantID:       schedule.TenantID,
+		Priority:       schedule.Priority,
+		Model:          schedule.Model,
+		PayloadJSON:    schedule.PayloadJSON,
+		IdempotencyKey: fmt.Sprintf("schedule:%s:%d", schedule.ID, slot.Unix()),
+		MaxAttempts:    schedule.MaxAttempts,
+	})
+	if err != nil {
+		return fmt.Errorf("create scheduled job: %w", err)
+	}
+
+	if _, err := c.store.AdvanceSchedule(ctx, schedule.ID, slot, next, job.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *CronRunner) jobStillActive(ctx context.Context, jobID string) (bool, error) {
+	job, _, err := c.store.GetJobByID(ctx, jobID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !job.Status.IsTerminal(), nil
+}
+
+func latest(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
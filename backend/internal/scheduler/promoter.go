@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+const promoteBatchSize = 200
+
+// Promoter moves scheduled jobs to queued once their run_at has passed; the
+// outbox relay then publishes them. Several promoters may run at once; each
+// due job is claimed by one.
+type Promoter struct {
+	store    store.Store
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewPromoter(store store.Store, interval time.Duration, logger *slog.Logger) *Promoter {
+	return &Promoter{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (p *Promoter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// Drain everything that is due before waiting for the next tick.
+		for {
+			jobIDs, err := p.store.PromoteDueJobs(ctx, promoteBatchSize)
+			if err != nil {
+				p.logger.Error("promote due jobs failed", "error", err)
+				break
+			}
+			if len(jobIDs) > 0 {
+				p.logger.Debug("promoted scheduled jobs", "count", len(jobIDs))
+			}
+			if len(jobIDs) < promoteBatchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+const expireBatchSize = 200
+
+// Sweeper expires jobs that are still waiting when their deadline passes, so
+// they reach a terminal state even if no worker ever dequeues them.
+type Sweeper struct {
+	store    store.Store
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewSweeper(store store.Store, interval time.Duration, logger *slog.Logger) *Sweeper {
+	return &Sweeper{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (s *Sweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		for {
+			jobIDs, err := s.store.ExpireOverdueJobs(ctx, expireBatchSize)
+			if err != nil {
+				s.logger.Error("expire overdue jobs failed", "error", err)
+				break
+			}
+			if len(jobIDs) > 0 {
+				s.logger.Info("expired overdue jobs", "count", len(jobIDs))
+			}
+			if len(jobIDs) < expireBatchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const apiKeyColumns = `id, tenant_id, name, role, prefix, created_at, expires_at, revoked_at, COALESCE(replaced_by, '')`
+
+// apiKeyScanTargets returns the scan destinations matching apiKeyColumns.
+func apiKeyScanTargets(key *models.APIKey) []any {
+	return []any{
+		&key.ID,
+		&key.TenantID,
+		&key.Name,
+		&key.Role,
+		&key.Prefix,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.RevokedAt,
+		&key.ReplacedBy,
+	}
+}
+
+func (s *PostgresStore) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error) {
+	created := models.APIKey{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`INSERT INTO api_keys (id, tenant_id, name, role, prefix, key_hash, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, now(), $7)
+		 RETURNING `+apiKeyColumns,
+		uuid.NewString(),
+		key.TenantID,
+		key.Name,
+		key.Role,
+		key.Prefix,
+		keyHash,
+		key.ExpiresAt,
+	).Scan(apiKeyScanTargets(&created)...)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("insert api key: %w", err)
+	}
+	return created, nil
+}
+
+func (s *PostgresStore) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, error) {
+	key := models.APIKey{}
+	err := s.db(ctx).QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, keyID).
+		Scan(apiKeyScanTargets(&key)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("get api key: %w", err)
+	}
+	return key, nil
+}
+
+func (s *PostgresStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	key := models.APIKey{}
+	err := s.db(ctx).QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash).
+		Scan(apiKeyScanTargets(&key)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("get api key by hash: %w", err)
+	}
+	return key, nil
+}
+
+func (s *PostgresStore) ListAPIKeys(ctx context.Context, tenantID string) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys`
+	args := make([]any, 0, 1)
+	if tenantID != "" {
+		query += ` WHERE tenant_id = $1`
+		args = append(args, tenantID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+
+	rows, err := s.db(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys query: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(apiKeyScanTargets(&key)...); err != nil {
+			return nil, fmt.Errorf("list api keys scan: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list api keys rows: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops a key from authenticating. Revoking a revoked key
+// returns it unchanged.
+func (s *PostgresStore) RevokeAPIKey(ctx context.Context, keyID string) (models.APIKey, error) {
+	key := models.APIKey{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now())
+		 WHERE id = $1
+		 RETURNING `+apiKeyColumns,
+		keyID,
+	).Scan(apiKeyScanTargets(&key)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("revoke api key: %w", err)
+	}
+	return key, nil
+}
+
+// RotateAPIKey issues a replacement for an active key with the same tenant,
+// name and role, and lets the old key expire at oldExpiresAt (or earlier, if it was
+// already due to expire). Rotating an inactive key fails with
+// ErrInvalidStateTransition.
+func (s *PostgresStore) RotateAPIKey(ctx context.Context, keyID string, prefix string, keyHash string, oldExpiresAt time.Time) (models.APIKey, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	old := models.APIKey{}
+	err = tx.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 FOR UPDATE`, keyID).
+		Scan(apiKeyScanTargets(&old)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("lock api key: %w", err)
+	}
+	if !old.Active(time.Now()) || old.ReplacedBy != "" {
+		return models.APIKey{}, ErrInvalidStateTransition
+	}
+
+	replacement := models.APIKey{}
+	err = tx.QueryRow(
+		ctx,
+		`INSERT INTO api_keys (id, tenant_id, name, role, prefix, key_hash, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, now(), $7)
+		 RETURNING `+apiKeyColumns,
+		uuid.NewString(),
+		old.TenantID,
+		old.Name,
+		old.Role,
+		prefix,
+		keyHash,
+		old.ExpiresAt,
+	).Scan(apiKeyScanTargets(&replacement)...)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("insert replacement api key: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE api_keys
+		 SET replaced_by = $2,
+		     expires_at = LEAST(COALESCE(expires_at, $3), $3)
+		 WHERE id = $1`,
+		keyID,
+		replacement.ID,
+		oldExpiresAt,
+	); err != nil {
+		return models.APIKey{}, fmt.Errorf("expire rotated api key: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.APIKey{}, fmt.Errorf("commit rotate api key: %w", err)
+	}
+	return replacement, nil
+}
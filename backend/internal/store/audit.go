@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+const auditColumns = `id, created_at, action, actor_type, actor_id, actor_tenant_id, actor_role, source_ip, user_agent,
+	target_type, target_id, tenant_id, reason, details`
+
+// auditScanTargets returns the scan destinations matching auditColumns.
+func auditScanTargets(entry *models.AuditEntry) []any {
+	return []any{
+		&entry.ID,
+		&entry.CreatedAt,
+		&entry.Action,
+		&entry.Actor.Type,
+		&entry.Actor.ID,
+		&entry.Actor.TenantID,
+		&entry.Actor.Role,
+		&entry.Actor.SourceIP,
+		&entry.Actor.UserAgent,
+		&entry.TargetType,
+		&entry.TargetID,
+		&entry.TenantID,
+		&entry.Reason,
+		&entry.Details,
+	}
+}
+
+// AppendAudit stores an audit entry. The table rejects updates and deletes.
+func (s *PostgresStore) AppendAudit(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	details := entry.Details
+	if len(details) == 0 {
+		details = []byte(`{}`)
+	}
+	saved := models.AuditEntry{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`INSERT INTO audit_log (action, actor_type, actor_id, actor_tenant_id, actor_role, source_ip, user_agent,
+		                        target_type, target_id, tenant_id, reason, details)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 RETURNING `+auditColumns,
+		entry.Action,
+		entry.Actor.Type,
+		entry.Actor.ID,
+		entry.Actor.TenantID,
+		entry.Actor.Role,
+		entry.Actor.SourceIP,
+		entry.Actor.UserAgent,
+		entry.TargetType,
+		entry.TargetID,
+		entry.TenantID,
+		entry.Reason,
+		details,
+	).Scan(auditScanTargets(&saved)...)
+	if err != nil {
+		return models.AuditEntry{}, fmt.Errorf("insert audit entry: %w", err)
+	}
+	return saved, nil
+}
+
+func (s *PostgresStore) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE true`
+	args := make([]any, 0, 8)
+	add := func(condition string, value any) {
+		args = append(args, value)
+		query += ` AND ` + condition + ` $` + strconv.Itoa(len(args))
+	}
+	if filter.TenantID != "" {
+		add("tenant_id =", filter.TenantID)
+	}
+	if filter.ActorID != "" {
+		add("actor_id =", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action =", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type =", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id =", filter.TargetID)
+	}
+	if filter.Since != nil {
+		add("created_at >=", *filter.Since)
+	}
+	if filter.Until != nil {
+		add("created_at <", *filter.Until)
+	}
+	if filter.BeforeID > 0 {
+		add("id <", filter.BeforeID)
+	}
+	args = append(args, filter.Limit)
+	query += ` ORDER BY id DESC LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := s.db(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list audit query: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := rows.Scan(auditScanTargets(&entry)...); err != nil {
+			return nil, fmt.Errorf("list audit scan: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list audit rows: %w", err)
+	}
+	return entries, nil
+}
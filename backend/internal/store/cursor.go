@@ -0,0 +1,46 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// jobCursor is the keyset position of the last row on a page. It is encoded
+// as opaque base64 JSON so clients cannot depend on its layout.
+type jobCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+	Priority  int       `json:"p,omitempty"`
+}
+
+func encodeJobCursor(sort string, job models.Job) string {
+	raw, _ := json.Marshal(jobCursor{
+		Sort:      sort,
+		CreatedAt: job.CreatedAt,
+		ID:        job.ID,
+		Priority:  job.Priority,
+	})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeJobCursor(sort string, encoded string) (jobCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return jobCursor{}, ErrInvalidCursor
+	}
+	var cursor jobCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return jobCursor{}, ErrInvalidCursor
+	}
+	if cursor.Sort != sort || cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return jobCursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
@@ -0,0 +1,373 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrDependencyNotFound is returned when depends_on references a job that
+// does not exist or belongs to another tenant.
+var ErrDependencyNotFound = errors.New("dependency not found")
+
+const dependencyFailedCode = "DEPENDENCY_FAILED"
+
+// unsatisfiedDependencySQL is true for a jobs row that still has a parent
+// which has not succeeded.
+const unsatisfiedDependencySQL = `EXISTS (
+	SELECT 1 FROM job_dependencies d
+	JOIN jobs p ON p.id = d.depends_on_job_id
+	WHERE d.job_id = jobs.id AND p.status <> 'succeeded'
+)`
+
+type dependencyResolution struct {
+	status         models.JobStatus
+	failedParentID string
+}
+
+// resolveDependenciesTx validates the parents of a new job and decides its
+// initial status. Parents are locked FOR SHARE so that none of them can finish
+// between this check and the commit that records the dependency rows.
+func resolveDependenciesTx(ctx context.Context, tx pgx.Tx, tenantID string, parentIDs []string) (dependencyResolution, error) {
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id, tenant_id, status FROM jobs WHERE id = ANY($1) ORDER BY id FOR SHARE`,
+		parentIDs,
+	)
+	if err != nil {
+		return dependencyResolution{}, fmt.Errorf("lock parent jobs: %w", err)
+	}
+	defer rows.Close()
+
+	found := 0
+	allSucceeded := true
+	resolution := dependencyResolution{}
+	for rows.Next() {
+		var id, parentTenant string
+		var status models.JobStatus
+		if err := rows.Scan(&id, &parentTenant, &status); err != nil {
+			return dependencyResolution{}, fmt.Errorf("lock parent jobs scan: %w", err)
+		}
+		if parentTenant != tenantID {
+			return dependencyResolution{}, ErrDependencyNotFound
+		}
+		found++
+		if status != models.JobStatusSucceeded {
+			allSucceeded = false
+		}
+		if isPermanentFailure(status) && resolution.failedParentID == "" {
+			resolution.failedParentID = id
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return dependencyResolution{}, fmt.Errorf("lock parent jobs rows: %w", err)
+	}
+	if found != len(parentIDs) {
+		return dependencyResolution{}, ErrDependencyNotFound
+	}
+
+	switch {
+	case resolution.failedParentID != "":
+		resolution.status = models.JobStatusCancelled
+	case allSucceeded:
+		resolution.status = models.JobStatusQueued
+	default:
+		resolution.status = models.JobStatusBlocked
+	}
+	return resolution, nil
+}
+
+func insertDependenciesTx(ctx context.Context, tx pgx.Tx, jobID string, parentIDs []string) error {
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO job_dependencies (job_id, depends_on_job_id, position)
+		 SELECT $1, parent.id, parent.position - 1
+		 FROM unnest($2::text[]) WITH ORDINALITY AS parent(id, position)`,
+		jobID,
+		parentIDs,
+	); err != nil {
+		return fmt.Errorf("insert job dependencies: %w", err)
+	}
+	return nil
+}
+
+// releaseDependantsTx moves blocked children of parentID to queued (or
+// scheduled, when run_at is still ahead) once every one of their parents has
+// succeeded. Queued dependants are recorded in the queue outbox.
+func releaseDependantsTx(ctx context.Context, tx pgx.Tx, parentID string) error {
+	// Lock the blocked children first. When two parents of the same child
+	// succeed concurrently the second waits here, and its UPDATE below then
+	// sees the first parent's committed status.
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id FROM jobs
+		 WHERE status = 'blocked'
+		   AND id IN (SELECT job_id FROM job_dependencies WHERE depends_on_job_id = $1)
+		 ORDER BY id
+		 FOR UPDATE`,
+		parentID,
+	)
+	if err != nil {
+		return fmt.Errorf("lock blocked dependants: %w", err)
+	}
+	candidates, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("lock blocked dependants scan: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	rows, err = tx.Query(
+		ctx,
+		`UPDATE jobs
+		 SET status = CASE WHEN run_at > now() THEN 'scheduled' ELSE 'queued' END
+		 WHERE id = ANY($1) AND status = 'blocked' AND NOT `+unsatisfiedDependencySQL+`
+		 RETURNING id, status`,
+		candidates,
+	)
+	if err != nil {
+		return fmt.Errorf("release dependants: %w", err)
+	}
+	unblocked := make([]string, 0, len(candidates))
+	released := make([]string, 0, len(candidates))
+	for rows.Next() {
+		var id string
+		var status models.JobStatus
+		if err := rows.Scan(&id, &status); err != nil {
+			rows.Close()
+			return fmt.Errorf("release dependants scan: %w", err)
+		}
+		unblocked = append(unblocked, id)
+		// Dependants with a future run_at are left for the scheduler.
+		if status == models.JobStatusQueued {
+			released = append(released, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("release dependants rows: %w", err)
+	}
+
+	if len(unblocked) > 0 {
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
+			 SELECT 'job.unblocked', id, NULL, $2, now()
+			 FROM unnest($1::text[]) AS id`,
+			unblocked,
+			"All parent jobs succeeded; last parent "+parentID,
+		); err != nil {
+			return fmt.Errorf("insert unblocked events: %w", err)
+		}
+	}
+	return enqueueOutboxTx(ctx, tx, released, false)
+}
+
+// cancelDependantsTx cancels every blocked descendant of parentID after the
+// parent failed permanently. Groups of the cancelled jobs are re-checked for
+// completion.
+func cancelDependantsTx(ctx context.Context, tx pgx.Tx, parentID string) error {
+	reason := "Parent job " + parentID + " failed permanently"
+	rows, err := tx.Query(
+		ctx,
+		`WITH RECURSIVE descendants AS (
+		     SELECT job_id FROM job_dependencies WHERE depends_on_job_id = $1
+		     UNION
+		     SELECT d.job_id FROM job_dependencies d JOIN descendants x ON d.depends_on_job_id = x.job_id
+		 )
+		 UPDATE jobs
+		 SET status = 'cancelled', finished_at = now(), error_code = $2, error_message = $3
+		 WHERE id IN (SELECT job_id FROM descendants) AND status = 'blocked'
+		 RETURNING id, COALESCE(group_id, '')`,
+		parentID,
+		dependencyFailedCode,
+		reason,
+	)
+	if err != nil {
+		return fmt.Errorf("cancel dependants: %w", err)
+	}
+
+	cancelled := make([]string, 0)
+	groupSet := make(map[string]struct{})
+	for rows.Next() {
+		var id, groupID string
+		if err := rows.Scan(&id, &groupID); err != nil {
+			rows.Close()
+			return fmt.Errorf("cancel dependants scan: %w", err)
+		}
+		cancelled = append(cancelled, id)
+		if groupID != "" {
+			groupSet[groupID] = struct{}{}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("cancel dependants rows: %w", err)
+	}
+	if len(cancelled) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
+		 SELECT 'job.failed', id, NULL, $2, now()
+		 FROM unnest($1::text[]) AS id`,
+		cancelled,
+		reason,
+	); err != nil {
+		return fmt.Errorf("insert dependant cancel events: %w", err)
+	}
+
+	// Lock groups in a stable order to avoid deadlocks between transactions
+	// that cascade into overlapping groups.
+	groupIDs := make([]string, 0, len(groupSet))
+	for groupID := range groupSet {
+		groupIDs = append(groupIDs, groupID)
+	}
+	sort.Strings(groupIDs)
+	for _, groupID := range groupIDs {
+		if err := lockGroupTx(ctx, tx, groupID); err != nil {
+			return err
+		}
+		if err := completeGroupIfDoneTx(ctx, tx, groupID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reblockDependantsTx undoes cancelDependantsTx for the descendants of
+// parentIDs once those parents are retried: jobs cancelled with
+// DEPENDENCY_FAILED go back to blocked and wait for their parents again. It
+// walks one generation at a time so a job is only reblocked when none of its
+// parents is still permanently failed, and returns the reblocked IDs.
+func reblockDependantsTx(ctx context.Context, tx pgx.Tx, parentIDs []string) ([]string, error) {
+	reblocked := make([]string, 0)
+	groupSet := make(map[string]struct{})
+	for frontier := parentIDs; len(frontier) > 0; {
+		rows, err := tx.Query(
+			ctx,
+			`UPDATE jobs
+			 SET status = 'blocked', finished_at = NULL, error_code = NULL, error_message = NULL
+			 WHERE id IN (SELECT job_id FROM job_dependencies WHERE depends_on_job_id = ANY($1))
+			   AND status = 'cancelled' AND error_code = $2
+			   AND NOT EXISTS (
+			       SELECT 1 FROM job_dependencies d
+			       JOIN jobs p ON p.id = d.depends_on_job_id
+			       WHERE d.job_id = jobs.id AND p.status IN ('failed', 'dlq', 'cancelled')
+			   )
+			 RETURNING id, COALESCE(group_id, '')`,
+			frontier,
+			dependencyFailedCode,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("reblock dependants: %w", err)
+		}
+		next := make([]string, 0)
+		for rows.Next() {
+			var id, groupID string
+			if err := rows.Scan(&id, &groupID); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("reblock dependants scan: %w", err)
+			}
+			next = append(next, id)
+			if groupID != "" {
+				groupSet[groupID] = struct{}{}
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("reblock dependants rows: %w", err)
+		}
+		reblocked = append(reblocked, next...)
+		frontier = next
+	}
+	if len(reblocked) == 0 {
+		return reblocked, nil
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
+		 SELECT 'job.blocked', id, NULL, 'A failed parent job was retried; waiting for parent jobs again', now()
+		 FROM unnest($1::text[]) AS id`,
+		reblocked,
+	); err != nil {
+		return nil, fmt.Errorf("insert dependant reblock events: %w", err)
+	}
+
+	groupIDs := make([]string, 0, len(groupSet))
+	for groupID := range groupSet {
+		groupIDs = append(groupIDs, groupID)
+	}
+	sort.Strings(groupIDs)
+	for _, groupID := range groupIDs {
+		if err := reopenGroupTx(ctx, tx, groupID); err != nil {
+			return nil, err
+		}
+	}
+	return reblocked, nil
+}
+
+// GetDependencyOutputs returns the results of a job's parents in the order
+// they were listed in depends_on.
+func (s *PostgresStore) GetDependencyOutputs(ctx context.Context, jobID string) ([]models.DependencyOutput, error) {
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT p.id, p.model, p.result_json
+		 FROM job_dependencies d
+		 JOIN jobs p ON p.id = d.depends_on_job_id
+		 WHERE d.job_id = $1
+		 ORDER BY d.position`,
+		jobID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("dependency outputs query: %w", err)
+	}
+	defer rows.Close()
+
+	outputs := make([]models.DependencyOutput, 0)
+	for rows.Next() {
+		var output models.DependencyOutput
+		if err := rows.Scan(&output.JobID, &output.Model, &output.Result); err != nil {
+			return nil, fmt.Errorf("dependency outputs scan: %w", err)
+		}
+		outputs = append(outputs, output)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dependency outputs rows: %w", err)
+	}
+	return outputs, nil
+}
+
+func (s *PostgresStore) GetDependencyIDs(ctx context.Context, jobID string) ([]string, error) {
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT depends_on_job_id FROM job_dependencies WHERE job_id = $1 ORDER BY position`,
+		jobID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("dependency ids query: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("dependency ids scan: %w", err)
+	}
+	return ids, nil
+}
+
+func isPermanentFailure(status models.JobStatus) bool {
+	switch status {
+	case models.JobStatusFailed, models.JobStatusDLQ, models.JobStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ExpiredCode is recorded on jobs whose deadline passed before they started.
+const ExpiredCode = "EXPIRED"
+
+const expiredMessageSQL = `'Deadline ' || to_char(deadline AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') || ' passed before the job started'`
+
+// ExpireJob fails a queued job whose deadline has passed. Workers call it when
+// MarkJobRunning refuses a job, so no tokens are spent on it. It reports
+// whether the job was expired.
+func (s *PostgresStore) ExpireJob(ctx context.Context, jobID string, workerID string) (bool, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	rows, err := tx.Query(
+		ctx,
+		`UPDATE jobs
+		 SET status = 'failed', finished_at = now(), error_code = $2, error_message = `+expiredMessageSQL+`
+		 WHERE id = $1 AND status = 'queued' AND deadline <= now()
+		 RETURNING id`,
+		jobID,
+		ExpiredCode,
+	)
+	if err != nil {
+		return false, fmt.Errorf("expire job: %w", err)
+	}
+	expired, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return false, fmt.Errorf("expire job scan: %w", err)
+	}
+	if len(expired) == 0 {
+		return false, nil
+	}
+
+	if err := finishExpiredJobsTx(ctx, tx, expired, &workerID); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit expire job: %w", err)
+	}
+	return true, nil
+}
+
+// ExpireOverdueJobs fails up to limit jobs that have not started and whose
+// deadline has passed, and returns their IDs. Queued jobs that are still in the
+// ready queue are dropped by workers once MarkJobRunning refuses them.
+func (s *PostgresStore) ExpireOverdueJobs(ctx context.Context, limit int) ([]string, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	rows, err := tx.Query(
+		ctx,
+		`UPDATE jobs
+		 SET status = 'failed', finished_at = now(), error_code = $2, error_message = `+expiredMessageSQL+`
+		 WHERE id IN (
+		     SELECT id FROM jobs
+		     WHERE status IN ('queued', 'scheduled', 'blocked', 'retry_scheduled') AND deadline <= now()
+		     ORDER BY deadline
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id`,
+		limit,
+		ExpiredCode,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("expire overdue jobs: %w", err)
+	}
+	expired, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("expire overdue jobs scan: %w", err)
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	if err := finishExpiredJobsTx(ctx, tx, expired, nil); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit expire overdue jobs: %w", err)
+	}
+	return expired, nil
+}
+
+// finishExpiredJobsTx records job.expired events and treats each expired job
+// like any other permanent failure: dependants are cancelled and groups are
+// checked for completion.
+func finishExpiredJobsTx(ctx context.Context, tx pgx.Tx, jobIDs []string, workerID *string) error {
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
+		 SELECT 'job.expired', id, $2, 'Deadline passed before the job started', now()
+		 FROM unnest($1::text[]) AS id`,
+		jobIDs,
+		workerID,
+	); err != nil {
+		return fmt.Errorf("insert expired events: %w", err)
+	}
+
+	for _, jobID := range jobIDs {
+		if err := cancelDependantsTx(ctx, tx, jobID); err != nil {
+			return err
+		}
+		if err := markGroupCompletedTx(ctx, tx, jobID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const fallbackPolicyColumns = `tenant_id, model, fallback_models, fallback_on, updated_at`
+
+// fallbackPolicyScanTargets returns the scan destinations matching
+// fallbackPolicyColumns.
+func fallbackPolicyScanTargets(policy *models.FallbackPolicy) []any {
+	return []any{
+		&policy.TenantID,
+		&policy.Model,
+		&policy.FallbackModels,
+		&policy.FallbackOn,
+		&policy.UpdatedAt,
+	}
+}
+
+func (s *PostgresStore) UpsertFallbackPolicy(ctx context.Context, policy models.FallbackPolicy) (models.FallbackPolicy, error) {
+	saved := models.FallbackPolicy{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`INSERT INTO model_fallback_policies (tenant_id, model, fallback_models, fallback_on, updated_at)
+		 VALUES ($1, $2, $3, COALESCE($4::text[], '{}'), now())
+		 ON CONFLICT (tenant_id, model) DO UPDATE
+		 SET fallback_models = EXCLUDED.fallback_models,
+		     fallback_on = EXCLUDED.fallback_on,
+		     updated_at = now()
+		 RETURNING `+fallbackPolicyColumns,
+		policy.TenantID,
+		policy.Model,
+		policy.FallbackModels,
+		policy.FallbackOn,
+	).Scan(fallbackPolicyScanTargets(&saved)...)
+	if err != nil {
+		return models.FallbackPolicy{}, fmt.Errorf("upsert fallback policy: %w", err)
+	}
+	return saved, nil
+}
+
+func (s *PostgresStore) GetFallbackPolicy(ctx context.Context, tenantID string, model string) (models.FallbackPolicy, error) {
+	policy := models.FallbackPolicy{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`SELECT `+fallbackPolicyColumns+` FROM model_fallback_policies WHERE tenant_id = $1 AND model = $2`,
+		tenantID,
+		model,
+	).Scan(fallbackPolicyScanTargets(&policy)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.FallbackPolicy{}, ErrNotFound
+	}
+	if err != nil {
+		return models.FallbackPolicy{}, fmt.Errorf("get fallback policy: %w", err)
+	}
+	return policy, nil
+}
+
+// ListFallbackPolicies returns the policies of tenantID, or of every tenant
+// when it is empty.
+func (s *PostgresStore) ListFallbackPolicies(ctx context.Context, tenantID string) ([]models.FallbackPolicy, error) {
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT `+fallbackPolicyColumns+` FROM model_fallback_policies
+		 WHERE $1 = '' OR tenant_id = $1
+		 ORDER BY tenant_id, model`,
+		tenantID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list fallback policies query: %w", err)
+	}
+	defer rows.Close()
+
+	policies := make([]models.FallbackPolicy, 0)
+	for rows.Next() {
+		var policy models.FallbackPolicy
+		if err := rows.Scan(fallbackPolicyScanTargets(&policy)...); err != nil {
+			return nil, fmt.Errorf("list fallback policies scan: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list fallback policies rows: %w", err)
+	}
+	return policies, nil
+}
+
+func (s *PostgresStore) DeleteFallbackPolicy(ctx context.Context, tenantID string, model string) error {
+	cmdTag, err := s.db(ctx).Exec(ctx, `DELETE FROM model_fallback_policies WHERE tenant_id = $1 AND model = $2`, tenantID, model)
+	if err != nil {
+		return fmt.Errorf("delete fallback policy: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FallBackJob fails the running attempt of a job with errorCode and starts the
+// next attempt on model without the job leaving running. It returns the job
+// as of the new attempt.
+func (s *PostgresStore) FallBackJob(ctx context.Context, jobID string, workerID string, errorCode string, errorMessage string, model string) (models.Job, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return models.Job{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	var previous string
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(model_used, model) FROM jobs WHERE id = $1 AND status = 'running' FOR UPDATE`, jobID).
+		Scan(&previous); errors.Is(err, pgx.ErrNoRows) {
+		return models.Job{}, ErrNotFound
+	} else if err != nil {
+		return models.Job{}, fmt.Errorf("lock job for fallback: %w", err)
+	}
+
+	job := models.Job{}
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE jobs
+		 SET attempt = attempt + 1, started_at = now(), model_used = $2
+		 WHERE id = $1
+		 RETURNING `+jobColumns,
+		jobID,
+		model,
+	).Scan(jobScanTargets(&job)...)
+	if err != nil {
+		return models.Job{}, fmt.Errorf("update job fallback: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE job_attempts
+		 SET finished_at = now(), success = false, error_code = $3, error_message = $4
+		 WHERE job_id = $1 AND attempt = $2`,
+		jobID,
+		job.Attempt-1,
+		errorCode,
+		errorMessage,
+	); err != nil {
+		return models.Job{}, fmt.Errorf("finish job attempt before fallback: %w", err)
+	}
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO job_attempts (job_id, attempt, model, started_at, worker_id) VALUES ($1, $2, $3, now(), $4)
+		 ON CONFLICT (job_id, attempt) DO UPDATE SET model = excluded.model, started_at = excluded.started_at, worker_id = excluded.worker_id`,
+		jobID,
+		job.Attempt,
+		model,
+		workerID,
+	); err != nil {
+		return models.Job{}, fmt.Errorf("insert fallback attempt: %w", err)
+	}
+
+	details := fmt.Sprintf("%s on %s; falling back to %s", errorCode, previous, model)
+	if err := appendEventTx(ctx, tx, "job.fallback", &jobID, &workerID, details); err != nil {
+		return models.Job{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Job{}, fmt.Errorf("commit job fallback: %w", err)
+	}
+	return job, nil
+}
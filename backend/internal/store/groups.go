@@ -0,0 +1,401 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const groupColumns = `id, tenant_id, name, COALESCE(webhook_url, ''), created_at, completed_at, webhook_delivered_at`
+
+// groupScanTargets returns the scan destinations matching groupColumns.
+func groupScanTargets(group *models.JobGroup) []any {
+	return []any{
+		&group.ID,
+		&group.TenantID,
+		&group.Name,
+		&group.WebhookURL,
+		&group.CreatedAt,
+		&group.CompletedAt,
+		&group.WebhookDeliveredAt,
+	}
+}
+
+func (s *PostgresStore) CreateGroup(ctx context.Context, input models.CreateJobGroupInput) (models.JobGroup, error) {
+	group := models.JobGroup{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`INSERT INTO job_groups (id, tenant_id, name, webhook_url, created_at)
+		 VALUES ($1, $2, $3, NULLIF($4, ''), now())
+		 RETURNING `+groupColumns,
+		uuid.NewString(),
+		input.TenantID,
+		input.Name,
+		input.WebhookURL,
+	).Scan(groupScanTargets(&group)...)
+	if err != nil {
+		return models.JobGroup{}, fmt.Errorf("insert job group: %w", err)
+	}
+
+	if err := s.appendEvent(ctx, "group.created", nil, nil, "Job group "+group.ID+" created"); err != nil {
+		return models.JobGroup{}, err
+	}
+	return group, nil
+}
+
+func (s *PostgresStore) GetGroup(ctx context.Context, groupID string) (models.JobGroup, error) {
+	group := models.JobGroup{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`SELECT `+groupColumns+` FROM job_groups WHERE id = $1`,
+		groupID,
+	).Scan(groupScanTargets(&group)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.JobGroup{}, ErrNotFound
+	}
+	if err != nil {
+		return models.JobGroup{}, fmt.Errorf("get job group: %w", err)
+	}
+	return group, nil
+}
+
+func (s *PostgresStore) GetGroupSummary(ctx context.Context, groupID string) (models.JobGroupSummary, error) {
+	group, err := s.GetGroup(ctx, groupID)
+	if err != nil {
+		return models.JobGroupSummary{}, err
+	}
+
+	summary := models.JobGroupSummary{
+		Group:  group,
+		Counts: make(map[models.JobStatus]int),
+	}
+
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT status, count(*) FROM jobs WHERE group_id = $1 GROUP BY status`,
+		groupID,
+	)
+	if err != nil {
+		return models.JobGroupSummary{}, fmt.Errorf("group status counts query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status models.JobStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return models.JobGroupSummary{}, fmt.Errorf("group status counts scan: %w", err)
+		}
+		summary.Counts[status] = count
+		summary.TotalJobs += count
+		if status.IsTerminal() {
+			summary.TerminalJobs += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return models.JobGroupSummary{}, fmt.Errorf("group status counts rows: %w", err)
+	}
+
+	if err := s.db(ctx).QueryRow(
+		ctx,
+		`SELECT COALESCE(sum(a.tokens), 0), COALESCE(sum(a.cost_usd), 0)
+		 FROM job_attempts a
+		 JOIN jobs j ON j.id = a.job_id
+		 WHERE j.group_id = $1`,
+		groupID,
+	).Scan(&summary.TotalTokens, &summary.TotalCostUSD); err != nil {
+		return models.JobGroupSummary{}, fmt.Errorf("group usage totals: %w", err)
+	}
+
+	if summary.TotalJobs > 0 {
+		summary.PercentComplete = float64(summary.TerminalJobs) * 100 / float64(summary.TotalJobs)
+	}
+	return summary, nil
+}
+
+// CancelGroup cancels every job in the group that has not finished yet and
+// returns the IDs that were cancelled so queue entries can be cleaned up.
+func (s *PostgresStore) CancelGroup(ctx context.Context, groupID string, reason string) ([]string, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if err := lockGroupTx(ctx, tx, groupID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`UPDATE jobs
+		 SET status = 'cancelled',
+		     finished_at = now(),
+		     error_code = 'CANCELLED',
+		     error_message = $2
+		 WHERE group_id = $1 AND status IN ('queued', 'running', 'retry_scheduled', 'blocked', 'scheduled')
+		 RETURNING id`,
+		groupID,
+		reason,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cancel group jobs: %w", err)
+	}
+	jobIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("cancel group jobs scan: %w", err)
+	}
+
+	if len(jobIDs) > 0 {
+		if _, err := tx.Exec(
+			ctx,
+			`UPDATE job_attempts
+			 SET finished_at = now(), success = false, error_code = 'CANCELLED', error_message = $2
+			 WHERE job_id = ANY($1) AND finished_at IS NULL`,
+			jobIDs,
+			reason,
+		); err != nil {
+			return nil, fmt.Errorf("mark group attempts cancelled: %w", err)
+		}
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
+			 SELECT 'job.failed', id, NULL, $2, now()
+			 FROM unnest($1::text[]) AS id`,
+			jobIDs,
+			reason,
+		); err != nil {
+			return nil, fmt.Errorf("insert group cancel events: %w", err)
+		}
+		for _, jobID := range jobIDs {
+			if err := cancelDependantsTx(ctx, tx, jobID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := completeGroupIfDoneTx(ctx, tx, groupID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit cancel group: %w", err)
+	}
+	return jobIDs, nil
+}
+
+// RetryFailedGroup moves every failed or dead-lettered job in the group back
+// to queued (or blocked, while parents are outstanding) and returns the IDs
+// that were queued. They reach the ready queue through the outbox.
+func (s *PostgresStore) RetryFailedGroup(ctx context.Context, groupID string) ([]string, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if err := lockGroupTx(ctx, tx, groupID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`UPDATE jobs
+		 SET status = CASE WHEN `+unsatisfiedDependencySQL+` THEN 'blocked' ELSE 'queued' END,
+		     started_at = null,
+		     finished_at = null,
+		     error_code = null,
+		     error_message = null
+		 WHERE group_id = $1 AND status IN ('failed', 'dlq')
+		 RETURNING id, status`,
+		groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("retry group jobs: %w", err)
+	}
+	retried := make([]string, 0)
+	queued := make([]string, 0)
+	for rows.Next() {
+		var id string
+		var status models.JobStatus
+		if err := rows.Scan(&id, &status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("retry group jobs scan: %w", err)
+		}
+		retried = append(retried, id)
+		if status == models.JobStatusQueued {
+			queued = append(queued, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("retry group jobs rows: %w", err)
+	}
+
+	if len(retried) > 0 {
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
+			 SELECT 'job.retry_scheduled', id, NULL, 'Group retry of failed jobs requested', now()
+			 FROM unnest($1::text[]) AS id`,
+			retried,
+		); err != nil {
+			return nil, fmt.Errorf("insert group retry events: %w", err)
+		}
+		if err := reopenGroupTx(ctx, tx, groupID); err != nil {
+			return nil, err
+		}
+		if _, err := reblockDependantsTx(ctx, tx, retried); err != nil {
+			return nil, err
+		}
+	}
+	if err := enqueueOutboxTx(ctx, tx, queued, true); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit retry group: %w", err)
+	}
+	return queued, nil
+}
+
+// ClaimGroupWebhooks reserves completed groups whose webhook is due. Claimed
+// rows are pushed back by backoff before being returned so that a crashed
+// dispatcher only delays delivery rather than losing it.
+func (s *PostgresStore) ClaimGroupWebhooks(ctx context.Context, limit int, maxAttempts int, backoff time.Duration) ([]models.JobGroup, error) {
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`UPDATE job_groups
+		 SET webhook_attempts = webhook_attempts + 1,
+		     webhook_next_attempt_at = now() + make_interval(secs => $2)
+		 WHERE id IN (
+		     SELECT id FROM job_groups
+		     WHERE completed_at IS NOT NULL
+		       AND webhook_url IS NOT NULL
+		       AND webhook_delivered_at IS NULL
+		       AND webhook_attempts < $3
+		       AND (webhook_next_attempt_at IS NULL OR webhook_next_attempt_at <= now())
+		     ORDER BY completed_at
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+groupColumns,
+		limit,
+		backoff.Seconds(),
+		maxAttempts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim group webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]models.JobGroup, 0, limit)
+	for rows.Next() {
+		var group models.JobGroup
+		if err := rows.Scan(groupScanTargets(&group)...); err != nil {
+			return nil, fmt.Errorf("claim group webhooks scan: %w", err)
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim group webhooks rows: %w", err)
+	}
+	return groups, nil
+}
+
+func (s *PostgresStore) MarkGroupWebhookDelivered(ctx context.Context, groupID string) error {
+	if _, err := s.db(ctx).Exec(
+		ctx,
+		`UPDATE job_groups SET webhook_delivered_at = now(), webhook_last_error = NULL WHERE id = $1`,
+		groupID,
+	); err != nil {
+		return fmt.Errorf("mark group webhook delivered: %w", err)
+	}
+	return s.appendEvent(ctx, "group.webhook_delivered", nil, nil, "Completion webhook delivered for group "+groupID)
+}
+
+func (s *PostgresStore) MarkGroupWebhookFailed(ctx context.Context, groupID string, message string) error {
+	if _, err := s.db(ctx).Exec(
+		ctx,
+		`UPDATE job_groups SET webhook_last_error = $2 WHERE id = $1`,
+		groupID,
+		message,
+	); err != nil {
+		return fmt.Errorf("mark group webhook failed: %w", err)
+	}
+	return nil
+}
+
+func reopenGroupTx(ctx context.Context, tx pgx.Tx, groupID string) error {
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE job_groups
+		 SET completed_at = NULL, webhook_delivered_at = NULL, webhook_attempts = 0, webhook_next_attempt_at = NULL
+		 WHERE id = $1 AND completed_at IS NOT NULL`,
+		groupID,
+	); err != nil {
+		return fmt.Errorf("reopen job group tx: %w", err)
+	}
+	return nil
+}
+
+func lockGroupTx(ctx context.Context, tx pgx.Tx, groupID string) error {
+	var id string
+	err := tx.QueryRow(ctx, `SELECT id FROM job_groups WHERE id = $1 FOR UPDATE`, groupID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("lock job group: %w", err)
+	}
+	return nil
+}
+
+// markGroupCompletedTx completes the group of jobID once its last job has
+// reached a terminal status. It is a no-op for jobs without a group.
+func markGroupCompletedTx(ctx context.Context, tx pgx.Tx, jobID string) error {
+	var groupID *string
+	if err := tx.QueryRow(ctx, `SELECT group_id FROM jobs WHERE id = $1`, jobID).Scan(&groupID); err != nil {
+		return fmt.Errorf("load job group: %w", err)
+	}
+	if groupID == nil {
+		return nil
+	}
+
+	// Locking the group serialises concurrent completions: the check below
+	// runs with a fresh snapshot after the lock, so the last job to finish
+	// always observes every sibling's final status.
+	if err := lockGroupTx(ctx, tx, *groupID); err != nil {
+		return err
+	}
+	return completeGroupIfDoneTx(ctx, tx, *groupID)
+}
+
+func completeGroupIfDoneTx(ctx context.Context, tx pgx.Tx, groupID string) error {
+	cmdTag, err := tx.Exec(
+		ctx,
+		`UPDATE job_groups g
+		 SET completed_at = now()
+		 WHERE g.id = $1
+		   AND g.completed_at IS NULL
+		   AND EXISTS (SELECT 1 FROM jobs WHERE group_id = g.id)
+		   AND NOT EXISTS (
+		       SELECT 1 FROM jobs
+		       WHERE group_id = g.id AND status NOT IN ('succeeded', 'failed', 'dlq', 'cancelled')
+		   )`,
+		groupID,
+	)
+	if err != nil {
+		return fmt.Errorf("complete job group: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return nil
+	}
+	return appendEventTx(ctx, tx, "group.completed", nil, nil, "All jobs in group "+groupID+" reached a terminal status")
+}
@@ -0,0 +1,219 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrIdempotencyMismatch is returned when an idempotency key is reused with a
+// request that differs from the one that created the job.
+var ErrIdempotencyMismatch = errors.New("idempotency key reused with a different request")
+
+// requestFingerprint hashes the parts of a normalized create request that
+// define the work. Scheduling fields are left out because relative forms
+// (delay_seconds, ttl_seconds) resolve to a different instant on every retry.
+func requestFingerprint(input models.CreateJobInput) (string, error) {
+	payload, err := canonicalJSON(input.PayloadJSON)
+	if err != nil {
+		return "", fmt.Errorf("canonicalize payload: %w", err)
+	}
+
+	canonical, err := json.Marshal(struct {
+		Model       string          `json:"model"`
+		Payload     json.RawMessage `json:"payload"`
+		Priority    int             `json:"priority"`
+		MaxAttempts int             `json:"max_attempts"`
+		GroupID     string          `json:"group_id"`
+		DependsOn   []string        `json:"depends_on"`
+		// Left out when empty so keys stored before fallback chains existed
+		// keep their fingerprint.
+		FallbackModels []string `json:"fallback_models,omitempty"`
+		FallbackOn     []string `json:"fallback_on,omitempty"`
+	}{
+		Model:          input.Model,
+		Payload:        payload,
+		Priority:       input.Priority,
+		MaxAttempts:    input.MaxAttempts,
+		GroupID:        input.GroupID,
+		DependsOn:      input.DependsOn,
+		FallbackModels: input.FallbackModels,
+		FallbackOn:     input.FallbackOn,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode fingerprint: %w", err)
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON re-encodes a document with sorted object keys and without
+// insignificant whitespace. Numbers keep their literal form.
+func canonicalJSON(raw json.RawMessage) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// idempotencyClaim reserves an idempotency key for a job that is about to be
+// inserted.
+type idempotencyClaim struct {
+	tenantID    string
+	key         string
+	jobID       string
+	fingerprint string
+	expiresAt   *time.Time
+}
+
+// releaseExpiredIdempotencyKeysTx drops expired reservations so the keys can
+// be claimed by new jobs. The jobs created under them keep their key.
+func releaseExpiredIdempotencyKeysTx(ctx context.Context, tx pgx.Tx, tenantIDs []string, keys []string) error {
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM idempotency_keys
+		 WHERE (tenant_id, key) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+		   AND expires_at <= now()`,
+		tenantIDs,
+		keys,
+	); err != nil {
+		return fmt.Errorf("release expired idempotency keys: %w", err)
+	}
+	return nil
+}
+
+// claimIdempotencyKeysTx reserves keys for the given jobs and returns the IDs
+// of the jobs whose claim succeeded. A claim fails when the key is already
+// held, in which case the request replays the holder.
+func claimIdempotencyKeysTx(ctx context.Context, tx pgx.Tx, claims []idempotencyClaim) (map[string]bool, error) {
+	claimed := make(map[string]bool, len(claims))
+	if len(claims) == 0 {
+		return claimed, nil
+	}
+
+	tenantIDs := make([]string, len(claims))
+	keys := make([]string, len(claims))
+	jobIDs := make([]string, len(claims))
+	fingerprints := make([]string, len(claims))
+	expiresAt := make([]*time.Time, len(claims))
+	for i, claim := range claims {
+		tenantIDs[i] = claim.tenantID
+		keys[i] = claim.key
+		jobIDs[i] = claim.jobID
+		fingerprints[i] = claim.fingerprint
+		expiresAt[i] = claim.expiresAt
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`INSERT INTO idempotency_keys (tenant_id, key, job_id, fingerprint, expires_at)
+		 SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::timestamptz[])
+		 ON CONFLICT (tenant_id, key) DO NOTHING
+		 RETURNING job_id`,
+		tenantIDs,
+		keys,
+		jobIDs,
+		fingerprints,
+		expiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim idempotency keys: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var jobID string
+		if err := rows.Scan(&jobID); err != nil {
+			return nil, fmt.Errorf("claim idempotency keys scan: %w", err)
+		}
+		claimed[jobID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim idempotency keys rows: %w", err)
+	}
+	return claimed, nil
+}
+
+func (s *PostgresStore) ReplayedInputs(ctx context.Context, inputs []models.CreateJobInput) ([]bool, error) {
+	replayed := make([]bool, len(inputs))
+	tenantIDs := make([]string, 0, len(inputs))
+	keys := make([]string, 0, len(inputs))
+	indexes := make([]int, 0, len(inputs))
+	for index, input := range inputs {
+		if input.IdempotencyKey != "" {
+			tenantIDs = append(tenantIDs, input.TenantID)
+			keys = append(keys, input.IdempotencyKey)
+			indexes = append(indexes, index)
+		}
+	}
+	if len(keys) == 0 {
+		return replayed, nil
+	}
+
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT requested.ord
+		 FROM unnest($1::text[], $2::text[]) WITH ORDINALITY AS requested(tenant_id, key, ord)
+		 JOIN idempotency_keys held ON held.tenant_id = requested.tenant_id AND held.key = requested.key
+		 WHERE held.expires_at IS NULL OR held.expires_at > now()`,
+		tenantIDs,
+		keys,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find replayed inputs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ord int
+		if err := rows.Scan(&ord); err != nil {
+			return nil, fmt.Errorf("find replayed inputs scan: %w", err)
+		}
+		replayed[indexes[ord-1]] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("find replayed inputs rows: %w", err)
+	}
+	return replayed, nil
+}
+
+// getJobByTenantAndIdempotencyTx returns the job holding an idempotency key
+// together with the fingerprint of the request that created it. Jobs created
+// before fingerprints were recorded report an empty fingerprint.
+func getJobByTenantAndIdempotencyTx(ctx context.Context, tx pgx.Tx, tenantID string, key string) (models.Job, string, error) {
+	job := models.Job{}
+	var fingerprint string
+	err := tx.QueryRow(
+		ctx,
+		`WITH holder AS (
+			SELECT job_id, fingerprint FROM idempotency_keys WHERE tenant_id = $1 AND key = $2
+		 )
+		 SELECT `+jobColumns+`, holder.fingerprint
+		 FROM jobs
+		 JOIN holder ON holder.job_id = jobs.id`,
+		tenantID,
+		key,
+	).Scan(append(jobScanTargets(&job), &fingerprint)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Job{}, "", ErrNotFound
+	}
+	if err != nil {
+		return models.Job{}, "", fmt.Errorf("get job by tenant + idempotency tx: %w", err)
+	}
+	return job, fingerprint, nil
+}
+
+func fingerprintsMatch(stored string, requested string) bool {
+	return stored == "" || stored == requested
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLease is a session-level Postgres advisory lock held on a dedicated
+// pool connection. The lock is lost if that connection dies, so holders should
+// call Alive periodically and stop acting as leader once it fails.
+type AdvisoryLease struct {
+	conn *pgxpool.Conn
+	key  int64
+}
+
+// TryAdvisoryLock attempts to take the advisory lock for key without waiting.
+// It returns a nil lease when another session holds the lock.
+func (s *PostgresStore) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLease, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire lock connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("try advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Release()
+		return nil, nil
+	}
+	return &AdvisoryLease{conn: conn, key: key}, nil
+}
+
+// Alive checks that the connection holding the lock is still usable.
+func (l *AdvisoryLease) Alive(ctx context.Context) error {
+	return l.conn.Ping(ctx)
+}
+
+// Release unlocks and returns the connection to the pool. If unlocking fails
+// the connection is closed instead, which releases the lock server-side.
+func (l *AdvisoryLease) Release(ctx context.Context) {
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		_ = l.conn.Conn().Close(ctx)
+	}
+	l.conn.Release()
+}
+
+// RunAsLeader runs fn at startup and then every interval, but only while this
+// process holds the advisory lock for key. The lease is checked before each
+// round; once it is lost another round of election decides the next leader.
+// name identifies the leader in logs. RunAsLeader returns when ctx is done,
+// releasing the lock if it holds it.
+func (s *PostgresStore) RunAsLeader(ctx context.Context, key int64, name string, interval time.Duration, logger *slog.Logger, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lease *AdvisoryLease
+	defer func() {
+		if lease != nil {
+			lease.Release(context.Background())
+		}
+	}()
+
+	for {
+		if lease != nil {
+			if err := lease.Alive(ctx); err != nil {
+				logger.Warn("leader connection lost", "leader", name, "error", err)
+				lease.Release(ctx)
+				lease = nil
+			}
+		}
+		if lease == nil {
+			acquired, err := s.TryAdvisoryLock(ctx, key)
+			if err != nil {
+				logger.Error("leader election failed", "leader", name, "error", err)
+			} else if acquired != nil {
+				lease = acquired
+				logger.Info("acquired leadership", "leader", name)
+			}
+		}
+		if lease != nil {
+			fn(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
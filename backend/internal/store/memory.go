@@ -0,0 +1,1940 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryEvent is an entry of the MemoryStore event log, the counterpart of a
+// row in the events table.
+type MemoryEvent struct {
+	Type      string
+	JobID     string
+	WorkerID  string
+	Details   string
+	CreatedAt time.Time
+}
+
+// MemoryHeartbeat is the last heartbeat a worker reported to a MemoryStore.
+type MemoryHeartbeat struct {
+	State        string
+	RunningJobID string
+	Concurrency  int
+	At           time.Time
+}
+
+type memoryJob struct {
+	job       models.Job
+	dependsOn []string
+}
+
+type memoryAPIKey struct {
+	key  models.APIKey
+	hash string
+}
+
+// idempotencyKey identifies a tenant's idempotency key.
+type idempotencyKey struct{ tenantID, key string }
+
+// memoryIdempotency is a key reservation, the counterpart of a row in the
+// idempotency_keys table.
+type memoryIdempotency struct {
+	jobID       string
+	fingerprint string
+	expiresAt   *time.Time
+}
+
+// fallbackKey identifies a tenant's fallback policy for a requested model.
+type fallbackKey struct{ tenantID, model string }
+
+// memorySpend is a tenant's spend in month, a "2006-01" UTC month.
+type memorySpend struct {
+	month         string
+	spentUSD      float64
+	softAlertedAt *time.Time
+	capReachedAt  *time.Time
+}
+
+// MemoryStore implements Store in process memory with the same state
+// transitions as PostgresStore. A single mutex stands in for the row locks
+// and transactions of the Postgres implementation. It is meant for tests.
+type MemoryStore struct {
+	mu         sync.Mutex
+	jobs       map[string]*memoryJob
+	idemKeys   map[idempotencyKey]memoryIdempotency
+	attempts   map[string][]models.JobAttempt
+	groups     map[string]*models.JobGroup
+	schedules  map[string]*models.Schedule
+	retention  map[string]models.RetentionPolicy
+	apiKeys    map[string]*memoryAPIKey
+	audit      []models.AuditEntry
+	prices     []models.ModelPrice
+	spendCaps  map[string]models.SpendCap
+	fallbacks  map[fallbackKey]models.FallbackPolicy
+	spend      map[string]*memorySpend
+	outbox     []models.OutboxEntry
+	outboxSeq  int64
+	events     []MemoryEvent
+	heartbeats map[string]MemoryHeartbeat
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:       make(map[string]*memoryJob),
+		idemKeys:   make(map[idempotencyKey]memoryIdempotency),
+		attempts:   make(map[string][]models.JobAttempt),
+		groups:     make(map[string]*models.JobGroup),
+		schedules:  make(map[string]*models.Schedule),
+		retention:  make(map[string]models.RetentionPolicy),
+		apiKeys:    make(map[string]*memoryAPIKey),
+		spendCaps:  make(map[string]models.SpendCap),
+		fallbacks:  make(map[fallbackKey]models.FallbackPolicy),
+		spend:      make(map[string]*memorySpend),
+		heartbeats: make(map[string]MemoryHeartbeat),
+	}
+}
+
+// Events returns a copy of the event log in insertion order.
+func (m *MemoryStore) Events() []MemoryEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MemoryEvent(nil), m.events...)
+}
+
+// Heartbeat returns the last heartbeat reported by workerID.
+func (m *MemoryStore) Heartbeat(workerID string) (MemoryHeartbeat, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	heartbeat, ok := m.heartbeats[workerID]
+	return heartbeat, ok
+}
+
+// memoryNow matches the microsecond precision of Postgres timestamps.
+func memoryNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func (m *MemoryStore) CreateJob(ctx context.Context, input models.CreateJobInput) (models.Job, bool, error) {
+	input = normalizeCreateJobInput(input)
+
+	var requestHash string
+	if input.IdempotencyKey != "" {
+		hash, err := requestFingerprint(input)
+		if err != nil {
+			return models.Job{}, false, err
+		}
+		requestHash = hash
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if input.IdempotencyKey != "" {
+		m.releaseExpiredIdempotencyKey(input.TenantID, input.IdempotencyKey)
+	}
+
+	status := models.JobStatusQueued
+	var errorCode, errorMessage string
+	if len(input.DependsOn) > 0 {
+		resolution, err := m.resolveDependencies(input.TenantID, input.DependsOn)
+		if err != nil {
+			return models.Job{}, false, err
+		}
+		status = resolution.status
+		if resolution.failedParentID != "" {
+			errorCode = dependencyFailedCode
+			errorMessage = "Parent job " + resolution.failedParentID + " failed permanently"
+		}
+	}
+	if status == models.JobStatusQueued && isFutureRunAt(input.RunAt) {
+		status = models.JobStatusScheduled
+	}
+
+	if input.IdempotencyKey != "" {
+		if existing, storedHash := m.jobByIdempotencyKey(input.TenantID, input.IdempotencyKey); existing != nil {
+			if !fingerprintsMatch(storedHash, requestHash) {
+				return existing.job, true, ErrIdempotencyMismatch
+			}
+			return existing.job, true, nil
+		}
+	}
+
+	now := memoryNow()
+	job := m.insertJob(input, status, requestHash, now)
+	if status == models.JobStatusCancelled {
+		job.job.FinishedAt = &now
+		job.job.ErrorCode = errorCode
+		job.job.ErrorMessage = errorMessage
+	}
+	if len(input.DependsOn) > 0 {
+		job.dependsOn = append([]string(nil), input.DependsOn...)
+	}
+	if job.job.GroupID != "" {
+		m.reopenGroup(job.job.GroupID)
+	}
+
+	m.appendEvent("job.created", job.job.ID, "", "Job accepted via POST /v1/jobs")
+	switch job.job.Status {
+	case models.JobStatusQueued:
+		m.enqueueOutbox([]string{job.job.ID}, false)
+	case models.JobStatusBlocked:
+		m.appendEvent("job.blocked", job.job.ID, "", "Waiting for parent jobs to succeed")
+	case models.JobStatusScheduled:
+		m.appendEvent("job.scheduled", job.job.ID, "", "Scheduled to run at "+job.job.RunAt.UTC().Format(time.RFC3339))
+	case models.JobStatusCancelled:
+		m.appendEvent("job.failed", job.job.ID, "", job.job.ErrorMessage)
+		m.markGroupCompleted(job.job.ID)
+	}
+	return job.job, false, nil
+}
+
+// CreateJobsBatch mirrors PostgresStore.CreateJobsBatch, including the
+// rollback of the whole batch on a mismatch in atomic mode.
+func (m *MemoryStore) CreateJobsBatch(ctx context.Context, inputs []models.CreateJobInput, atomic bool) ([]models.CreateJobResult, error) {
+	results := make([]models.CreateJobResult, len(inputs))
+	if len(inputs) == 0 {
+		return results, nil
+	}
+
+	type pendingInsert struct {
+		index       int
+		input       models.CreateJobInput
+		fingerprint string
+	}
+
+	pending := make([]pendingInsert, 0, len(inputs))
+	fingerprints := make([]string, len(inputs))
+	firstByKey := make(map[string]int, len(inputs))
+	duplicateOf := make(map[int]int)
+	for index, input := range inputs {
+		input = normalizeCreateJobInput(input)
+		if input.IdempotencyKey != "" {
+			hash, err := requestFingerprint(input)
+			if err != nil {
+				return nil, err
+			}
+			fingerprints[index] = hash
+			dedupKey := input.TenantID + "\x00" + input.IdempotencyKey
+			if first, ok := firstByKey[dedupKey]; ok {
+				duplicateOf[index] = first
+				continue
+			}
+			firstByKey[dedupKey] = index
+		}
+		pending = append(pending, pendingInsert{index: index, input: input, fingerprint: fingerprints[index]})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, item := range pending {
+		if item.input.IdempotencyKey != "" {
+			m.releaseExpiredIdempotencyKey(item.input.TenantID, item.input.IdempotencyKey)
+		}
+	}
+
+	// Resolve replays before inserting anything so that an atomic batch can
+	// be rejected without side effects.
+	inserts := make([]pendingInsert, 0, len(pending))
+	for _, item := range pending {
+		if item.input.IdempotencyKey != "" {
+			if existing, storedHash := m.jobByIdempotencyKey(item.input.TenantID, item.input.IdempotencyKey); existing != nil {
+				results[item.index] = models.CreateJobResult{
+					Job:      existing.job,
+					Existing: true,
+					Mismatch: !fingerprintsMatch(storedHash, item.fingerprint),
+				}
+				continue
+			}
+		}
+		inserts = append(inserts, item)
+	}
+	for index, first := range duplicateOf {
+		results[index] = models.CreateJobResult{
+			Existing: true,
+			Mismatch: results[first].Mismatch || fingerprints[index] != fingerprints[first],
+		}
+	}
+	if atomic {
+		for _, result := range results {
+			if result.Mismatch {
+				for index, first := range duplicateOf {
+					results[index].Job = results[first].Job
+				}
+				return results, ErrIdempotencyMismatch
+			}
+		}
+	}
+
+	now := memoryNow()
+	queuedIDs := make([]string, 0, len(inserts))
+	createdGroups := make(map[string]struct{})
+	for _, item := range inserts {
+		status := models.JobStatusQueued
+		if isFutureRunAt(item.input.RunAt) {
+			status = models.JobStatusScheduled
+		}
+		job := m.insertJob(item.input, status, item.fingerprint, now)
+		results[item.index] = models.CreateJobResult{Job: job.job}
+		m.appendEvent("job.created", job.job.ID, "", "Job accepted via POST /v1/jobs/batch")
+		if status == models.JobStatusQueued {
+			queuedIDs = append(queuedIDs, job.job.ID)
+		}
+		if job.job.GroupID != "" {
+			createdGroups[job.job.GroupID] = struct{}{}
+		}
+	}
+	for index, first := range duplicateOf {
+		results[index].Job = results[first].Job
+	}
+
+	m.enqueueOutbox(queuedIDs, false)
+	for groupID := range createdGroups {
+		m.reopenGroup(groupID)
+	}
+	return results, nil
+}
+
+func (m *MemoryStore) ReplayedInputs(ctx context.Context, inputs []models.CreateJobInput) ([]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	replayed := make([]bool, len(inputs))
+	now := memoryNow()
+	for index, input := range inputs {
+		if input.IdempotencyKey == "" {
+			continue
+		}
+		reservation, ok := m.idemKeys[idempotencyKey{input.TenantID, input.IdempotencyKey}]
+		if !ok || (reservation.expiresAt != nil && !reservation.expiresAt.After(now)) {
+			continue
+		}
+		existing, _ := m.jobByIdempotencyKey(input.TenantID, input.IdempotencyKey)
+		replayed[index] = existing != nil
+	}
+	return replayed, nil
+}
+
+func (m *MemoryStore) insertJob(input models.CreateJobInput, status models.JobStatus, fingerprint string, now time.Time) *memoryJob {
+	job := &memoryJob{
+		job: models.Job{
+			ID:                   uuid.NewString(),
+			TenantID:             input.TenantID,
+			Status:               status,
+			Priority:             input.Priority,
+			Model:                input.Model,
+			FallbackModels:       input.FallbackModels,
+			FallbackOn:           input.FallbackOn,
+			PayloadJSON:          input.PayloadJSON,
+			IdempotencyKey:       input.IdempotencyKey,
+			MaxAttempts:          input.MaxAttempts,
+			CreatedAt:            now,
+			TraceID:              input.TraceID,
+			Traceparent:          input.Traceparent,
+			Tracestate:           input.Tracestate,
+			GroupID:              input.GroupID,
+			RunAt:                input.RunAt,
+			Deadline:             input.Deadline,
+			IdempotencyExpiresAt: input.IdempotencyExpiresAt,
+		},
+	}
+	m.jobs[job.job.ID] = job
+	if input.IdempotencyKey != "" {
+		m.idemKeys[idempotencyKey{input.TenantID, input.IdempotencyKey}] = memoryIdempotency{
+			jobID:       job.job.ID,
+			fingerprint: fingerprint,
+			expiresAt:   input.IdempotencyExpiresAt,
+		}
+	}
+	return job
+}
+
+// jobByIdempotencyKey returns the job holding a key and the fingerprint of
+// the request that created it. A deleted holder frees the key, as the
+// cascading foreign key does in Postgres.
+func (m *MemoryStore) jobByIdempotencyKey(tenantID string, key string) (*memoryJob, string) {
+	reservation, ok := m.idemKeys[idempotencyKey{tenantID, key}]
+	if !ok {
+		return nil, ""
+	}
+	job, ok := m.jobs[reservation.jobID]
+	if !ok {
+		delete(m.idemKeys, idempotencyKey{tenantID, key})
+		return nil, ""
+	}
+	return job, reservation.fingerprint
+}
+
+func (m *MemoryStore) releaseExpiredIdempotencyKey(tenantID string, key string) {
+	reservation, ok := m.idemKeys[idempotencyKey{tenantID, key}]
+	if ok && reservation.expiresAt != nil && !reservation.expiresAt.After(memoryNow()) {
+		delete(m.idemKeys, idempotencyKey{tenantID, key})
+	}
+}
+
+func (m *MemoryStore) resolveDependencies(tenantID string, parentIDs []string) (dependencyResolution, error) {
+	allSucceeded := true
+	resolution := dependencyResolution{}
+	for _, parentID := range parentIDs {
+		parent, ok := m.jobs[parentID]
+		if !ok || parent.job.TenantID != tenantID {
+			return dependencyResolution{}, ErrDependencyNotFound
+		}
+		if parent.job.Status != models.JobStatusSucceeded {
+			allSucceeded = false
+		}
+		if isPermanentFailure(parent.job.Status) && resolution.failedParentID == "" {
+			resolution.failedParentID = parentID
+		}
+	}
+
+	switch {
+	case resolution.failedParentID != "":
+		resolution.status = models.JobStatusCancelled
+	case allSucceeded:
+		resolution.status = models.JobStatusQueued
+	default:
+		resolution.status = models.JobStatusBlocked
+	}
+	return resolution, nil
+}
+
+func (m *MemoryStore) GetJobByID(ctx context.Context, jobID string) (models.Job, *models.JobAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return models.Job{}, nil, ErrNotFound
+	}
+	attempts := m.attempts[jobID]
+	if len(attempts) == 0 {
+		return job.job, nil, nil
+	}
+	latest := attempts[0]
+	for _, attempt := range attempts[1:] {
+		if attempt.Attempt > latest.Attempt {
+			latest = attempt
+		}
+	}
+	latest.DurationMS = attemptDurationMS(latest)
+	return job.job, &latest, nil
+}
+
+func (m *MemoryStore) ListJobAttempts(ctx context.Context, jobID string) (models.JobAttemptHistory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return models.JobAttemptHistory{}, ErrNotFound
+	}
+
+	attempts := append(make([]models.JobAttempt, 0, job.job.Attempt), m.attempts[jobID]...)
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].Attempt < attempts[j].Attempt })
+	for i := range attempts {
+		attempts[i].DurationMS = attemptDurationMS(attempts[i])
+	}
+	return models.JobAttemptHistory{
+		JobID:    job.job.ID,
+		Attempts: attempts,
+		Totals:   summarizeAttempts(attempts),
+	}, nil
+}
+
+func (m *MemoryStore) ListJobs(ctx context.Context, filter models.ListJobsFilter) (models.JobPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	sortKey := filter.Sort
+	if sortKey == "" {
+		sortKey = models.JobSortCreatedDesc
+	}
+	var less func(a, b models.Job) bool
+	switch sortKey {
+	case models.JobSortCreatedAsc:
+		less = func(a, b models.Job) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		}
+	case models.JobSortPriorityDesc:
+		less = func(a, b models.Job) bool {
+			if a.Priority != b.Priority {
+				return a.Priority > b.Priority
+			}
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		}
+	case models.JobSortCreatedDesc:
+		less = func(a, b models.Job) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		}
+	default:
+		return models.JobPage{}, fmt.Errorf("unknown sort %q", sortKey)
+	}
+
+	var cursor *models.Job
+	if filter.Cursor != "" {
+		decoded, err := decodeJobCursor(sortKey, filter.Cursor)
+		if err != nil {
+			return models.JobPage{}, err
+		}
+		cursor = &models.Job{ID: decoded.ID, CreatedAt: decoded.CreatedAt, Priority: decoded.Priority}
+	}
+
+	m.mu.Lock()
+	matched := make([]models.Job, 0)
+	for _, job := range m.jobs {
+		if matchesJobFilter(job.job, filter) {
+			matched = append(matched, job.job)
+		}
+	}
+	m.mu.Unlock()
+
+	page := models.JobPage{}
+	if filter.IncludeTotal {
+		total := int64(len(matched))
+		page.Total = &total
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+	jobs := make([]models.Job, 0, limit+1)
+	for _, job := range matched {
+		// Rows sorting after the cursor row are exactly the keyset tail.
+		if cursor != nil && !less(*cursor, job) {
+			continue
+		}
+		jobs = append(jobs, job)
+		if len(jobs) > limit {
+			break
+		}
+	}
+
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+		page.NextCursor = encodeJobCursor(sortKey, jobs[len(jobs)-1])
+	}
+	page.Jobs = jobs
+	return page, nil
+}
+
+func matchesJobFilter(job models.Job, filter models.ListJobsFilter) bool {
+	if len(filter.Statuses) > 0 {
+		found := false
+		for _, status := range filter.Statuses {
+			if job.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	switch {
+	case filter.TenantID != "" && job.TenantID != filter.TenantID,
+		filter.Model != "" && job.Model != filter.Model,
+		filter.ErrorCode != "" && job.ErrorCode != filter.ErrorCode,
+		filter.IdempotencyKey != "" && job.IdempotencyKey != filter.IdempotencyKey,
+		filter.TraceID != "" && job.TraceID != filter.TraceID,
+		filter.CreatedAfter != nil && job.CreatedAt.Before(*filter.CreatedAfter),
+		filter.CreatedBefore != nil && !job.CreatedAt.Before(*filter.CreatedBefore),
+		filter.FinishedAfter != nil && (job.FinishedAt == nil || job.FinishedAt.Before(*filter.FinishedAfter)),
+		filter.FinishedBefore != nil && (job.FinishedAt == nil || !job.FinishedAt.Before(*filter.FinishedBefore)),
+		filter.MinPriority != nil && job.Priority < *filter.MinPriority,
+		filter.MaxPriority != nil && job.Priority > *filter.MaxPriority:
+		return false
+	}
+	return true
+}
+
+func (m *MemoryStore) CancelJob(ctx context.Context, jobID string, reason string) (models.Job, error) {
+	if strings.TrimSpace(reason) == "" {
+		reason = "Cancelled by operator"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return models.Job{}, ErrNotFound
+	}
+	if !isCancellable(job.job.Status) {
+		return models.Job{}, ErrInvalidStateTransition
+	}
+
+	m.cancelJob(job, reason)
+	m.appendEvent("job.failed", jobID, "", reason)
+	m.cancelDependants(jobID)
+	m.markGroupCompleted(jobID)
+	return job.job, nil
+}
+
+func isCancellable(status models.JobStatus) bool {
+	switch status {
+	case models.JobStatusQueued, models.JobStatusRunning, models.JobStatusRetryScheduled, models.JobStatusBlocked, models.JobStatusScheduled:
+		return true
+	default:
+		return false
+	}
+}
+
+// cancelJob marks the job and its open attempt cancelled.
+func (m *MemoryStore) cancelJob(job *memoryJob, reason string) {
+	now := memoryNow()
+	job.job.Status = models.JobStatusCancelled
+	job.job.FinishedAt = &now
+	job.job.ErrorCode = "CANCELLED"
+	job.job.ErrorMessage = reason
+
+	attempts := m.attempts[job.job.ID]
+	for i := range attempts {
+		if attempts[i].FinishedAt == nil {
+			success := false
+			attempts[i].FinishedAt = &now
+			attempts[i].Success = &success
+			attempts[i].ErrorCode = "CANCELLED"
+			attempts[i].ErrorMessage = reason
+		}
+	}
+}
+
+func (m *MemoryStore) RetryJob(ctx context.Context, jobID string, reason string) (models.Job, error) {
+	if strings.TrimSpace(reason) == "" {
+		reason = "Manual retry requested"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return models.Job{}, ErrNotFound
+	}
+	switch job.job.Status {
+	case models.JobStatusFailed, models.JobStatusCancelled, models.JobStatusDLQ, models.JobStatusRetryScheduled, models.JobStatusQueued:
+	default:
+		return models.Job{}, ErrInvalidStateTransition
+	}
+
+	m.requeue(job, m.hasUnsatisfiedDependency(job))
+	if job.job.Deadline != nil && !job.job.Deadline.After(memoryNow()) {
+		job.job.Deadline = nil
+	}
+	m.appendEvent("job.retry_scheduled", jobID, "", reason)
+	m.reblockDependants([]string{jobID})
+	if job.job.Status == models.JobStatusQueued {
+		m.enqueueOutbox([]string{jobID}, true)
+	}
+	if job.job.GroupID != "" {
+		m.reopenGroup(job.job.GroupID)
+	}
+	return job.job, nil
+}
+
+// requeue resets a finished job to queued, or to blocked while a parent has
+// not succeeded.
+func (m *MemoryStore) requeue(job *memoryJob, blocked bool) {
+	job.job.Status = models.JobStatusQueued
+	if blocked {
+		job.job.Status = models.JobStatusBlocked
+	}
+	job.job.StartedAt = nil
+	job.job.FinishedAt = nil
+	job.job.ErrorCode = ""
+	job.job.ErrorMessage = ""
+}
+
+func (m *MemoryStore) hasUnsatisfiedDependency(job *memoryJob) bool {
+	for _, parentID := range job.dependsOn {
+		if parent, ok := m.jobs[parentID]; ok && parent.job.Status != models.JobStatusSucceeded {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryStore) RescheduleJob(ctx context.Context, jobID string, runAt time.Time) (models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return models.Job{}, ErrNotFound
+	}
+	if job.job.Status != models.JobStatusScheduled && job.job.Status != models.JobStatusQueued {
+		return models.Job{}, ErrInvalidStateTransition
+	}
+
+	// Postgres keeps microseconds; without truncating, a run time of "now"
+	// can compare as later than memoryNow and leave the job scheduled.
+	runAt = runAt.UTC().Truncate(time.Microsecond)
+	job.job.RunAt = &runAt
+	job.job.Status = models.JobStatusQueued
+	if runAt.After(memoryNow()) {
+		job.job.Status = models.JobStatusScheduled
+	}
+	m.appendEvent("job.scheduled", jobID, "", "Rescheduled to run at "+runAt.UTC().Format(time.RFC3339))
+	if job.job.Status == models.JobStatusQueued {
+		m.enqueueOutbox([]string{jobID}, true)
+	}
+	return job.job, nil
+}
+
+func (m *MemoryStore) PromoteDueJobs(ctx context.Context, limit int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := memoryNow()
+	due := make([]*memoryJob, 0)
+	for _, job := range m.jobs {
+		if job.job.Status == models.JobStatusScheduled && job.job.RunAt != nil && !job.job.RunAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].job.RunAt.Before(*due[j].job.RunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	jobIDs := make([]string, 0, len(due))
+	for _, job := range due {
+		job.job.Status = models.JobStatusQueued
+		jobIDs = append(jobIDs, job.job.ID)
+		m.appendEvent("job.promoted", job.job.ID, "", "Scheduled run time reached")
+	}
+	m.enqueueOutbox(jobIDs, false)
+	return jobIDs, nil
+}
+
+func (m *MemoryStore) ExpireJob(ctx context.Context, jobID string, workerID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.job.Status != models.JobStatusQueued || !isPastDeadline(job.job.Deadline) {
+		return false, nil
+	}
+	m.expireJob(job, workerID)
+	return true, nil
+}
+
+func (m *MemoryStore) ExpireOverdueJobs(ctx context.Context, limit int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	overdue := make([]*memoryJob, 0)
+	for _, job := range m.jobs {
+		switch job.job.Status {
+		case models.JobStatusQueued, models.JobStatusScheduled, models.JobStatusBlocked, models.JobStatusRetryScheduled:
+			if isPastDeadline(job.job.Deadline) {
+				overdue = append(overdue, job)
+			}
+		}
+	}
+	if len(overdue) == 0 {
+		return nil, nil
+	}
+	sort.Slice(overdue, func(i, j int) bool { return overdue[i].job.Deadline.Before(*overdue[j].job.Deadline) })
+	if len(overdue) > limit {
+		overdue = overdue[:limit]
+	}
+
+	// Fail the whole set before cascading, as the single UPDATE does.
+	expired := make([]string, 0, len(overdue))
+	for _, job := range overdue {
+		m.failExpired(job)
+		expired = append(expired, job.job.ID)
+	}
+	for _, job := range overdue {
+		m.finishExpired(job, "")
+	}
+	return expired, nil
+}
+
+func isPastDeadline(deadline *time.Time) bool {
+	return deadline != nil && !deadline.After(memoryNow())
+}
+
+func (m *MemoryStore) expireJob(job *memoryJob, workerID string) {
+	m.failExpired(job)
+	m.finishExpired(job, workerID)
+}
+
+func (m *MemoryStore) failExpired(job *memoryJob) {
+	now := memoryNow()
+	job.job.Status = models.JobStatusFailed
+	job.job.FinishedAt = &now
+	job.job.ErrorCode = ExpiredCode
+	job.job.ErrorMessage = "Deadline " + job.job.Deadline.UTC().Format("2006-01-02T15:04:05Z") + " passed before the job started"
+}
+
+func (m *MemoryStore) finishExpired(job *memoryJob, workerID string) {
+	m.appendEvent("job.expired", job.job.ID, workerID, "Deadline passed before the job started")
+	m.cancelDependants(job.job.ID)
+	m.markGroupCompleted(job.job.ID)
+}
+
+func (m *MemoryStore) MarkJobRunning(ctx context.Context, jobID string, workerID string) (models.Job, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.job.Status != models.JobStatusQueued {
+		return models.Job{}, false, nil
+	}
+	now := memoryNow()
+	if job.job.Deadline != nil && !job.job.Deadline.After(now) {
+		return models.Job{}, false, nil
+	}
+
+	job.job.Status = models.JobStatusRunning
+	job.job.StartedAt = &now
+	job.job.Attempt++
+	job.job.ModelUsed = job.job.Model
+	job.job.ErrorCode = ""
+	job.job.ErrorMessage = ""
+	m.startAttempt(job, workerID, now)
+
+	m.appendEvent("job.started", jobID, workerID, "Dequeued and started")
+	return job.job, true, nil
+}
+
+// startAttempt records the start of the job's current attempt on the model it
+// uses.
+func (m *MemoryStore) startAttempt(job *memoryJob, workerID string, now time.Time) {
+	attempts := m.attempts[job.job.ID]
+	for i := range attempts {
+		if attempts[i].Attempt == job.job.Attempt {
+			attempts[i].Model = job.job.ModelUsed
+			attempts[i].StartedAt = &now
+			attempts[i].WorkerID = workerID
+			return
+		}
+	}
+	m.attempts[job.job.ID] = append(attempts, models.JobAttempt{
+		JobID:     job.job.ID,
+		Attempt:   job.job.Attempt,
+		Model:     job.job.ModelUsed,
+		WorkerID:  workerID,
+		StartedAt: &now,
+	})
+}
+
+func (m *MemoryStore) MarkJobSucceeded(
+	ctx context.Context,
+	jobID string,
+	workerID string,
+	usage models.Usage,
+	costUSD float64,
+	providerMeta json.RawMessage,
+	result json.RawMessage,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.job.Status != models.JobStatusRunning {
+		return ErrNotFound
+	}
+
+	now := memoryNow()
+	job.job.Status = models.JobStatusSucceeded
+	job.job.FinishedAt = &now
+	job.job.ErrorCode = ""
+	job.job.ErrorMessage = ""
+	job.job.ResultJSON = result
+
+	success := true
+	attempt := m.finishAttempt(job, workerID, now)
+	attempt.Success = &success
+	attempt.Tokens = usage.Total()
+	attempt.PromptTokens = usage.PromptTokens
+	attempt.CachedPromptTokens = usage.CachedPromptTokens
+	attempt.CompletionTokens = usage.CompletionTokens
+	attempt.CostUSD = costUSD
+	attempt.ProviderMeta = providerMeta
+
+	m.appendEvent("job.succeeded", jobID, workerID, "Provider returned completion")
+	m.recordSpend(job.job.TenantID, jobID, workerID, costUSD, now)
+	m.releaseDependants(jobID)
+	m.markGroupCompleted(jobID)
+	return nil
+}
+
+func (m *MemoryStore) MarkJobFailed(ctx context.Context, jobID string, workerID string, errorCode string, errorMessage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.job.Status != models.JobStatusRunning {
+		return ErrNotFound
+	}
+
+	now := memoryNow()
+	job.job.Status = models.JobStatusFailed
+	job.job.FinishedAt = &now
+	job.job.ErrorCode = errorCode
+	job.job.ErrorMessage = errorMessage
+
+	success := false
+	attempt := m.finishAttempt(job, workerID, now)
+	attempt.Success = &success
+	attempt.ErrorCode = errorCode
+	attempt.ErrorMessage = errorMessage
+
+	m.appendEvent("job.failed", jobID, workerID, "Provider execution failed")
+	m.cancelDependants(jobID)
+	m.markGroupCompleted(jobID)
+	return nil
+}
+
+// finishAttempt returns the row of the job's current attempt with finished_at
+// set, inserting it when MarkJobRunning did not record one.
+func (m *MemoryStore) finishAttempt(job *memoryJob, workerID string, now time.Time) *models.JobAttempt {
+	attempts := m.attempts[job.job.ID]
+	for i := range attempts {
+		if attempts[i].Attempt == job.job.Attempt {
+			attempts[i].FinishedAt = &now
+			return &attempts[i]
+		}
+	}
+	m.attempts[job.job.ID] = append(attempts, models.JobAttempt{
+		JobID:      job.job.ID,
+		Attempt:    job.job.Attempt,
+		Model:      job.job.ModelUsed,
+		WorkerID:   workerID,
+		StartedAt:  &now,
+		FinishedAt: &now,
+	})
+	attempts = m.attempts[job.job.ID]
+	return &attempts[len(attempts)-1]
+}
+
+func (m *MemoryStore) UpsertWorkerHeartbeat(ctx context.Context, workerID string, state string, runningJobID string, concurrency int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.heartbeats[workerID] = MemoryHeartbeat{
+		State:        state,
+		RunningJobID: runningJobID,
+		Concurrency:  concurrency,
+		At:           memoryNow(),
+	}
+	return nil
+}
+
+func (m *MemoryStore) RecordEvent(ctx context.Context, eventType string, jobID string, workerID string, details string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.appendEvent(eventType, jobID, workerID, details)
+	return nil
+}
+
+func (m *MemoryStore) GetDependencyOutputs(ctx context.Context, jobID string) ([]models.DependencyOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	outputs := make([]models.DependencyOutput, 0)
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return outputs, nil
+	}
+	for _, parentID := range job.dependsOn {
+		if parent, ok := m.jobs[parentID]; ok {
+			outputs = append(outputs, models.DependencyOutput{
+				JobID:  parent.job.ID,
+				Model:  parent.job.Model,
+				Result: parent.job.ResultJSON,
+			})
+		}
+	}
+	return outputs, nil
+}
+
+func (m *MemoryStore) GetDependencyIDs(ctx context.Context, jobID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || len(job.dependsOn) == 0 {
+		return []string{}, nil
+	}
+	return append([]string(nil), job.dependsOn...), nil
+}
+
+// dependants returns the jobs that list parentID in depends_on.
+func (m *MemoryStore) dependants(parentID string) []*memoryJob {
+	children := make([]*memoryJob, 0)
+	for _, job := range m.jobs {
+		for _, dependsOn := range job.dependsOn {
+			if dependsOn == parentID {
+				children = append(children, job)
+				break
+			}
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].job.ID < children[j].job.ID })
+	return children
+}
+
+func (m *MemoryStore) releaseDependants(parentID string) {
+	now := memoryNow()
+	released := make([]string, 0)
+	for _, child := range m.dependants(parentID) {
+		if child.job.Status != models.JobStatusBlocked || m.hasUnsatisfiedDependency(child) {
+			continue
+		}
+		child.job.Status = models.JobStatusQueued
+		if child.job.RunAt != nil && child.job.RunAt.After(now) {
+			child.job.Status = models.JobStatusScheduled
+		}
+		m.appendEvent("job.unblocked", child.job.ID, "", "All parent jobs succeeded; last parent "+parentID)
+		if child.job.Status == models.JobStatusQueued {
+			released = append(released, child.job.ID)
+		}
+	}
+	m.enqueueOutbox(released, false)
+}
+
+func (m *MemoryStore) cancelDependants(parentID string) {
+	reason := "Parent job " + parentID + " failed permanently"
+
+	descendants := make([]*memoryJob, 0)
+	seen := map[string]bool{}
+	frontier := []string{parentID}
+	for len(frontier) > 0 {
+		next := make([]string, 0)
+		for _, id := range frontier {
+			for _, child := range m.dependants(id) {
+				if seen[child.job.ID] {
+					continue
+				}
+				seen[child.job.ID] = true
+				descendants = append(descendants, child)
+				next = append(next, child.job.ID)
+			}
+		}
+		frontier = next
+	}
+
+	now := memoryNow()
+	groupSet := make(map[string]struct{})
+	for _, job := range descendants {
+		if job.job.Status != models.JobStatusBlocked {
+			continue
+		}
+		job.job.Status = models.JobStatusCancelled
+		job.job.FinishedAt = &now
+		job.job.ErrorCode = dependencyFailedCode
+		job.job.ErrorMessage = reason
+		m.appendEvent("job.failed", job.job.ID, "", reason)
+		if job.job.GroupID != "" {
+			groupSet[job.job.GroupID] = struct{}{}
+		}
+	}
+	for groupID := range groupSet {
+		m.completeGroupIfDone(groupID)
+	}
+}
+
+// reblockDependants mirrors reblockDependantsTx: one generation at a time,
+// descendants cancelled with DEPENDENCY_FAILED whose parents are no longer
+// permanently failed go back to blocked.
+func (m *MemoryStore) reblockDependants(parentIDs []string) {
+	for frontier := parentIDs; len(frontier) > 0; {
+		next := make([]string, 0)
+		seen := make(map[string]bool)
+		for _, parentID := range frontier {
+			for _, child := range m.dependants(parentID) {
+				if seen[child.job.ID] || child.job.Status != models.JobStatusCancelled || child.job.ErrorCode != dependencyFailedCode || m.hasFailedParent(child) {
+					continue
+				}
+				seen[child.job.ID] = true
+				child.job.Status = models.JobStatusBlocked
+				child.job.FinishedAt = nil
+				child.job.ErrorCode = ""
+				child.job.ErrorMessage = ""
+				m.appendEvent("job.blocked", child.job.ID, "", "A failed parent job was retried; waiting for parent jobs again")
+				if child.job.GroupID != "" {
+					m.reopenGroup(child.job.GroupID)
+				}
+				next = append(next, child.job.ID)
+			}
+		}
+		frontier = next
+	}
+}
+
+func (m *MemoryStore) hasFailedParent(job *memoryJob) bool {
+	for _, parentID := range job.dependsOn {
+		if parent, ok := m.jobs[parentID]; ok && isPermanentFailure(parent.job.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryStore) CreateGroup(ctx context.Context, input models.CreateJobGroupInput) (models.JobGroup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group := &models.JobGroup{
+		ID:         uuid.NewString(),
+		TenantID:   input.TenantID,
+		Name:       input.Name,
+		WebhookURL: input.WebhookURL,
+		CreatedAt:  memoryNow(),
+	}
+	m.groups[group.ID] = group
+	m.appendEvent("group.created", "", "", "Job group "+group.ID+" created")
+	return *group, nil
+}
+
+func (m *MemoryStore) GetGroup(ctx context.Context, groupID string) (models.JobGroup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, ok := m.groups[groupID]
+	if !ok {
+		return models.JobGroup{}, ErrNotFound
+	}
+	return *group, nil
+}
+
+func (m *MemoryStore) GetGroupSummary(ctx context.Context, groupID string) (models.JobGroupSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, ok := m.groups[groupID]
+	if !ok {
+		return models.JobGroupSummary{}, ErrNotFound
+	}
+
+	summary := models.JobGroupSummary{
+		Group:  *group,
+		Counts: make(map[models.JobStatus]int),
+	}
+	for _, job := range m.groupJobs(groupID) {
+		summary.Counts[job.job.Status]++
+		summary.TotalJobs++
+		if job.job.Status.IsTerminal() {
+			summary.TerminalJobs++
+		}
+		for _, attempt := range m.attempts[job.job.ID] {
+			summary.TotalTokens += attempt.Tokens
+			summary.TotalCostUSD += attempt.CostUSD
+		}
+	}
+	if summary.TotalJobs > 0 {
+		summary.PercentComplete = float64(summary.TerminalJobs) * 100 / float64(summary.TotalJobs)
+	}
+	return summary, nil
+}
+
+func (m *MemoryStore) CancelGroup(ctx context.Context, groupID string, reason string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[groupID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	cancelled := make([]string, 0)
+	for _, job := range m.groupJobs(groupID) {
+		if !isCancellable(job.job.Status) {
+			continue
+		}
+		m.cancelJob(job, reason)
+		m.appendEvent("job.failed", job.job.ID, "", reason)
+		cancelled = append(cancelled, job.job.ID)
+	}
+	for _, jobID := range cancelled {
+		m.cancelDependants(jobID)
+	}
+	m.completeGroupIfDone(groupID)
+	return cancelled, nil
+}
+
+func (m *MemoryStore) RetryFailedGroup(ctx context.Context, groupID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[groupID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	retried := make([]*memoryJob, 0)
+	for _, job := range m.groupJobs(groupID) {
+		if job.job.Status == models.JobStatusFailed || job.job.Status == models.JobStatusDLQ {
+			retried = append(retried, job)
+		}
+	}
+	// Evaluate every dependency against the statuses before the update, as
+	// the single UPDATE statement does.
+	blocked := make(map[string]bool, len(retried))
+	for _, job := range retried {
+		blocked[job.job.ID] = m.hasUnsatisfiedDependency(job)
+	}
+
+	queued := make([]string, 0)
+	for _, job := range retried {
+		m.requeue(job, blocked[job.job.ID])
+		if job.job.Status == models.JobStatusQueued {
+			queued = append(queued, job.job.ID)
+		}
+		m.appendEvent("job.retry_scheduled", job.job.ID, "", "Group retry of failed jobs requested")
+	}
+	if len(retried) > 0 {
+		m.reopenGroup(groupID)
+		retriedIDs := make([]string, 0, len(retried))
+		for _, job := range retried {
+			retriedIDs = append(retriedIDs, job.job.ID)
+		}
+		m.reblockDependants(retriedIDs)
+	}
+	m.enqueueOutbox(queued, true)
+	return queued, nil
+}
+
+// groupJobs returns the jobs of a group in creation order.
+func (m *MemoryStore) groupJobs(groupID string) []*memoryJob {
+	jobs := make([]*memoryJob, 0)
+	for _, job := range m.jobs {
+		if job.job.GroupID == groupID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].job.CreatedAt.Equal(jobs[j].job.CreatedAt) {
+			return jobs[i].job.CreatedAt.Before(jobs[j].job.CreatedAt)
+		}
+		return jobs[i].job.ID < jobs[j].job.ID
+	})
+	return jobs
+}
+
+func (m *MemoryStore) reopenGroup(groupID string) {
+	if group, ok := m.groups[groupID]; ok && group.CompletedAt != nil {
+		group.CompletedAt = nil
+		group.WebhookDeliveredAt = nil
+	}
+}
+
+func (m *MemoryStore) markGroupCompleted(jobID string) {
+	if job, ok := m.jobs[jobID]; ok && job.job.GroupID != "" {
+		m.completeGroupIfDone(job.job.GroupID)
+	}
+}
+
+func (m *MemoryStore) completeGroupIfDone(groupID string) {
+	group, ok := m.groups[groupID]
+	if !ok || group.CompletedAt != nil {
+		return
+	}
+	jobs := m.groupJobs(groupID)
+	if len(jobs) == 0 {
+		return
+	}
+	for _, job := range jobs {
+		if !job.job.Status.IsTerminal() {
+			return
+		}
+	}
+	now := memoryNow()
+	group.CompletedAt = &now
+	m.appendEvent("group.completed", "", "", "All jobs in group "+groupID+" reached a terminal status")
+}
+
+func (m *MemoryStore) CreateSchedule(ctx context.Context, input models.CreateScheduleInput, nextFireAt time.Time) (models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := memoryNow()
+	schedule := &models.Schedule{
+		ID:            uuid.NewString(),
+		TenantID:      input.TenantID,
+		Name:          input.Name,
+		CronExpr:      input.CronExpr,
+		Timezone:      input.Timezone,
+		Model:         input.Model,
+		PayloadJSON:   input.PayloadJSON,
+		Priority:      input.Priority,
+		MaxAttempts:   input.MaxAttempts,
+		OverlapPolicy: input.OverlapPolicy,
+		NextFireAt:    &nextFireAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	m.schedules[schedule.ID] = schedule
+	m.appendEvent("schedule.created", "", "", "Schedule "+schedule.ID+" created with cron "+schedule.CronExpr)
+	return *schedule, nil
+}
+
+func (m *MemoryStore) GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedule, ok := m.schedules[scheduleID]
+	if !ok {
+		return models.Schedule{}, ErrNotFound
+	}
+	return *schedule, nil
+}
+
+func (m *MemoryStore) ListSchedules(ctx context.Context, tenantID string) ([]models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedules := make([]models.Schedule, 0)
+	for _, schedule := range m.schedules {
+		if tenantID == "" || schedule.TenantID == tenantID {
+			schedules = append(schedules, *schedule)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].CreatedAt.After(schedules[j].CreatedAt) })
+	return schedules, nil
+}
+
+func (m *MemoryStore) SetSchedulePaused(ctx context.Context, scheduleID string, paused bool, nextFireAt *time.Time) (models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedule, ok := m.schedules[scheduleID]
+	if !ok {
+		return models.Schedule{}, ErrNotFound
+	}
+	schedule.Paused = paused
+	if nextFireAt != nil {
+		next := *nextFireAt
+		schedule.NextFireAt = &next
+	}
+	schedule.UpdatedAt = memoryNow()
+
+	eventType := "schedule.resumed"
+	if paused {
+		eventType = "schedule.paused"
+	}
+	m.appendEvent(eventType, "", "", "Schedule "+scheduleID)
+	return *schedule, nil
+}
+
+func (m *MemoryStore) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.schedules[scheduleID]; !ok {
+		return ErrNotFound
+	}
+	delete(m.schedules, scheduleID)
+	m.appendEvent("schedule.deleted", "", "", "Schedule "+scheduleID+" deleted")
+	return nil
+}
+
+func (m *MemoryStore) UpsertRetentionPolicy(ctx context.Context, policy models.RetentionPolicy) (models.RetentionPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	policy.UpdatedAt = memoryNow()
+	m.retention[policy.TenantID] = policy
+	return policy, nil
+}
+
+func (m *MemoryStore) GetRetentionPolicy(ctx context.Context, tenantID string) (models.RetentionPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	policy, ok := m.retention[tenantID]
+	if !ok {
+		return models.RetentionPolicy{}, ErrNotFound
+	}
+	return policy, nil
+}
+
+func (m *MemoryStore) ListRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	policies := make([]models.RetentionPolicy, 0, len(m.retention))
+	for _, policy := range m.retention {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].TenantID < policies[j].TenantID })
+	return policies, nil
+}
+
+func (m *MemoryStore) DeleteRetentionPolicy(ctx context.Context, tenantID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.retention[tenantID]; !ok {
+		return ErrNotFound
+	}
+	delete(m.retention, tenantID)
+	return nil
+}
+
+func (m *MemoryStore) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insertAPIKey(key, keyHash)
+}
+
+func (m *MemoryStore) insertAPIKey(key models.APIKey, keyHash string) (models.APIKey, error) {
+	for _, existing := range m.apiKeys {
+		if existing.hash == keyHash {
+			return models.APIKey{}, fmt.Errorf("insert api key: duplicate key hash")
+		}
+	}
+	key.ID = uuid.NewString()
+	key.CreatedAt = memoryNow()
+	key.RevokedAt = nil
+	key.ReplacedBy = ""
+	m.apiKeys[key.ID] = &memoryAPIKey{key: key, hash: keyHash}
+	return key, nil
+}
+
+func (m *MemoryStore) GetAPIKey(ctx context.Context, keyID string) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.apiKeys[keyID]
+	if !ok {
+		return models.APIKey{}, ErrNotFound
+	}
+	return stored.key, nil
+}
+
+func (m *MemoryStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, stored := range m.apiKeys {
+		if stored.hash == keyHash {
+			return stored.key, nil
+		}
+	}
+	return models.APIKey{}, ErrNotFound
+}
+
+func (m *MemoryStore) ListAPIKeys(ctx context.Context, tenantID string) ([]models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]models.APIKey, 0)
+	for _, stored := range m.apiKeys {
+		if tenantID == "" || stored.key.TenantID == tenantID {
+			keys = append(keys, stored.key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID > keys[j].ID
+	})
+	return keys, nil
+}
+
+func (m *MemoryStore) RevokeAPIKey(ctx context.Context, keyID string) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.apiKeys[keyID]
+	if !ok {
+		return models.APIKey{}, ErrNotFound
+	}
+	if stored.key.RevokedAt == nil {
+		now := memoryNow()
+		stored.key.RevokedAt = &now
+	}
+	return stored.key, nil
+}
+
+func (m *MemoryStore) RotateAPIKey(ctx context.Context, keyID string, prefix string, keyHash string, oldExpiresAt time.Time) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.apiKeys[keyID]
+	if !ok {
+		return models.APIKey{}, ErrNotFound
+	}
+	if !old.key.Active(time.Now()) || old.key.ReplacedBy != "" {
+		return models.APIKey{}, ErrInvalidStateTransition
+	}
+
+	replacement, err := m.insertAPIKey(models.APIKey{
+		TenantID:  old.key.TenantID,
+		Name:      old.key.Name,
+		Role:      old.key.Role,
+		Prefix:    prefix,
+		ExpiresAt: old.key.ExpiresAt,
+	}, keyHash)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	old.key.ReplacedBy = replacement.ID
+	expiresAt := oldExpiresAt.UTC().Truncate(time.Microsecond)
+	if old.key.ExpiresAt == nil || expiresAt.Before(*old.key.ExpiresAt) {
+		old.key.ExpiresAt = &expiresAt
+	}
+	return replacement, nil
+}
+
+// InTx runs fn directly. MemoryStore has no rollback: writes made before fn
+// fails stay in place.
+func (m *MemoryStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MemoryStore) AppendAudit(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.ID = int64(len(m.audit)) + 1
+	entry.CreatedAt = memoryNow()
+	if len(entry.Details) == 0 {
+		entry.Details = json.RawMessage(`{}`)
+	}
+	m.audit = append(m.audit, entry)
+	return entry, nil
+}
+
+func (m *MemoryStore) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]models.AuditEntry, 0)
+	for i := len(m.audit) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		if auditMatches(m.audit[i], filter) {
+			entries = append(entries, m.audit[i])
+		}
+	}
+	return entries, nil
+}
+
+// auditMatches applies an AuditFilter in memory.
+func auditMatches(entry models.AuditEntry, filter models.AuditFilter) bool {
+	switch {
+	case filter.TenantID != "" && entry.TenantID != filter.TenantID,
+		filter.ActorID != "" && entry.Actor.ID != filter.ActorID,
+		filter.Action != "" && entry.Action != filter.Action,
+		filter.TargetType != "" && entry.TargetType != filter.TargetType,
+		filter.TargetID != "" && entry.TargetID != filter.TargetID,
+		filter.Since != nil && entry.CreatedAt.Before(*filter.Since),
+		filter.Until != nil && !entry.CreatedAt.Before(*filter.Until),
+		filter.BeforeID > 0 && entry.ID >= filter.BeforeID:
+		return false
+	}
+	return true
+}
+
+func (m *MemoryStore) CreateModelPrice(ctx context.Context, price models.ModelPrice) (models.ModelPrice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.prices {
+		if existing.Model == price.Model && existing.EffectiveFrom.Equal(price.EffectiveFrom) {
+			return models.ModelPrice{}, ErrPriceExists
+		}
+	}
+	price.CreatedAt = memoryNow()
+	m.prices = append(m.prices, price)
+	return price, nil
+}
+
+func (m *MemoryStore) ListModelPrices(ctx context.Context, model string) ([]models.ModelPrice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prices := make([]models.ModelPrice, 0, len(m.prices))
+	for _, price := range m.prices {
+		if model == "" || price.Model == model {
+			prices = append(prices, price)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if prices[i].Model != prices[j].Model {
+			return prices[i].Model < prices[j].Model
+		}
+		return prices[i].EffectiveFrom.After(prices[j].EffectiveFrom)
+	})
+	return prices, nil
+}
+
+func (m *MemoryStore) GetModelPrice(ctx context.Context, model string, at time.Time) (models.ModelPrice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var found *models.ModelPrice
+	for i, price := range m.prices {
+		if price.Model != model || price.EffectiveFrom.After(at) {
+			continue
+		}
+		if found == nil || price.EffectiveFrom.After(found.EffectiveFrom) {
+			found = &m.prices[i]
+		}
+	}
+	if found == nil {
+		return models.ModelPrice{}, ErrNotFound
+	}
+	return *found, nil
+}
+
+// UsageReport aggregates finished attempts directly; the memory store keeps
+// no rollup.
+func (m *MemoryStore) UsageReport(ctx context.Context, filter models.UsageFilter) ([]models.UsageRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := filter.From
+	to := filter.To.AddDate(0, 0, 1)
+	type usageKey struct{ tenantID, group string }
+	totals := map[usageKey]*models.UsageRow{}
+	for jobID, attempts := range m.attempts {
+		job := m.jobs[jobID]
+		if job == nil || (filter.TenantID != "" && job.job.TenantID != filter.TenantID) {
+			continue
+		}
+		for _, attempt := range attempts {
+			if attempt.FinishedAt == nil || attempt.FinishedAt.Before(from) || !attempt.FinishedAt.Before(to) {
+				continue
+			}
+			key := usageKey{tenantID: job.job.TenantID, group: attempt.FinishedAt.UTC().Format("2006-01-02")}
+			if filter.GroupBy == models.UsageGroupByModel {
+				key.group = attempt.Model
+			}
+			row := totals[key]
+			if row == nil {
+				row = &models.UsageRow{TenantID: key.tenantID}
+				if filter.GroupBy == models.UsageGroupByModel {
+					row.Model = key.group
+				} else {
+					row.Day = key.group
+				}
+				totals[key] = row
+			}
+			amounts := models.UsageAmounts{
+				Attempts:           1,
+				PromptTokens:       attempt.PromptTokens,
+				CachedPromptTokens: attempt.CachedPromptTokens,
+				CompletionTokens:   attempt.CompletionTokens,
+				Tokens:             attempt.Tokens,
+				CostUSD:            attempt.CostUSD,
+			}
+			if attempt.Success == nil || !*attempt.Success {
+				amounts.FailedAttempts = 1
+				amounts.FailedCostUSD = attempt.CostUSD
+			}
+			row.Add(amounts)
+		}
+	}
+
+	rows := make([]models.UsageRow, 0, len(totals))
+	for _, row := range totals {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].TenantID != rows[j].TenantID {
+			return rows[i].TenantID < rows[j].TenantID
+		}
+		return rows[i].Day+rows[i].Model < rows[j].Day+rows[j].Model
+	})
+	return rows, nil
+}
+
+func (m *MemoryStore) GetSpendStatus(ctx context.Context, tenantID string) (models.SpendStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.spendStatus(tenantID, memoryNow()), nil
+}
+
+func (m *MemoryStore) ListSpendStatuses(ctx context.Context) ([]models.SpendStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := memoryNow()
+	month := now.Format("2006-01")
+	tenantIDs := make([]string, 0, len(m.spendCaps)+len(m.spend))
+	for tenantID := range m.spendCaps {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	for tenantID, spend := range m.spend {
+		if _, ok := m.spendCaps[tenantID]; !ok && spend.month == month {
+			tenantIDs = append(tenantIDs, tenantID)
+		}
+	}
+	sort.Strings(tenantIDs)
+
+	statuses := make([]models.SpendStatus, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		statuses = append(statuses, m.spendStatus(tenantID, now))
+	}
+	return statuses, nil
+}
+
+func (m *MemoryStore) SetSpendCap(ctx context.Context, tenantID string, capUSD *float64) (models.SpendCap, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	spendCap := m.spendCaps[tenantID]
+	spendCap.MonthlyCapUSD = capUSD
+	return m.saveSpendCap(tenantID, spendCap), nil
+}
+
+func (m *MemoryStore) SetSpendCapOverride(ctx context.Context, tenantID string, capUSD *float64, until *time.Time) (models.SpendCap, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	spendCap := m.spendCaps[tenantID]
+	spendCap.OverrideCapUSD = capUSD
+	spendCap.OverrideUntil = until
+	return m.saveSpendCap(tenantID, spendCap), nil
+}
+
+// saveSpendCap stores spendCap and re-arms this month's alerts.
+func (m *MemoryStore) saveSpendCap(tenantID string, spendCap models.SpendCap) models.SpendCap {
+	now := memoryNow()
+	spendCap.TenantID = tenantID
+	spendCap.UpdatedAt = &now
+	m.spendCaps[tenantID] = spendCap
+	if spend := m.spend[tenantID]; spend != nil {
+		spend.softAlertedAt = nil
+		spend.capReachedAt = nil
+	}
+	return spendCap
+}
+
+func (m *MemoryStore) spendStatus(tenantID string, now time.Time) models.SpendStatus {
+	spendCap, ok := m.spendCaps[tenantID]
+	if !ok {
+		spendCap = models.SpendCap{TenantID: tenantID}
+	}
+	spend := m.spend[tenantID]
+	if spend == nil || spend.month != now.Format("2006-01") {
+		return newSpendStatus(spendCap, 0, nil, nil, now)
+	}
+	return newSpendStatus(spendCap, spend.spentUSD, spend.softAlertedAt, spend.capReachedAt, now)
+}
+
+func (m *MemoryStore) recordSpend(tenantID string, jobID string, workerID string, costUSD float64, now time.Time) {
+	if costUSD <= 0 {
+		return
+	}
+	month := now.Format("2006-01")
+	spend := m.spend[tenantID]
+	if spend == nil || spend.month != month {
+		spend = &memorySpend{month: month}
+		m.spend[tenantID] = spend
+	}
+	spend.spentUSD += costUSD
+
+	status := m.spendStatus(tenantID, now)
+	eventType, capReached := spendAlert(status)
+	if eventType == "" {
+		return
+	}
+	if capReached {
+		spend.capReachedAt = &now
+	}
+	if spend.softAlertedAt == nil {
+		spend.softAlertedAt = &now
+	}
+	m.appendEvent(eventType, jobID, workerID, spendAlertDetails(status))
+}
+
+func (m *MemoryStore) DeferJob(ctx context.Context, jobID string, workerID string, runAt time.Time, errorCode string, errorMessage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.job.Status != models.JobStatusRunning {
+		return ErrNotFound
+	}
+
+	attempts := m.attempts[jobID]
+	kept := attempts[:0]
+	var previousStart *time.Time
+	previousModel := ""
+	for _, attempt := range attempts {
+		switch attempt.Attempt {
+		case job.job.Attempt:
+			continue
+		case job.job.Attempt - 1:
+			previousStart = attempt.StartedAt
+			previousModel = attempt.Model
+		}
+		kept = append(kept, attempt)
+	}
+	m.attempts[jobID] = kept
+
+	job.job.Status = models.JobStatusScheduled
+	job.job.RunAt = &runAt
+	job.job.Attempt--
+	job.job.StartedAt = previousStart
+	job.job.ModelUsed = previousModel
+	job.job.ErrorCode = errorCode
+	job.job.ErrorMessage = errorMessage
+	m.appendEvent("job.held", jobID, workerID, errorMessage+"; retrying at "+runAt.UTC().Format(time.RFC3339))
+	return nil
+}
+
+func (m *MemoryStore) UpsertFallbackPolicy(ctx context.Context, policy models.FallbackPolicy) (models.FallbackPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := memoryNow()
+	policy.UpdatedAt = &now
+	m.fallbacks[fallbackKey{tenantID: policy.TenantID, model: policy.Model}] = policy
+	return policy, nil
+}
+
+func (m *MemoryStore) GetFallbackPolicy(ctx context.Context, tenantID string, model string) (models.FallbackPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	policy, ok := m.fallbacks[fallbackKey{tenantID: tenantID, model: model}]
+	if !ok {
+		return models.FallbackPolicy{}, ErrNotFound
+	}
+	return policy, nil
+}
+
+func (m *MemoryStore) ListFallbackPolicies(ctx context.Context, tenantID string) ([]models.FallbackPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	policies := make([]models.FallbackPolicy, 0, len(m.fallbacks))
+	for _, policy := range m.fallbacks {
+		if tenantID == "" || policy.TenantID == tenantID {
+			policies = append(policies, policy)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].TenantID != policies[j].TenantID {
+			return policies[i].TenantID < policies[j].TenantID
+		}
+		return policies[i].Model < policies[j].Model
+	})
+	return policies, nil
+}
+
+func (m *MemoryStore) DeleteFallbackPolicy(ctx context.Context, tenantID string, model string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := fallbackKey{tenantID: tenantID, model: model}
+	if _, ok := m.fallbacks[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.fallbacks, key)
+	return nil
+}
+
+func (m *MemoryStore) FallBackJob(ctx context.Context, jobID string, workerID string, errorCode string, errorMessage string, model string) (models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.job.Status != models.JobStatusRunning {
+		return models.Job{}, ErrNotFound
+	}
+
+	now := memoryNow()
+	success := false
+	attempt := m.finishAttempt(job, workerID, now)
+	attempt.Success = &success
+	attempt.ErrorCode = errorCode
+	attempt.ErrorMessage = errorMessage
+
+	previous := job.job.ModelUsed
+	job.job.Attempt++
+	job.job.StartedAt = &now
+	job.job.ModelUsed = model
+	m.startAttempt(job, workerID, now)
+
+	m.appendEvent("job.fallback", jobID, workerID, fmt.Sprintf("%s on %s; falling back to %s", errorCode, previous, model))
+	return job.job, nil
+}
+
+func (m *MemoryStore) enqueueOutbox(jobIDs []string, dedupe bool) {
+	now := memoryNow()
+	for _, jobID := range jobIDs {
+		// Jobs are enqueued as they become runnable, which the jobs_queued_at
+		// trigger records in Postgres.
+		if job, ok := m.jobs[jobID]; ok {
+			queuedAt := now
+			job.job.QueuedAt = &queuedAt
+		}
+		m.outboxSeq++
+		m.outbox = append(m.outbox, models.OutboxEntry{
+			ID:        m.outboxSeq,
+			JobID:     jobID,
+			Dedupe:    dedupe,
+			CreatedAt: now,
+		})
+	}
+}
+
+// RelayOutbox claims up to limit entries, publishes them without holding the
+// store lock and puts them back in front of the outbox when publish fails.
+func (m *MemoryStore) RelayOutbox(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, entries []models.OutboxEntry) error,
+) (int, error) {
+	m.mu.Lock()
+	count := len(m.outbox)
+	if count > limit {
+		count = limit
+	}
+	entries := append([]models.OutboxEntry(nil), m.outbox[:count]...)
+	m.outbox = m.outbox[count:]
+	m.mu.Unlock()
+
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	if err := publish(ctx, entries); err != nil {
+		m.mu.Lock()
+		m.outbox = append(entries, m.outbox...)
+		m.mu.Unlock()
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+func (m *MemoryStore) appendEvent(eventType string, jobID string, workerID string, details string) {
+	m.events = append(m.events, MemoryEvent{
+		Type:      eventType,
+		JobID:     jobID,
+		WorkerID:  workerID,
+		Details:   details,
+		CreatedAt: memoryNow(),
+	})
+}
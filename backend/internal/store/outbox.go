@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// enqueueOutboxTx records that jobIDs must be pushed to the ready queue. It
+// runs in the transaction that makes the jobs queued, so a committed queued
+// job always has a pending publish. dedupe asks the relay to drop stale ready
+// queue entries for the job before pushing it again.
+func enqueueOutboxTx(ctx context.Context, tx pgx.Tx, jobIDs []string, dedupe bool) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO queue_outbox (job_id, dedupe, created_at)
+		 SELECT id, $2, now()
+		 FROM unnest($1::text[]) WITH ORDINALITY AS entry(id, position)
+		 ORDER BY position`,
+		jobIDs,
+		dedupe,
+	); err != nil {
+		return fmt.Errorf("insert queue outbox entries: %w", err)
+	}
+	return nil
+}
+
+// RelayOutbox claims up to limit pending outbox entries in insertion order,
+// hands them to publish and deletes them once publish succeeded. Entries stay
+// pending when publish fails, so delivery is at-least-once; relays running in
+// several processes claim disjoint entries. It returns the number relayed.
+func (s *PostgresStore) RelayOutbox(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, entries []models.OutboxEntry) error,
+) (int, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id, job_id, dedupe, created_at
+		 FROM queue_outbox
+		 ORDER BY id
+		 LIMIT $1
+		 FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("claim outbox query: %w", err)
+	}
+	entries := make([]models.OutboxEntry, 0, limit)
+	for rows.Next() {
+		var entry models.OutboxEntry
+		if err := rows.Scan(&entry.ID, &entry.JobID, &entry.Dedupe, &entry.CreatedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("claim outbox scan: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("claim outbox rows: %w", err)
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(ctx, entries); err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM queue_outbox WHERE id = ANY($1)`, ids); err != nil {
+		return 0, fmt.Errorf("delete relayed outbox entries: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit relay outbox: %w", err)
+	}
+	return len(entries), nil
+}
@@ -6,17 +6,84 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"job-queue-llm-orchestrator/backend/internal/models"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var ErrNotFound = errors.New("not found")
 var ErrInvalidStateTransition = errors.New("invalid state transition")
 
+const jobColumns = `id, tenant_id, status, priority, model, COALESCE(model_used, ''), fallback_models, fallback_on, payload_json, COALESCE(idempotency_key, ''), attempt, max_attempts, created_at, started_at, finished_at, COALESCE(error_code, ''), COALESCE(error_message, ''), trace_id, COALESCE(traceparent, ''), COALESCE(tracestate, ''), COALESCE(group_id, ''), result_json, run_at, deadline, queued_at, idempotency_expires_at`
+
+// jobScanTargets returns the scan destinations matching jobColumns.
+func jobScanTargets(job *models.Job) []any {
+	return []any{
+		&job.ID,
+		&job.TenantID,
+		&job.Status,
+		&job.Priority,
+		&job.Model,
+		&job.ModelUsed,
+		&job.FallbackModels,
+		&job.FallbackOn,
+		&job.PayloadJSON,
+		&job.IdempotencyKey,
+		&job.Attempt,
+		&job.MaxAttempts,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.ErrorCode,
+		&job.ErrorMessage,
+		&job.TraceID,
+		&job.Traceparent,
+		&job.Tracestate,
+		&job.GroupID,
+		&job.ResultJSON,
+		&job.RunAt,
+		&job.Deadline,
+		&job.QueuedAt,
+		&job.IdempotencyExpiresAt,
+	}
+}
+
+const attemptColumns = `job_id, attempt, COALESCE(model, ''), COALESCE(worker_id, ''), started_at, finished_at, success, COALESCE(error_code, ''), COALESCE(error_message, ''), COALESCE(tokens, 0), prompt_tokens, cached_prompt_tokens, completion_tokens, COALESCE(cost_usd, 0), provider_meta_json`
+
+// attemptScanTargets returns the scan destinations matching attemptColumns.
+func attemptScanTargets(attempt *models.JobAttempt) []any {
+	return []any{
+		&attempt.JobID,
+		&attempt.Attempt,
+		&attempt.Model,
+		&attempt.WorkerID,
+		&attempt.StartedAt,
+		&attempt.FinishedAt,
+		&attempt.Success,
+		&attempt.ErrorCode,
+		&attempt.ErrorMessage,
+		&attempt.Tokens,
+		&attempt.PromptTokens,
+		&attempt.CachedPromptTokens,
+		&attempt.CompletionTokens,
+		&attempt.CostUSD,
+		&attempt.ProviderMeta,
+	}
+}
+
+func attemptDurationMS(attempt models.JobAttempt) *int64 {
+	if attempt.StartedAt == nil || attempt.FinishedAt == nil {
+		return nil
+	}
+	duration := attempt.FinishedAt.Sub(*attempt.StartedAt).Milliseconds()
+	return &duration
+}
+
 type PostgresStore struct {
 	pool *pgxpool.Pool
 }
@@ -38,35 +105,126 @@ func (s *PostgresStore) Ping(ctx context.Context) error {
 	return s.pool.Ping(ctx)
 }
 
-func (s *PostgresStore) CreateJob(ctx context.Context, input models.CreateJobInput) (models.Job, bool, error) {
-	job := models.Job{}
+// querier is what store methods run statements on: the pool, or the
+// transaction opened by InTx. Begin on a transaction opens a savepoint, so a
+// method's own transaction nests inside InTx.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
 
-	if input.Priority <= 0 {
-		input.Priority = 3
+type txKey struct{}
+
+func (s *PostgresStore) db(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
 	}
-	if input.MaxAttempts <= 0 {
-		input.MaxAttempts = 3
+	return s.pool
+}
+
+// InTx runs fn in one transaction. Store calls made with the context passed to
+// fn join it, and nothing is committed unless fn returns nil.
+func (s *PostgresStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
 	}
-	if len(input.PayloadJSON) == 0 {
-		input.PayloadJSON = json.RawMessage(`{}`)
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) CreateJob(ctx context.Context, input models.CreateJobInput) (models.Job, bool, error) {
+	job := models.Job{}
+
+	input = normalizeCreateJobInput(input)
 
 	jobID := uuid.NewString()
-	traceID := uuid.NewString()
-	var idempotency any
+	var idempotency, fingerprint any
+	var requestHash string
 	if input.IdempotencyKey != "" {
 		idempotency = input.IdempotencyKey
+		hash, err := requestFingerprint(input)
+		if err != nil {
+			return models.Job{}, false, err
+		}
+		requestHash = hash
+		fingerprint = hash
+	}
+
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return models.Job{}, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if input.IdempotencyKey != "" {
+		if err := releaseExpiredIdempotencyKeysTx(ctx, tx, []string{input.TenantID}, []string{input.IdempotencyKey}); err != nil {
+			return models.Job{}, false, err
+		}
+	}
+
+	status := models.JobStatusQueued
+	var errorCode, errorMessage string
+	if len(input.DependsOn) > 0 {
+		resolution, err := resolveDependenciesTx(ctx, tx, input.TenantID, input.DependsOn)
+		if err != nil {
+			return models.Job{}, false, err
+		}
+		status = resolution.status
+		if resolution.failedParentID != "" {
+			errorCode = dependencyFailedCode
+			errorMessage = "Parent job " + resolution.failedParentID + " failed permanently"
+		}
+	}
+	if status == models.JobStatusQueued && isFutureRunAt(input.RunAt) {
+		status = models.JobStatusScheduled
+	}
+
+	if input.IdempotencyKey != "" {
+		claimed, err := claimIdempotencyKeysTx(ctx, tx, []idempotencyClaim{{
+			tenantID:    input.TenantID,
+			key:         input.IdempotencyKey,
+			jobID:       jobID,
+			fingerprint: requestHash,
+			expiresAt:   input.IdempotencyExpiresAt,
+		}})
+		if err != nil {
+			return models.Job{}, false, err
+		}
+		if !claimed[jobID] {
+			existing, storedHash, err := getJobByTenantAndIdempotencyTx(ctx, tx, input.TenantID, input.IdempotencyKey)
+			if err != nil {
+				return models.Job{}, false, err
+			}
+			if !fingerprintsMatch(storedHash, requestHash) {
+				return existing, true, ErrIdempotencyMismatch
+			}
+			return existing, true, nil
+		}
 	}
 
 	query := `
 INSERT INTO jobs (
-	id, tenant_id, status, priority, model, payload_json, idempotency_key, attempt, max_attempts, created_at, trace_id
+	id, tenant_id, status, priority, model, payload_json, idempotency_key, attempt, max_attempts, created_at, trace_id, traceparent, tracestate, group_id,
+	finished_at, error_code, error_message, run_at, deadline, idempotency_fingerprint, idempotency_expires_at, fallback_models, fallback_on
+)
+VALUES (
+	$1, $2, $12, $3, $4, $5, $6, 0, $7, now(), $8, NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''),
+	CASE WHEN $12 = 'cancelled' THEN now() END, NULLIF($13, ''), NULLIF($14, ''), $15, $16, $17, $18,
+	COALESCE($19::text[], '{}'), COALESCE($20::text[], '{}')
 )
-VALUES ($1, $2, 'queued', $3, $4, $5, $6, 0, $7, now(), $8)
-ON CONFLICT (tenant_id, idempotency_key) DO NOTHING
-RETURNING id, tenant_id, status, priority, model, payload_json, COALESCE(idempotency_key, ''), attempt, max_attempts, created_at, started_at, finished_at, COALESCE(error_code, ''), COALESCE(error_message, ''), trace_id
+RETURNING ` + jobColumns + `
 `
-	err := s.pool.QueryRow(
+	err = tx.QueryRow(
 		ctx,
 		query,
 		jobID,
@@ -76,44 +234,312 @@ RETURNING id, tenant_id, status, priority, model, payload_json, COALESCE(idempot
 		[]byte(input.PayloadJSON),
 		idempotency,
 		input.MaxAttempts,
-		traceID,
-	).Scan(
-		&job.ID,
-		&job.TenantID,
-		&job.Status,
-		&job.Priority,
-		&job.Model,
-		&job.PayloadJSON,
-		&job.IdempotencyKey,
-		&job.Attempt,
-		&job.MaxAttempts,
-		&job.CreatedAt,
-		&job.StartedAt,
-		&job.FinishedAt,
-		&job.ErrorCode,
-		&job.ErrorMessage,
-		&job.TraceID,
-	)
-	if err == nil {
-		if err := s.appendEvent(ctx, "job.created", &job.ID, nil, "Job accepted via POST /v1/jobs"); err != nil {
+		input.TraceID,
+		input.Traceparent,
+		input.Tracestate,
+		input.GroupID,
+		string(status),
+		errorCode,
+		errorMessage,
+		input.RunAt,
+		input.Deadline,
+		fingerprint,
+		input.IdempotencyExpiresAt,
+		input.FallbackModels,
+		input.FallbackOn,
+	).Scan(jobScanTargets(&job)...)
+	if err != nil {
+		return models.Job{}, false, fmt.Errorf("insert job: %w", err)
+	}
+
+	if len(input.DependsOn) > 0 {
+		if err := insertDependenciesTx(ctx, tx, job.ID, input.DependsOn); err != nil {
+			return models.Job{}, false, err
+		}
+	}
+	if job.GroupID != "" {
+		if err := reopenGroupTx(ctx, tx, job.GroupID); err != nil {
+			return models.Job{}, false, err
+		}
+	}
+	if err := appendEventTx(ctx, tx, "job.created", &job.ID, nil, "Job accepted via POST /v1/jobs"); err != nil {
+		return models.Job{}, false, err
+	}
+	switch job.Status {
+	case models.JobStatusQueued:
+		if err := enqueueOutboxTx(ctx, tx, []string{job.ID}, false); err != nil {
+			return models.Job{}, false, err
+		}
+	case models.JobStatusBlocked:
+		if err := appendEventTx(ctx, tx, "job.blocked", &job.ID, nil, "Waiting for parent jobs to succeed"); err != nil {
+			return models.Job{}, false, err
+		}
+	case models.JobStatusScheduled:
+		if err := appendEventTx(ctx, tx, "job.scheduled", &job.ID, nil, "Scheduled to run at "+job.RunAt.UTC().Format(time.RFC3339)); err != nil {
+			return models.Job{}, false, err
+		}
+	case models.JobStatusCancelled:
+		if err := appendEventTx(ctx, tx, "job.failed", &job.ID, nil, job.ErrorMessage); err != nil {
+			return models.Job{}, false, err
+		}
+		if err := markGroupCompletedTx(ctx, tx, job.ID); err != nil {
 			return models.Job{}, false, err
 		}
-		return job, false, nil
 	}
 
-	if !errors.Is(err, pgx.ErrNoRows) {
-		return models.Job{}, false, fmt.Errorf("insert job: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return models.Job{}, false, fmt.Errorf("commit create job: %w", err)
 	}
+	return job, false, nil
+}
 
-	if input.IdempotencyKey == "" {
-		return models.Job{}, false, fmt.Errorf("job conflict without idempotency key: %w", err)
+// batchInsertChunk keeps multi-row inserts well under the 65535 bind
+// parameter limit of the Postgres wire protocol.
+const batchInsertChunk = 500
+
+// CreateJobsBatch inserts all inputs in one transaction. Results are returned
+// in input order; inputs whose idempotency key already exists (in the table or
+// earlier in the same batch) resolve to the existing job with Existing set,
+// and with Mismatch set as well when the requests differ. With atomic set, any
+// mismatch rolls the batch back and ErrIdempotencyMismatch is returned along
+// with the results that identify the offending inputs.
+func (s *PostgresStore) CreateJobsBatch(ctx context.Context, inputs []models.CreateJobInput, atomic bool) ([]models.CreateJobResult, error) {
+	results := make([]models.CreateJobResult, len(inputs))
+	if len(inputs) == 0 {
+		return results, nil
 	}
 
-	existing, err := s.getJobByTenantAndIdempotency(ctx, input.TenantID, input.IdempotencyKey)
+	tx, err := s.db(ctx).Begin(ctx)
 	if err != nil {
-		return models.Job{}, false, err
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	type pendingInsert struct {
+		index       int
+		id          string
+		input       models.CreateJobInput
+		fingerprint string
+	}
+
+	pending := make([]pendingInsert, 0, len(inputs))
+	fingerprints := make([]string, len(inputs))
+	firstByKey := make(map[string]int, len(inputs))
+	duplicateOf := make(map[int]int)
+	keyTenants := make([]string, 0, len(inputs))
+	keys := make([]string, 0, len(inputs))
+	for index, input := range inputs {
+		input = normalizeCreateJobInput(input)
+		if input.IdempotencyKey != "" {
+			hash, err := requestFingerprint(input)
+			if err != nil {
+				return nil, err
+			}
+			fingerprints[index] = hash
+			dedupKey := input.TenantID + "\x00" + input.IdempotencyKey
+			if first, ok := firstByKey[dedupKey]; ok {
+				duplicateOf[index] = first
+				continue
+			}
+			firstByKey[dedupKey] = index
+			keyTenants = append(keyTenants, input.TenantID)
+			keys = append(keys, input.IdempotencyKey)
+		}
+		pending = append(pending, pendingInsert{index: index, id: uuid.NewString(), input: input, fingerprint: fingerprints[index]})
+	}
+
+	var replays []pendingInsert
+	inserts := pending
+	if len(keys) > 0 {
+		if err := releaseExpiredIdempotencyKeysTx(ctx, tx, keyTenants, keys); err != nil {
+			return nil, err
+		}
+
+		claims := make([]idempotencyClaim, 0, len(keys))
+		for _, item := range pending {
+			if item.input.IdempotencyKey != "" {
+				claims = append(claims, idempotencyClaim{
+					tenantID:    item.input.TenantID,
+					key:         item.input.IdempotencyKey,
+					jobID:       item.id,
+					fingerprint: item.fingerprint,
+					expiresAt:   item.input.IdempotencyExpiresAt,
+				})
+			}
+		}
+		claimed, err := claimIdempotencyKeysTx(ctx, tx, claims)
+		if err != nil {
+			return nil, err
+		}
+		inserts = make([]pendingInsert, 0, len(pending))
+		for _, item := range pending {
+			if item.input.IdempotencyKey != "" && !claimed[item.id] {
+				replays = append(replays, item)
+				continue
+			}
+			inserts = append(inserts, item)
+		}
+	}
+
+	insertedByID := make(map[string]models.Job, len(inserts))
+	for start := 0; start < len(inserts); start += batchInsertChunk {
+		end := start + batchInsertChunk
+		if end > len(inserts) {
+			end = len(inserts)
+		}
+
+		values := make([]string, 0, end-start)
+		args := make([]any, 0, (end-start)*16)
+		for _, item := range inserts[start:end] {
+			var idempotency, fingerprint any
+			if item.input.IdempotencyKey != "" {
+				idempotency = item.input.IdempotencyKey
+				fingerprint = item.fingerprint
+			}
+			status := models.JobStatusQueued
+			if isFutureRunAt(item.input.RunAt) {
+				status = models.JobStatusScheduled
+			}
+			base := len(args)
+			values = append(values, fmt.Sprintf(
+				"($%d, $%d, $%d, $%d, $%d, $%d, $%d, 0, $%d, now(), $%d, NULLIF($%d, ''), NULLIF($%d, ''), NULLIF($%d, ''), $%d::timestamptz, $%d::timestamptz, $%d::text, $%d::timestamptz, COALESCE($%d::text[], '{}'), COALESCE($%d::text[], '{}'))",
+				base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11, base+12, base+13, base+14, base+15, base+16, base+17, base+18,
+			))
+			args = append(args,
+				item.id,
+				item.input.TenantID,
+				string(status),
+				item.input.Priority,
+				item.input.Model,
+				[]byte(item.input.PayloadJSON),
+				idempotency,
+				item.input.MaxAttempts,
+				item.input.TraceID,
+				item.input.Traceparent,
+				item.input.Tracestate,
+				item.input.GroupID,
+				item.input.RunAt,
+				item.input.Deadline,
+				fingerprint,
+				item.input.IdempotencyExpiresAt,
+				item.input.FallbackModels,
+				item.input.FallbackOn,
+			)
+		}
+
+		rows, err := tx.Query(
+			ctx,
+			`INSERT INTO jobs (
+				id, tenant_id, status, priority, model, payload_json, idempotency_key, attempt, max_attempts, created_at, trace_id, traceparent, tracestate, group_id, run_at, deadline,
+				idempotency_fingerprint, idempotency_expires_at, fallback_models, fallback_on
+			)
+			VALUES `+strings.Join(values, ", ")+`
+			RETURNING `+jobColumns,
+			args...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("batch insert jobs: %w", err)
+		}
+		for rows.Next() {
+			var job models.Job
+			if err := rows.Scan(jobScanTargets(&job)...); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("batch insert jobs scan: %w", err)
+			}
+			insertedByID[job.ID] = job
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("batch insert jobs rows: %w", err)
+		}
+	}
+
+	createdIDs := make([]string, 0, len(insertedByID))
+	queuedIDs := make([]string, 0, len(insertedByID))
+	createdGroups := make(map[string]struct{})
+	for _, item := range inserts {
+		job := insertedByID[item.id]
+		results[item.index] = models.CreateJobResult{Job: job}
+		createdIDs = append(createdIDs, job.ID)
+		if job.Status == models.JobStatusQueued {
+			queuedIDs = append(queuedIDs, job.ID)
+		}
+		if job.GroupID != "" {
+			createdGroups[job.GroupID] = struct{}{}
+		}
+	}
+	for _, item := range replays {
+		existing, storedHash, err := getJobByTenantAndIdempotencyTx(ctx, tx, item.input.TenantID, item.input.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		results[item.index] = models.CreateJobResult{
+			Job:      existing,
+			Existing: true,
+			Mismatch: !fingerprintsMatch(storedHash, item.fingerprint),
+		}
+	}
+	for index, first := range duplicateOf {
+		results[index] = models.CreateJobResult{
+			Job:      results[first].Job,
+			Existing: true,
+			Mismatch: results[first].Mismatch || fingerprints[index] != fingerprints[first],
+		}
+	}
+
+	if atomic {
+		for _, result := range results {
+			if result.Mismatch {
+				return results, ErrIdempotencyMismatch
+			}
+		}
+	}
+
+	if len(createdIDs) > 0 {
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
+			 SELECT 'job.created', id, NULL, 'Job accepted via POST /v1/jobs/batch', now()
+			 FROM unnest($1::text[]) AS id`,
+			createdIDs,
+		); err != nil {
+			return nil, fmt.Errorf("insert batch created events: %w", err)
+		}
+	}
+	if err := enqueueOutboxTx(ctx, tx, queuedIDs, false); err != nil {
+		return nil, err
+	}
+	for groupID := range createdGroups {
+		if err := reopenGroupTx(ctx, tx, groupID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit batch create: %w", err)
+	}
+
+	return results, nil
+}
+
+func isFutureRunAt(runAt *time.Time) bool {
+	return runAt != nil && runAt.After(time.Now())
+}
+
+func normalizeCreateJobInput(input models.CreateJobInput) models.CreateJobInput {
+	if input.Priority <= 0 {
+		input.Priority = 3
+	}
+	if input.MaxAttempts <= 0 {
+		input.MaxAttempts = 3
+	}
+	if len(input.PayloadJSON) == 0 {
+		input.PayloadJSON = json.RawMessage(`{}`)
 	}
-	return existing, true, nil
+	if input.TraceID == "" {
+		input.TraceID = uuid.NewString()
+	}
+	return input
 }
 
 func (s *PostgresStore) GetJobByID(ctx context.Context, jobID string) (models.Job, *models.JobAttempt, error) {
@@ -133,13 +559,67 @@ func (s *PostgresStore) GetJobByID(ctx context.Context, jobID string) (models.Jo
 	return job, &attempt, nil
 }
 
-func (s *PostgresStore) ListJobs(
-	ctx context.Context,
-	status string,
-	tenantID string,
-	model string,
-	limit int,
-) ([]models.Job, error) {
+func (s *PostgresStore) ListJobAttempts(ctx context.Context, jobID string) (models.JobAttemptHistory, error) {
+	job, err := s.getJobByID(ctx, jobID)
+	if err != nil {
+		return models.JobAttemptHistory{}, err
+	}
+
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT `+attemptColumns+`
+		 FROM job_attempts
+		 WHERE job_id = $1
+		 ORDER BY attempt ASC`,
+		jobID,
+	)
+	if err != nil {
+		return models.JobAttemptHistory{}, fmt.Errorf("list job attempts query: %w", err)
+	}
+	defer rows.Close()
+
+	history := models.JobAttemptHistory{
+		JobID:    job.ID,
+		Attempts: make([]models.JobAttempt, 0, job.Attempt),
+	}
+	for rows.Next() {
+		var attempt models.JobAttempt
+		if err := rows.Scan(attemptScanTargets(&attempt)...); err != nil {
+			return models.JobAttemptHistory{}, fmt.Errorf("list job attempts scan: %w", err)
+		}
+		attempt.DurationMS = attemptDurationMS(attempt)
+		history.Attempts = append(history.Attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return models.JobAttemptHistory{}, fmt.Errorf("list job attempts rows: %w", err)
+	}
+
+	history.Totals = summarizeAttempts(history.Attempts)
+	return history, nil
+}
+
+func summarizeAttempts(attempts []models.JobAttempt) models.JobAttemptTotals {
+	totals := models.JobAttemptTotals{Attempts: len(attempts)}
+	for _, attempt := range attempts {
+		switch {
+		case attempt.Success == nil:
+			totals.InFlight++
+		case *attempt.Success:
+			totals.Succeeded++
+		default:
+			totals.Failed++
+		}
+		totals.Tokens += attempt.Tokens
+		totals.CostUSD += attempt.CostUSD
+		if attempt.DurationMS != nil {
+			totals.DurationMS += *attempt.DurationMS
+		}
+	}
+	return totals
+}
+
+func (s *PostgresStore) ListJobs(ctx context.Context, filter models.ListJobsFilter) (models.JobPage, error) {
+	limit := filter.Limit
 	if limit <= 0 {
 		limit = 100
 	}
@@ -147,70 +627,134 @@ func (s *PostgresStore) ListJobs(
 		limit = 500
 	}
 
-	baseQuery := `SELECT id, tenant_id, status, priority, model, payload_json, COALESCE(idempotency_key, ''), attempt, max_attempts, created_at, started_at, finished_at, COALESCE(error_code, ''), COALESCE(error_message, ''), trace_id FROM jobs`
-	filters := make([]string, 0, 3)
-	args := make([]any, 0, 4)
-	argPos := 1
+	sort := filter.Sort
+	if sort == "" {
+		sort = models.JobSortCreatedDesc
+	}
+	var orderBy string
+	switch sort {
+	case models.JobSortCreatedAsc:
+		orderBy = " ORDER BY created_at ASC, id ASC"
+	case models.JobSortPriorityDesc:
+		orderBy = " ORDER BY priority DESC, created_at DESC, id DESC"
+	case models.JobSortCreatedDesc:
+		orderBy = " ORDER BY created_at DESC, id DESC"
+	default:
+		return models.JobPage{}, fmt.Errorf("unknown sort %q", sort)
+	}
 
-	if status != "" {
-		filters = append(filters, fmt.Sprintf("status = $%d", argPos))
-		args = append(args, status)
-		argPos++
+	filters := make([]string, 0, 12)
+	args := make([]any, 0, 16)
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, string(status))
+		}
+		filters = append(filters, "status = ANY("+addArg(statuses)+")")
+	}
+	if filter.TenantID != "" {
+		filters = append(filters, "tenant_id = "+addArg(filter.TenantID))
 	}
-	if tenantID != "" {
-		filters = append(filters, fmt.Sprintf("tenant_id = $%d", argPos))
-		args = append(args, tenantID)
-		argPos++
+	if filter.Model != "" {
+		filters = append(filters, "model = "+addArg(filter.Model))
 	}
-	if model != "" {
-		filters = append(filters, fmt.Sprintf("model = $%d", argPos))
-		args = append(args, model)
-		argPos++
+	if filter.ErrorCode != "" {
+		filters = append(filters, "error_code = "+addArg(filter.ErrorCode))
+	}
+	if filter.IdempotencyKey != "" {
+		filters = append(filters, "idempotency_key = "+addArg(filter.IdempotencyKey))
+	}
+	if filter.TraceID != "" {
+		filters = append(filters, "trace_id = "+addArg(filter.TraceID))
+	}
+	if filter.CreatedAfter != nil {
+		filters = append(filters, "created_at >= "+addArg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		filters = append(filters, "created_at < "+addArg(*filter.CreatedBefore))
+	}
+	if filter.FinishedAfter != nil {
+		filters = append(filters, "finished_at >= "+addArg(*filter.FinishedAfter))
+	}
+	if filter.FinishedBefore != nil {
+		filters = append(filters, "finished_at < "+addArg(*filter.FinishedBefore))
+	}
+	if filter.MinPriority != nil {
+		filters = append(filters, "priority >= "+addArg(*filter.MinPriority))
+	}
+	if filter.MaxPriority != nil {
+		filters = append(filters, "priority <= "+addArg(*filter.MaxPriority))
 	}
 
-	query := baseQuery
+	where := ""
 	if len(filters) > 0 {
-		query += " WHERE " + strings.Join(filters, " AND ")
+		where = " WHERE " + strings.Join(filters, " AND ")
+	}
+
+	page := models.JobPage{}
+	if filter.IncludeTotal {
+		var total int64
+		if err := s.db(ctx).QueryRow(ctx, `SELECT count(*) FROM jobs`+where, args...).Scan(&total); err != nil {
+			return models.JobPage{}, fmt.Errorf("count jobs: %w", err)
+		}
+		page.Total = &total
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeJobCursor(sort, filter.Cursor)
+		if err != nil {
+			return models.JobPage{}, err
+		}
+		var keyset string
+		switch sort {
+		case models.JobSortCreatedAsc:
+			keyset = fmt.Sprintf("(created_at, id) > (%s, %s)", addArg(cursor.CreatedAt), addArg(cursor.ID))
+		case models.JobSortPriorityDesc:
+			keyset = fmt.Sprintf("(priority, created_at, id) < (%s, %s, %s)", addArg(cursor.Priority), addArg(cursor.CreatedAt), addArg(cursor.ID))
+		default:
+			keyset = fmt.Sprintf("(created_at, id) < (%s, %s)", addArg(cursor.CreatedAt), addArg(cursor.ID))
+		}
+		if where == "" {
+			where = " WHERE " + keyset
+		} else {
+			where += " AND " + keyset
+		}
 	}
-	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", argPos)
-	args = append(args, limit)
 
-	rows, err := s.pool.Query(ctx, query, args...)
+	// Fetch one extra row to learn whether another page exists.
+	query := `SELECT ` + jobColumns + ` FROM jobs` + where + orderBy + " LIMIT " + addArg(limit+1)
+
+	rows, err := s.db(ctx).Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list jobs query: %w", err)
+		return models.JobPage{}, fmt.Errorf("list jobs query: %w", err)
 	}
 	defer rows.Close()
 
-	jobs := make([]models.Job, 0, limit)
+	jobs := make([]models.Job, 0, limit+1)
 	for rows.Next() {
 		var job models.Job
-		if err := rows.Scan(
-			&job.ID,
-			&job.TenantID,
-			&job.Status,
-			&job.Priority,
-			&job.Model,
-			&job.PayloadJSON,
-			&job.IdempotencyKey,
-			&job.Attempt,
-			&job.MaxAttempts,
-			&job.CreatedAt,
-			&job.StartedAt,
-			&job.FinishedAt,
-			&job.ErrorCode,
-			&job.ErrorMessage,
-			&job.TraceID,
-		); err != nil {
-			return nil, fmt.Errorf("list jobs scan: %w", err)
+		if err := rows.Scan(jobScanTargets(&job)...); err != nil {
+			return models.JobPage{}, fmt.Errorf("list jobs scan: %w", err)
 		}
 		jobs = append(jobs, job)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("list jobs rows: %w", err)
+		return models.JobPage{}, fmt.Errorf("list jobs rows: %w", err)
 	}
 
-	return jobs, nil
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+		page.NextCursor = encodeJobCursor(sort, jobs[len(jobs)-1])
+	}
+	page.Jobs = jobs
+
+	return page, nil
 }
 
 func (s *PostgresStore) CancelJob(ctx context.Context, jobID string, reason string) (models.Job, error) {
@@ -218,7 +762,7 @@ func (s *PostgresStore) CancelJob(ctx context.Context, jobID string, reason stri
 		reason = "Cancelled by operator"
 	}
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.db(ctx).Begin(ctx)
 	if err != nil {
 		return models.Job{}, fmt.Errorf("begin tx: %w", err)
 	}
@@ -232,27 +776,11 @@ func (s *PostgresStore) CancelJob(ctx context.Context, jobID string, reason stri
 		     finished_at = now(),
 		     error_code = 'CANCELLED',
 		     error_message = $2
-		 WHERE id = $1 AND status IN ('queued', 'running', 'retry_scheduled')
-		 RETURNING id, tenant_id, status, priority, model, payload_json, COALESCE(idempotency_key, ''), attempt, max_attempts, created_at, started_at, finished_at, COALESCE(error_code, ''), COALESCE(error_message, ''), trace_id`,
+		 WHERE id = $1 AND status IN ('queued', 'running', 'retry_scheduled', 'blocked', 'scheduled')
+		 RETURNING `+jobColumns,
 		jobID,
 		reason,
-	).Scan(
-		&job.ID,
-		&job.TenantID,
-		&job.Status,
-		&job.Priority,
-		&job.Model,
-		&job.PayloadJSON,
-		&job.IdempotencyKey,
-		&job.Attempt,
-		&job.MaxAttempts,
-		&job.CreatedAt,
-		&job.StartedAt,
-		&job.FinishedAt,
-		&job.ErrorCode,
-		&job.ErrorMessage,
-		&job.TraceID,
-	)
+	).Scan(jobScanTargets(&job)...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		exists, existsErr := jobExistsTx(ctx, tx, jobID)
 		if existsErr != nil {
@@ -282,6 +810,14 @@ func (s *PostgresStore) CancelJob(ctx context.Context, jobID string, reason stri
 		return models.Job{}, err
 	}
 
+	if err := cancelDependantsTx(ctx, tx, jobID); err != nil {
+		return models.Job{}, err
+	}
+
+	if err := markGroupCompletedTx(ctx, tx, jobID); err != nil {
+		return models.Job{}, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return models.Job{}, fmt.Errorf("commit cancel job: %w", err)
 	}
@@ -289,8 +825,15 @@ func (s *PostgresStore) CancelJob(ctx context.Context, jobID string, reason stri
 	return job, nil
 }
 
-func (s *PostgresStore) RetryJob(ctx context.Context, jobID string) (models.Job, error) {
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+// RetryJob puts a job back in line. A deadline that has already passed is
+// cleared, since a manual retry asks for the job to run regardless. Dependants
+// that were cancelled because the job failed are blocked on it again.
+func (s *PostgresStore) RetryJob(ctx context.Context, jobID string, reason string) (models.Job, error) {
+	if strings.TrimSpace(reason) == "" {
+		reason = "Manual retry requested"
+	}
+
+	tx, err := s.db(ctx).Begin(ctx)
 	if err != nil {
 		return models.Job{}, fmt.Errorf("begin tx: %w", err)
 	}
@@ -300,31 +843,16 @@ func (s *PostgresStore) RetryJob(ctx context.Context, jobID string) (models.Job,
 	err = tx.QueryRow(
 		ctx,
 		`UPDATE jobs
-		 SET status = 'queued',
+		 SET status = CASE WHEN `+unsatisfiedDependencySQL+` THEN 'blocked' ELSE 'queued' END,
 		     started_at = null,
 		     finished_at = null,
 		     error_code = null,
-		     error_message = null
+		     error_message = null,
+		     deadline = CASE WHEN deadline <= now() THEN NULL ELSE deadline END
 		 WHERE id = $1 AND status IN ('failed', 'cancelled', 'dlq', 'retry_scheduled', 'queued')
-		 RETURNING id, tenant_id, status, priority, model, payload_json, COALESCE(idempotency_key, ''), attempt, max_attempts, created_at, started_at, finished_at, COALESCE(error_code, ''), COALESCE(error_message, ''), trace_id`,
+		 RETURNING `+jobColumns,
 		jobID,
-	).Scan(
-		&job.ID,
-		&job.TenantID,
-		&job.Status,
-		&job.Priority,
-		&job.Model,
-		&job.PayloadJSON,
-		&job.IdempotencyKey,
-		&job.Attempt,
-		&job.MaxAttempts,
-		&job.CreatedAt,
-		&job.StartedAt,
-		&job.FinishedAt,
-		&job.ErrorCode,
-		&job.ErrorMessage,
-		&job.TraceID,
-	)
+	).Scan(jobScanTargets(&job)...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		exists, existsErr := jobExistsTx(ctx, tx, jobID)
 		if existsErr != nil {
@@ -339,10 +867,26 @@ func (s *PostgresStore) RetryJob(ctx context.Context, jobID string) (models.Job,
 		return models.Job{}, fmt.Errorf("update retry job: %w", err)
 	}
 
-	if err := appendEventTx(ctx, tx, "job.retry_scheduled", &jobID, nil, "Manual retry requested"); err != nil {
+	if err := appendEventTx(ctx, tx, "job.retry_scheduled", &jobID, nil, reason); err != nil {
+		return models.Job{}, err
+	}
+	if _, err := reblockDependantsTx(ctx, tx, []string{jobID}); err != nil {
 		return models.Job{}, err
 	}
 
+	// A previous attempt may have left the ID in the ready queue.
+	if job.Status == models.JobStatusQueued {
+		if err := enqueueOutboxTx(ctx, tx, []string{jobID}, true); err != nil {
+			return models.Job{}, err
+		}
+	}
+
+	if job.GroupID != "" {
+		if err := reopenGroupTx(ctx, tx, job.GroupID); err != nil {
+			return models.Job{}, err
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return models.Job{}, fmt.Errorf("commit retry job: %w", err)
 	}
@@ -350,31 +894,118 @@ func (s *PostgresStore) RetryJob(ctx context.Context, jobID string) (models.Job,
 	return job, nil
 }
 
+// RescheduleJob moves a job that has not started yet to a new run time. A run
+// time in the past makes the job runnable immediately.
+func (s *PostgresStore) RescheduleJob(ctx context.Context, jobID string, runAt time.Time) (models.Job, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return models.Job{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	job := models.Job{}
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE jobs
+		 SET run_at = $2,
+		     status = CASE WHEN $2 > now() THEN 'scheduled' ELSE 'queued' END
+		 WHERE id = $1 AND status IN ('scheduled', 'queued')
+		 RETURNING `+jobColumns,
+		jobID,
+		runAt,
+	).Scan(jobScanTargets(&job)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		exists, existsErr := jobExistsTx(ctx, tx, jobID)
+		if existsErr != nil {
+			return models.Job{}, existsErr
+		}
+		if !exists {
+			return models.Job{}, ErrNotFound
+		}
+		return models.Job{}, ErrInvalidStateTransition
+	}
+	if err != nil {
+		return models.Job{}, fmt.Errorf("update reschedule job: %w", err)
+	}
+
+	if err := appendEventTx(ctx, tx, "job.scheduled", &jobID, nil, "Rescheduled to run at "+runAt.UTC().Format(time.RFC3339)); err != nil {
+		return models.Job{}, err
+	}
+
+	if job.Status == models.JobStatusQueued {
+		if err := enqueueOutboxTx(ctx, tx, []string{jobID}, true); err != nil {
+			return models.Job{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Job{}, fmt.Errorf("commit reschedule job: %w", err)
+	}
+	return job, nil
+}
+
+// PromoteDueJobs moves scheduled jobs whose run_at has passed to queued,
+// records them in the queue outbox and returns their IDs. SKIP LOCKED lets
+// several promoters run side by side.
+func (s *PostgresStore) PromoteDueJobs(ctx context.Context, limit int) ([]string, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	rows, err := tx.Query(
+		ctx,
+		`UPDATE jobs
+		 SET status = 'queued'
+		 WHERE id IN (
+		     SELECT id FROM jobs
+		     WHERE status = 'scheduled' AND run_at <= now()
+		     ORDER BY run_at
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("promote due jobs: %w", err)
+	}
+	jobIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("promote due jobs scan: %w", err)
+	}
+
+	if len(jobIDs) > 0 {
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
+			 SELECT 'job.promoted', id, NULL, 'Scheduled run time reached', now()
+			 FROM unnest($1::text[]) AS id`,
+			jobIDs,
+		); err != nil {
+			return nil, fmt.Errorf("insert promoted events: %w", err)
+		}
+	}
+	if err := enqueueOutboxTx(ctx, tx, jobIDs, false); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit promote due jobs: %w", err)
+	}
+	return jobIDs, nil
+}
+
 func (s *PostgresStore) MarkJobRunning(ctx context.Context, jobID string, workerID string) (models.Job, bool, error) {
 	job := models.Job{}
 	query := `
 UPDATE jobs
-SET status = 'running', started_at = now(), attempt = attempt + 1, error_code = null, error_message = null
-WHERE id = $1 AND status = 'queued'
-RETURNING id, tenant_id, status, priority, model, payload_json, COALESCE(idempotency_key, ''), attempt, max_attempts, created_at, started_at, finished_at, COALESCE(error_code, ''), COALESCE(error_message, ''), trace_id
+SET status = 'running', started_at = now(), attempt = attempt + 1, model_used = model, error_code = null, error_message = null
+WHERE id = $1 AND status = 'queued' AND (deadline IS NULL OR deadline > now())
+RETURNING ` + jobColumns + `
 `
-	err := s.pool.QueryRow(ctx, query, jobID).Scan(
-		&job.ID,
-		&job.TenantID,
-		&job.Status,
-		&job.Priority,
-		&job.Model,
-		&job.PayloadJSON,
-		&job.IdempotencyKey,
-		&job.Attempt,
-		&job.MaxAttempts,
-		&job.CreatedAt,
-		&job.StartedAt,
-		&job.FinishedAt,
-		&job.ErrorCode,
-		&job.ErrorMessage,
-		&job.TraceID,
-	)
+	err := s.db(ctx).QueryRow(ctx, query, jobID).Scan(jobScanTargets(&job)...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return models.Job{}, false, nil
 	}
@@ -382,12 +1013,14 @@ RETURNING id, tenant_id, status, priority, model, payload_json, COALESCE(idempot
 		return models.Job{}, false, fmt.Errorf("mark running: %w", err)
 	}
 
-	if _, err := s.pool.Exec(
+	if _, err := s.db(ctx).Exec(
 		ctx,
-		`INSERT INTO job_attempts (job_id, attempt, started_at) VALUES ($1, $2, now())
-		 ON CONFLICT (job_id, attempt) DO UPDATE SET started_at = excluded.started_at`,
+		`INSERT INTO job_attempts (job_id, attempt, model, started_at, worker_id) VALUES ($1, $2, $3, now(), $4)
+		 ON CONFLICT (job_id, attempt) DO UPDATE SET model = excluded.model, started_at = excluded.started_at, worker_id = excluded.worker_id`,
 		job.ID,
 		job.Attempt,
+		job.ModelUsed,
+		workerID,
 	); err != nil {
 		return models.Job{}, false, fmt.Errorf("insert job attempt start: %w", err)
 	}
@@ -399,22 +1032,34 @@ RETURNING id, tenant_id, status, priority, model, payload_json, COALESCE(idempot
 	return job, true, nil
 }
 
-func (s *PostgresStore) MarkJobSucceeded(ctx context.Context, jobID string, workerID string, tokens int, costUSD float64, providerMeta json.RawMessage) error {
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+// MarkJobSucceeded records the successful attempt and its result. Dependants
+// that became runnable are queued in the same transaction.
+func (s *PostgresStore) MarkJobSucceeded(
+	ctx context.Context,
+	jobID string,
+	workerID string,
+	usage models.Usage,
+	costUSD float64,
+	providerMeta json.RawMessage,
+	result json.RawMessage,
+) error {
+	tx, err := s.db(ctx).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx) //nolint:errcheck
 
 	var attempt int
+	var tenantID string
 	err = tx.QueryRow(
 		ctx,
 		`UPDATE jobs
-		 SET status = 'succeeded', finished_at = now(), error_code = null, error_message = null
+		 SET status = 'succeeded', finished_at = now(), error_code = null, error_message = null, result_json = $2
 		 WHERE id = $1 AND status = 'running'
-		 RETURNING attempt`,
+		 RETURNING attempt, tenant_id`,
 		jobID,
-	).Scan(&attempt)
+		[]byte(result),
+	).Scan(&attempt, &tenantID)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return ErrNotFound
 	}
@@ -425,11 +1070,15 @@ func (s *PostgresStore) MarkJobSucceeded(ctx context.Context, jobID string, work
 	cmdTag, err := tx.Exec(
 		ctx,
 		`UPDATE job_attempts
-		 SET finished_at = now(), success = true, tokens = $3, cost_usd = $4, provider_meta_json = $5
+		 SET finished_at = now(), success = true, tokens = $3, prompt_tokens = $4, cached_prompt_tokens = $5,
+		     completion_tokens = $6, cost_usd = $7, provider_meta_json = $8
 		 WHERE job_id = $1 AND attempt = $2`,
 		jobID,
 		attempt,
-		tokens,
+		usage.Total(),
+		usage.PromptTokens,
+		usage.CachedPromptTokens,
+		usage.CompletionTokens,
 		costUSD,
 		[]byte(providerMeta),
 	)
@@ -439,13 +1088,18 @@ func (s *PostgresStore) MarkJobSucceeded(ctx context.Context, jobID string, work
 	if cmdTag.RowsAffected() == 0 {
 		if _, err := tx.Exec(
 			ctx,
-			`INSERT INTO job_attempts (job_id, attempt, started_at, finished_at, success, tokens, cost_usd, provider_meta_json)
-			 VALUES ($1, $2, now(), now(), true, $3, $4, $5)`,
+			`INSERT INTO job_attempts (job_id, attempt, model, started_at, finished_at, success, tokens, prompt_tokens,
+			     cached_prompt_tokens, completion_tokens, cost_usd, provider_meta_json, worker_id)
+			 VALUES ($1, $2, (SELECT model_used FROM jobs WHERE id = $1), now(), now(), true, $3, $4, $5, $6, $7, $8, $9)`,
 			jobID,
 			attempt,
-			tokens,
+			usage.Total(),
+			usage.PromptTokens,
+			usage.CachedPromptTokens,
+			usage.CompletionTokens,
 			costUSD,
 			[]byte(providerMeta),
+			workerID,
 		); err != nil {
 			return fmt.Errorf("insert missing success attempt row: %w", err)
 		}
@@ -454,12 +1108,26 @@ func (s *PostgresStore) MarkJobSucceeded(ctx context.Context, jobID string, work
 	if err := appendEventTx(ctx, tx, "job.succeeded", &jobID, &workerID, "Provider returned completion"); err != nil {
 		return err
 	}
+	if err := recordSpendTx(ctx, tx, tenantID, jobID, workerID, costUSD); err != nil {
+		return err
+	}
 
-	return tx.Commit(ctx)
+	if err := releaseDependantsTx(ctx, tx, jobID); err != nil {
+		return err
+	}
+
+	if err := markGroupCompletedTx(ctx, tx, jobID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit succeeded job: %w", err)
+	}
+	return nil
 }
 
 func (s *PostgresStore) MarkJobFailed(ctx context.Context, jobID string, workerID string, errorCode string, errorMessage string) error {
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.db(ctx).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
@@ -499,12 +1167,13 @@ func (s *PostgresStore) MarkJobFailed(ctx context.Context, jobID string, workerI
 	if cmdTag.RowsAffected() == 0 {
 		if _, err := tx.Exec(
 			ctx,
-			`INSERT INTO job_attempts (job_id, attempt, started_at, finished_at, success, error_code, error_message)
-			 VALUES ($1, $2, now(), now(), false, $3, $4)`,
+			`INSERT INTO job_attempts (job_id, attempt, model, started_at, finished_at, success, error_code, error_message, worker_id)
+			 VALUES ($1, $2, (SELECT model_used FROM jobs WHERE id = $1), now(), now(), false, $3, $4, $5)`,
 			jobID,
 			attempt,
 			errorCode,
 			errorMessage,
+			workerID,
 		); err != nil {
 			return fmt.Errorf("insert missing failed attempt row: %w", err)
 		}
@@ -514,6 +1183,14 @@ func (s *PostgresStore) MarkJobFailed(ctx context.Context, jobID string, workerI
 		return err
 	}
 
+	if err := cancelDependantsTx(ctx, tx, jobID); err != nil {
+		return err
+	}
+
+	if err := markGroupCompletedTx(ctx, tx, jobID); err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
@@ -528,7 +1205,7 @@ func (s *PostgresStore) UpsertWorkerHeartbeat(
 	if runningJobID != "" {
 		runningJob = runningJobID
 	}
-	_, err := s.pool.Exec(
+	_, err := s.db(ctx).Exec(
 		ctx,
 		`INSERT INTO workers (worker_id, last_heartbeat_at, state, running_job_id, concurrency)
 		 VALUES ($1, now(), $2, $3, $4)
@@ -547,29 +1224,13 @@ func (s *PostgresStore) UpsertWorkerHeartbeat(
 
 func (s *PostgresStore) getJobByID(ctx context.Context, jobID string) (models.Job, error) {
 	job := models.Job{}
-	err := s.pool.QueryRow(
+	err := s.db(ctx).QueryRow(
 		ctx,
-		`SELECT id, tenant_id, status, priority, model, payload_json, COALESCE(idempotency_key, ''), attempt, max_attempts, created_at, started_at, finished_at, COALESCE(error_code, ''), COALESCE(error_message, ''), trace_id
+		`SELECT `+jobColumns+`
 		 FROM jobs
 		 WHERE id = $1`,
 		jobID,
-	).Scan(
-		&job.ID,
-		&job.TenantID,
-		&job.Status,
-		&job.Priority,
-		&job.Model,
-		&job.PayloadJSON,
-		&job.IdempotencyKey,
-		&job.Attempt,
-		&job.MaxAttempts,
-		&job.CreatedAt,
-		&job.StartedAt,
-		&job.FinishedAt,
-		&job.ErrorCode,
-		&job.ErrorMessage,
-		&job.TraceID,
-	)
+	).Scan(jobScanTargets(&job)...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return models.Job{}, ErrNotFound
 	}
@@ -579,72 +1240,38 @@ func (s *PostgresStore) getJobByID(ctx context.Context, jobID string) (models.Jo
 	return job, nil
 }
 
-func (s *PostgresStore) getJobByTenantAndIdempotency(ctx context.Context, tenantID string, key string) (models.Job, error) {
-	job := models.Job{}
-	err := s.pool.QueryRow(
-		ctx,
-		`SELECT id, tenant_id, status, priority, model, payload_json, COALESCE(idempotency_key, ''), attempt, max_attempts, created_at, started_at, finished_at, COALESCE(error_code, ''), COALESCE(error_message, ''), trace_id
-		 FROM jobs
-		 WHERE tenant_id = $1 AND idempotency_key = $2`,
-		tenantID,
-		key,
-	).Scan(
-		&job.ID,
-		&job.TenantID,
-		&job.Status,
-		&job.Priority,
-		&job.Model,
-		&job.PayloadJSON,
-		&job.IdempotencyKey,
-		&job.Attempt,
-		&job.MaxAttempts,
-		&job.CreatedAt,
-		&job.StartedAt,
-		&job.FinishedAt,
-		&job.ErrorCode,
-		&job.ErrorMessage,
-		&job.TraceID,
-	)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return models.Job{}, ErrNotFound
-	}
-	if err != nil {
-		return models.Job{}, fmt.Errorf("get job by tenant + idempotency: %w", err)
-	}
-	return job, nil
-}
-
 func (s *PostgresStore) getLatestAttempt(ctx context.Context, jobID string) (models.JobAttempt, error) {
 	attempt := models.JobAttempt{}
-	err := s.pool.QueryRow(
+	err := s.db(ctx).QueryRow(
 		ctx,
-		`SELECT job_id, attempt, started_at, finished_at, success, COALESCE(error_code, ''), COALESCE(error_message, ''), tokens, cost_usd, provider_meta_json
+		`SELECT `+attemptColumns+`
 		 FROM job_attempts
 		 WHERE job_id = $1
 		 ORDER BY attempt DESC
 		 LIMIT 1`,
 		jobID,
-	).Scan(
-		&attempt.JobID,
-		&attempt.Attempt,
-		&attempt.StartedAt,
-		&attempt.FinishedAt,
-		&attempt.Success,
-		&attempt.ErrorCode,
-		&attempt.ErrorMessage,
-		&attempt.Tokens,
-		&attempt.CostUSD,
-		&attempt.ProviderMeta,
-	)
+	).Scan(attemptScanTargets(&attempt)...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return models.JobAttempt{}, ErrNotFound
 	}
 	if err != nil {
 		return models.JobAttempt{}, fmt.Errorf("get latest attempt: %w", err)
 	}
+	attempt.DurationMS = attemptDurationMS(attempt)
 	return attempt, nil
 }
 
+func (s *PostgresStore) RecordEvent(ctx context.Context, eventType string, jobID string, workerID string, details string) error {
+	var jobRef, workerRef *string
+	if jobID != "" {
+		jobRef = &jobID
+	}
+	if workerID != "" {
+		workerRef = &workerID
+	}
+	return s.appendEvent(ctx, eventType, jobRef, workerRef, details)
+}
+
 func (s *PostgresStore) appendEvent(
 	ctx context.Context,
 	eventType string,
@@ -652,7 +1279,7 @@ func (s *PostgresStore) appendEvent(
 	workerID *string,
 	details string,
 ) error {
-	_, err := s.pool.Exec(
+	_, err := s.db(ctx).Exec(
 		ctx,
 		`INSERT INTO events (event_type, job_id, worker_id, details, created_at)
 		 VALUES ($1, $2, $3, $4, now())`,
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrPriceExists is returned when a model already has a price taking effect
+// at the same instant. Prices are never edited in place, so the cost stored on
+// past attempts always matches the catalog.
+var ErrPriceExists = errors.New("model price already exists")
+
+const modelPriceColumns = `model, effective_from, input_per_1k_usd, cached_input_per_1k_usd, output_per_1k_usd, created_at`
+
+// modelPriceScanTargets returns the scan destinations matching modelPriceColumns.
+func modelPriceScanTargets(price *models.ModelPrice) []any {
+	return []any{
+		&price.Model,
+		&price.EffectiveFrom,
+		&price.InputPer1K,
+		&price.CachedInputPer1K,
+		&price.OutputPer1K,
+		&price.CreatedAt,
+	}
+}
+
+func (s *PostgresStore) CreateModelPrice(ctx context.Context, price models.ModelPrice) (models.ModelPrice, error) {
+	created := models.ModelPrice{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`INSERT INTO model_prices (model, effective_from, input_per_1k_usd, cached_input_per_1k_usd, output_per_1k_usd, created_at)
+		 VALUES ($1, $2, $3, $4, $5, now())
+		 ON CONFLICT (model, effective_from) DO NOTHING
+		 RETURNING `+modelPriceColumns,
+		price.Model,
+		price.EffectiveFrom,
+		price.InputPer1K,
+		price.CachedInputPer1K,
+		price.OutputPer1K,
+	).Scan(modelPriceScanTargets(&created)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ModelPrice{}, ErrPriceExists
+	}
+	if err != nil {
+		return models.ModelPrice{}, fmt.Errorf("insert model price: %w", err)
+	}
+	return created, nil
+}
+
+// ListModelPrices returns the price history of model, or of every model when
+// model is empty, ordered by model and then newest first.
+func (s *PostgresStore) ListModelPrices(ctx context.Context, model string) ([]models.ModelPrice, error) {
+	query := `SELECT ` + modelPriceColumns + ` FROM model_prices`
+	args := make([]any, 0, 1)
+	if model != "" {
+		query += ` WHERE model = $1`
+		args = append(args, model)
+	}
+	query += ` ORDER BY model, effective_from DESC`
+
+	rows, err := s.db(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list model prices query: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make([]models.ModelPrice, 0)
+	for rows.Next() {
+		var price models.ModelPrice
+		if err := rows.Scan(modelPriceScanTargets(&price)...); err != nil {
+			return nil, fmt.Errorf("list model prices scan: %w", err)
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list model prices rows: %w", err)
+	}
+	return prices, nil
+}
+
+// GetModelPrice returns the price of model in effect at at.
+func (s *PostgresStore) GetModelPrice(ctx context.Context, model string, at time.Time) (models.ModelPrice, error) {
+	price := models.ModelPrice{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`SELECT `+modelPriceColumns+`
+		 FROM model_prices
+		 WHERE model = $1 AND effective_from <= $2
+		 ORDER BY effective_from DESC
+		 LIMIT 1`,
+		model,
+		at,
+	).Scan(modelPriceScanTargets(&price)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ModelPrice{}, ErrNotFound
+	}
+	if err != nil {
+		return models.ModelPrice{}, fmt.Errorf("get model price: %w", err)
+	}
+	return price, nil
+}
@@ -0,0 +1,307 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const retentionPolicyColumns = `tenant_id, jobs_retention_days, archive, updated_at`
+
+// eventPartitionLayout names the monthly partitions of the events table.
+const eventPartitionLayout = "events_2006_01"
+
+// ExpiredJob is a finished job past its tenant's retention, together with its
+// attempts. It is the unit written to archive files.
+type ExpiredJob struct {
+	models.Job
+	Attempts []models.JobAttempt `json:"attempts"`
+	// Archive is false when the tenant's policy deletes without archiving.
+	Archive bool `json:"-"`
+}
+
+// EventPartition is one monthly partition of the events table covering
+// [From, To).
+type EventPartition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// EventRecord is a row of the events table as written to archive files.
+type EventRecord struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"event_type"`
+	JobID     *string   `json:"job_id,omitempty"`
+	WorkerID  *string   `json:"worker_id,omitempty"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *PostgresStore) UpsertRetentionPolicy(ctx context.Context, policy models.RetentionPolicy) (models.RetentionPolicy, error) {
+	saved := models.RetentionPolicy{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`INSERT INTO retention_policies (tenant_id, jobs_retention_days, archive, updated_at)
+		 VALUES ($1, $2, $3, now())
+		 ON CONFLICT (tenant_id) DO UPDATE
+		 SET jobs_retention_days = EXCLUDED.jobs_retention_days,
+		     archive = EXCLUDED.archive,
+		     updated_at = now()
+		 RETURNING `+retentionPolicyColumns,
+		policy.TenantID,
+		policy.JobsRetentionDays,
+		policy.Archive,
+	).Scan(&saved.TenantID, &saved.JobsRetentionDays, &saved.Archive, &saved.UpdatedAt)
+	if err != nil {
+		return models.RetentionPolicy{}, fmt.Errorf("upsert retention policy: %w", err)
+	}
+	return saved, nil
+}
+
+func (s *PostgresStore) GetRetentionPolicy(ctx context.Context, tenantID string) (models.RetentionPolicy, error) {
+	policy := models.RetentionPolicy{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`SELECT `+retentionPolicyColumns+` FROM retention_policies WHERE tenant_id = $1`,
+		tenantID,
+	).Scan(&policy.TenantID, &policy.JobsRetentionDays, &policy.Archive, &policy.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.RetentionPolicy{}, ErrNotFound
+	}
+	if err != nil {
+		return models.RetentionPolicy{}, fmt.Errorf("get retention policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (s *PostgresStore) ListRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	rows, err := s.db(ctx).Query(ctx, `SELECT `+retentionPolicyColumns+` FROM retention_policies ORDER BY tenant_id`)
+	if err != nil {
+		return nil, fmt.Errorf("list retention policies query: %w", err)
+	}
+	defer rows.Close()
+
+	policies := make([]models.RetentionPolicy, 0)
+	for rows.Next() {
+		var policy models.RetentionPolicy
+		if err := rows.Scan(&policy.TenantID, &policy.JobsRetentionDays, &policy.Archive, &policy.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("list retention policies scan: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list retention policies rows: %w", err)
+	}
+	return policies, nil
+}
+
+func (s *PostgresStore) DeleteRetentionPolicy(ctx context.Context, tenantID string) error {
+	cmdTag, err := s.db(ctx).Exec(ctx, `DELETE FROM retention_policies WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete retention policy: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ExpiredJobs returns up to limit finished jobs, oldest first, that are past
+// their tenant's retention. Tenants without a policy use defaultDays; zero
+// keeps their jobs. Jobs are held back while a dependant still needs their
+// result or while their group is still open.
+func (s *PostgresStore) ExpiredJobs(ctx context.Context, defaultDays int, limit int) ([]ExpiredJob, error) {
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT `+jobColumns+`, expired.archive
+		 FROM jobs
+		 JOIN (
+		     SELECT j.id AS expired_id, COALESCE(p.archive, true) AS archive
+		     FROM jobs j
+		     LEFT JOIN retention_policies p ON p.tenant_id = j.tenant_id
+		     WHERE j.status IN ('succeeded', 'failed', 'dlq', 'cancelled')
+		       AND j.finished_at IS NOT NULL
+		       AND COALESCE(p.jobs_retention_days, $1) > 0
+		       AND j.finished_at < now() - make_interval(days => COALESCE(p.jobs_retention_days, $1))
+		       AND NOT EXISTS (
+		           SELECT 1
+		           FROM job_dependencies d
+		           JOIN jobs child ON child.id = d.job_id
+		           WHERE d.depends_on_job_id = j.id
+		             AND child.status NOT IN ('succeeded', 'failed', 'dlq', 'cancelled')
+		       )
+		       AND NOT EXISTS (
+		           SELECT 1 FROM job_groups g WHERE g.id = j.group_id AND g.completed_at IS NULL
+		       )
+		     ORDER BY j.finished_at, j.id
+		     LIMIT $2
+		 ) expired ON expired.expired_id = jobs.id
+		 ORDER BY jobs.finished_at, jobs.id`,
+		defaultDays,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("expired jobs query: %w", err)
+	}
+	defer rows.Close()
+
+	expired := make([]ExpiredJob, 0)
+	index := map[string]int{}
+	for rows.Next() {
+		var job ExpiredJob
+		if err := rows.Scan(append(jobScanTargets(&job.Job), &job.Archive)...); err != nil {
+			return nil, fmt.Errorf("expired jobs scan: %w", err)
+		}
+		job.Attempts = make([]models.JobAttempt, 0, job.Attempt)
+		index[job.ID] = len(expired)
+		expired = append(expired, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("expired jobs rows: %w", err)
+	}
+	if len(expired) == 0 {
+		return expired, nil
+	}
+
+	jobIDs := make([]string, 0, len(expired))
+	for _, job := range expired {
+		jobIDs = append(jobIDs, job.ID)
+	}
+	attemptRows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT `+attemptColumns+` FROM job_attempts WHERE job_id = ANY($1) ORDER BY job_id, attempt`,
+		jobIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("expired job attempts query: %w", err)
+	}
+	defer attemptRows.Close()
+
+	for attemptRows.Next() {
+		var attempt models.JobAttempt
+		if err := attemptRows.Scan(attemptScanTargets(&attempt)...); err != nil {
+			return nil, fmt.Errorf("expired job attempts scan: %w", err)
+		}
+		attempt.DurationMS = attemptDurationMS(attempt)
+		position := index[attempt.JobID]
+		expired[position].Attempts = append(expired[position].Attempts, attempt)
+	}
+	if err := attemptRows.Err(); err != nil {
+		return nil, fmt.Errorf("expired job attempts rows: %w", err)
+	}
+	return expired, nil
+}
+
+// DeleteJobs removes jobs together with their attempts, dependency edges and
+// outbox entries. Events are left to partition retention.
+func (s *PostgresStore) DeleteJobs(ctx context.Context, jobIDs []string) (int64, error) {
+	if len(jobIDs) == 0 {
+		return 0, nil
+	}
+	cmdTag, err := s.db(ctx).Exec(ctx, `DELETE FROM jobs WHERE id = ANY($1)`, jobIDs)
+	if err != nil {
+		return 0, fmt.Errorf("delete jobs: %w", err)
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+// EnsureEventPartitions creates the monthly events partitions for the month
+// containing from and the following ahead months.
+func (s *PostgresStore) EnsureEventPartitions(ctx context.Context, from time.Time, ahead int) error {
+	month := monthStart(from)
+	for i := 0; i <= ahead; i++ {
+		next := month.AddDate(0, 1, 0)
+		name := month.Format(eventPartitionLayout)
+		_, err := s.db(ctx).Exec(ctx, fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s PARTITION OF events FOR VALUES FROM ('%s') TO ('%s')`,
+			pgx.Identifier{name}.Sanitize(),
+			month.Format(time.RFC3339),
+			next.Format(time.RFC3339),
+		))
+		if err != nil {
+			return fmt.Errorf("create event partition %s: %w", name, err)
+		}
+		month = next
+	}
+	return nil
+}
+
+// EventPartitions lists the monthly events partitions, oldest first.
+func (s *PostgresStore) EventPartitions(ctx context.Context) ([]EventPartition, error) {
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT child.relname
+		 FROM pg_inherits
+		 JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		 JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		 WHERE parent.oid = 'events'::regclass`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list event partitions query: %w", err)
+	}
+	defer rows.Close()
+
+	partitions := make([]EventPartition, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("list event partitions scan: %w", err)
+		}
+		month, err := time.Parse(eventPartitionLayout, name)
+		if err != nil {
+			// Not created by the archiver or the migration; leave it alone.
+			continue
+		}
+		partitions = append(partitions, EventPartition{Name: name, From: month, To: month.AddDate(0, 1, 0)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list event partitions rows: %w", err)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].From.Before(partitions[j].From) })
+	return partitions, nil
+}
+
+// ExportEventPartition streams every row of a partition to fn in ID order.
+func (s *PostgresStore) ExportEventPartition(ctx context.Context, partition EventPartition, fn func(EventRecord) error) error {
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT id, event_type, job_id, worker_id, details, created_at FROM `+pgx.Identifier{partition.Name}.Sanitize()+` ORDER BY id`,
+	)
+	if err != nil {
+		return fmt.Errorf("export event partition %s: %w", partition.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record EventRecord
+		if err := rows.Scan(&record.ID, &record.Type, &record.JobID, &record.WorkerID, &record.Details, &record.CreatedAt); err != nil {
+			return fmt.Errorf("export event partition %s scan: %w", partition.Name, err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("export event partition %s rows: %w", partition.Name, err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) DropEventPartition(ctx context.Context, partition EventPartition) error {
+	if _, err := s.db(ctx).Exec(ctx, `DROP TABLE IF EXISTS `+pgx.Identifier{partition.Name}.Sanitize()); err != nil {
+		return fmt.Errorf("drop event partition %s: %w", partition.Name, err)
+	}
+	return nil
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const scheduleColumns = `id, tenant_id, name, cron_expr, timezone, model, payload_json, priority, max_attempts, overlap_policy, paused, next_fire_at, last_fired_at, COALESCE(last_job_id, ''), created_at, updated_at`
+
+// scheduleScanTargets returns the scan destinations matching scheduleColumns.
+func scheduleScanTargets(schedule *models.Schedule) []any {
+	return []any{
+		&schedule.ID,
+		&schedule.TenantID,
+		&schedule.Name,
+		&schedule.CronExpr,
+		&schedule.Timezone,
+		&schedule.Model,
+		&schedule.PayloadJSON,
+		&schedule.Priority,
+		&schedule.MaxAttempts,
+		&schedule.OverlapPolicy,
+		&schedule.Paused,
+		&schedule.NextFireAt,
+		&schedule.LastFiredAt,
+		&schedule.LastJobID,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	}
+}
+
+func (s *PostgresStore) CreateSchedule(ctx context.Context, input models.CreateScheduleInput, nextFireAt time.Time) (models.Schedule, error) {
+	schedule := models.Schedule{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`INSERT INTO schedules (
+			id, tenant_id, name, cron_expr, timezone, model, payload_json, priority, max_attempts, overlap_policy, next_fire_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, now(), now())
+		RETURNING `+scheduleColumns,
+		uuid.NewString(),
+		input.TenantID,
+		input.Name,
+		input.CronExpr,
+		input.Timezone,
+		input.Model,
+		[]byte(input.PayloadJSON),
+		input.Priority,
+		input.MaxAttempts,
+		input.OverlapPolicy,
+		nextFireAt,
+	).Scan(scheduleScanTargets(&schedule)...)
+	if err != nil {
+		return models.Schedule{}, fmt.Errorf("insert schedule: %w", err)
+	}
+
+	if err := s.appendEvent(ctx, "schedule.created", nil, nil, "Schedule "+schedule.ID+" created with cron "+schedule.CronExpr); err != nil {
+		return models.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (s *PostgresStore) GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	schedule := models.Schedule{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`SELECT `+scheduleColumns+` FROM schedules WHERE id = $1`,
+		scheduleID,
+	).Scan(scheduleScanTargets(&schedule)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Schedule{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Schedule{}, fmt.Errorf("get schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+func (s *PostgresStore) ListSchedules(ctx context.Context, tenantID string) ([]models.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules`
+	args := make([]any, 0, 1)
+	if tenantID != "" {
+		query += ` WHERE tenant_id = $1`
+		args = append(args, tenantID)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := s.db(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list schedules query: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]models.Schedule, 0)
+	for rows.Next() {
+		var schedule models.Schedule
+		if err := rows.Scan(scheduleScanTargets(&schedule)...); err != nil {
+			return nil, fmt.Errorf("list schedules scan: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list schedules rows: %w", err)
+	}
+	return schedules, nil
+}
+
+// SetSchedulePaused pauses or resumes a schedule. Resuming sets the next fire
+// time so that slots missed while paused are not replayed.
+func (s *PostgresStore) SetSchedulePaused(ctx context.Context, scheduleID string, paused bool, nextFireAt *time.Time) (models.Schedule, error) {
+	schedule := models.Schedule{}
+	err := s.db(ctx).QueryRow(
+		ctx,
+		`UPDATE schedules
+		 SET paused = $2, next_fire_at = COALESCE($3, next_fire_at), updated_at = now()
+		 WHERE id = $1
+		 RETURNING `+scheduleColumns,
+		scheduleID,
+		paused,
+		nextFireAt,
+	).Scan(scheduleScanTargets(&schedule)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Schedule{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Schedule{}, fmt.Errorf("update schedule paused: %w", err)
+	}
+
+	eventType := "schedule.resumed"
+	if paused {
+		eventType = "schedule.paused"
+	}
+	if err := s.appendEvent(ctx, eventType, nil, nil, "Schedule "+scheduleID); err != nil {
+		return models.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (s *PostgresStore) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	cmdTag, err := s.db(ctx).Exec(ctx, `DELETE FROM schedules WHERE id = $1`, scheduleID)
+	if err != nil {
+		return fmt.Errorf("delete schedule: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return s.appendEvent(ctx, "schedule.deleted", nil, nil, "Schedule "+scheduleID+" deleted")
+}
+
+// DueSchedules returns active schedules whose next fire time has passed.
+func (s *PostgresStore) DueSchedules(ctx context.Context, limit int) ([]models.Schedule, error) {
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`SELECT `+scheduleColumns+`
+		 FROM schedules
+		 WHERE NOT paused AND next_fire_at <= now()
+		 ORDER BY next_fire_at
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("due schedules query: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]models.Schedule, 0)
+	for rows.Next() {
+		var schedule models.Schedule
+		if err := rows.Scan(scheduleScanTargets(&schedule)...); err != nil {
+			return nil, fmt.Errorf("due schedules scan: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("due schedules rows: %w", err)
+	}
+	return schedules, nil
+}
+
+// AdvanceSchedule moves a schedule past the slot that fired at firedAt. The
+// update only applies while next_fire_at still equals firedAt, so a slot is
+// advanced at most once even if two schedulers briefly overlap. jobID is empty
+// when the slot was skipped.
+func (s *PostgresStore) AdvanceSchedule(ctx context.Context, scheduleID string, firedAt time.Time, nextFireAt time.Time, jobID string) (bool, error) {
+	cmdTag, err := s.db(ctx).Exec(
+		ctx,
+		`UPDATE schedules
+		 SET next_fire_at = $3,
+		     last_fired_at = CASE WHEN $4 = '' THEN last_fired_at ELSE $2 END,
+		     last_job_id = COALESCE(NULLIF($4, ''), last_job_id),
+		     updated_at = now()
+		 WHERE id = $1 AND next_fire_at = $2`,
+		scheduleID,
+		firedAt,
+		nextFireAt,
+		jobID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("advance schedule: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	details := "Schedule " + scheduleID + " slot " + firedAt.UTC().Format(time.RFC3339)
+	eventType := "schedule.fired"
+	if jobID == "" {
+		eventType = "schedule.skipped"
+	}
+	var eventJobID *string
+	if jobID != "" {
+		eventJobID = &jobID
+	}
+	if err := s.appendEvent(ctx, eventType, eventJobID, nil, details); err != nil {
+		return true, err
+	}
+	return true, nil
+}
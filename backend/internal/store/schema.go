@@ -0,0 +1,22 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// SchemaVersion returns the newest version recorded in schema_migrations, or 0
+// when cmd/migrate has never run against this database. It never creates the
+// table.
+func (s *PostgresStore) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.db(ctx).QueryRow(ctx, `
+SELECT CASE
+    WHEN to_regclass('schema_migrations') IS NULL THEN 0
+    ELSE (SELECT COALESCE(MAX(version), 0) FROM schema_migrations)
+END`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
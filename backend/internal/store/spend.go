@@ -0,0 +1,268 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// spendMonth is the first day of the current UTC month, the key of
+// tenant_spend.
+const spendMonth = `date_trunc('month', now() AT TIME ZONE 'UTC')::date`
+
+const spendCapColumns = `tenant_id, monthly_spend_cap_usd, spend_cap_override_usd, spend_cap_override_until, updated_at`
+
+// spendCapScanTargets returns the scan destinations matching spendCapColumns.
+func spendCapScanTargets(spendCap *models.SpendCap) []any {
+	return []any{
+		&spendCap.TenantID,
+		&spendCap.MonthlyCapUSD,
+		&spendCap.OverrideCapUSD,
+		&spendCap.OverrideUntil,
+		&spendCap.UpdatedAt,
+	}
+}
+
+// spendStatusQuery selects every tenant with limits or spend this month, or
+// only the tenant in $1 when it is not empty.
+const spendStatusQuery = `
+SELECT COALESCE(l.tenant_id, s.tenant_id), l.monthly_spend_cap_usd, l.spend_cap_override_usd,
+       l.spend_cap_override_until, l.updated_at, COALESCE(s.spent_usd, 0), s.soft_alerted_at, s.cap_reached_at
+FROM tenant_limits l
+FULL JOIN (SELECT * FROM tenant_spend WHERE month = ` + spendMonth + `) s ON s.tenant_id = l.tenant_id
+WHERE $1 = '' OR COALESCE(l.tenant_id, s.tenant_id) = $1
+ORDER BY 1`
+
+// newSpendStatus derives the effective cap and whether it is exceeded.
+func newSpendStatus(spendCap models.SpendCap, spentUSD float64, softAlertedAt *time.Time, capReachedAt *time.Time, now time.Time) models.SpendStatus {
+	status := models.SpendStatus{
+		SpendCap:        spendCap,
+		Month:           now.UTC().Format("2006-01"),
+		SpentUSD:        spentUSD,
+		EffectiveCapUSD: spendCap.EffectiveCap(now),
+		SoftAlertedAt:   softAlertedAt,
+		CapReachedAt:    capReachedAt,
+	}
+	status.OverCap = status.EffectiveCapUSD != nil && spentUSD >= *status.EffectiveCapUSD
+	return status
+}
+
+// GetSpendStatus returns the tenant's spend this month. A tenant without a cap
+// is returned uncapped rather than as ErrNotFound.
+func (s *PostgresStore) GetSpendStatus(ctx context.Context, tenantID string) (models.SpendStatus, error) {
+	statuses, err := s.querySpendStatuses(ctx, tenantID)
+	if err != nil {
+		return models.SpendStatus{}, err
+	}
+	if len(statuses) == 0 {
+		return newSpendStatus(models.SpendCap{TenantID: tenantID}, 0, nil, nil, time.Now()), nil
+	}
+	return statuses[0], nil
+}
+
+func (s *PostgresStore) ListSpendStatuses(ctx context.Context) ([]models.SpendStatus, error) {
+	return s.querySpendStatuses(ctx, "")
+}
+
+func (s *PostgresStore) querySpendStatuses(ctx context.Context, tenantID string) ([]models.SpendStatus, error) {
+	rows, err := s.db(ctx).Query(ctx, spendStatusQuery, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("spend status query: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	statuses := make([]models.SpendStatus, 0)
+	for rows.Next() {
+		var spendCap models.SpendCap
+		var spentUSD float64
+		var softAlertedAt, capReachedAt *time.Time
+		if err := rows.Scan(append(spendCapScanTargets(&spendCap), &spentUSD, &softAlertedAt, &capReachedAt)...); err != nil {
+			return nil, fmt.Errorf("spend status scan: %w", err)
+		}
+		statuses = append(statuses, newSpendStatus(spendCap, spentUSD, softAlertedAt, capReachedAt, now))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("spend status rows: %w", err)
+	}
+	return statuses, nil
+}
+
+// SetSpendCap sets or, with a nil capUSD, removes the tenant's monthly cap.
+func (s *PostgresStore) SetSpendCap(ctx context.Context, tenantID string, capUSD *float64) (models.SpendCap, error) {
+	return s.updateSpendCap(ctx, tenantID,
+		`INSERT INTO tenant_limits (tenant_id, monthly_spend_cap_usd, updated_at)
+		 VALUES ($1, $2, now())
+		 ON CONFLICT (tenant_id) DO UPDATE
+		 SET monthly_spend_cap_usd = EXCLUDED.monthly_spend_cap_usd, updated_at = now()
+		 RETURNING `+spendCapColumns,
+		tenantID, capUSD)
+}
+
+// SetSpendCapOverride replaces the tenant's cap with capUSD until until; a nil
+// capUSD lifts the cap meanwhile. A nil until clears the override.
+func (s *PostgresStore) SetSpendCapOverride(ctx context.Context, tenantID string, capUSD *float64, until *time.Time) (models.SpendCap, error) {
+	return s.updateSpendCap(ctx, tenantID,
+		`INSERT INTO tenant_limits (tenant_id, spend_cap_override_usd, spend_cap_override_until, updated_at)
+		 VALUES ($1, $2, $3, now())
+		 ON CONFLICT (tenant_id) DO UPDATE
+		 SET spend_cap_override_usd = EXCLUDED.spend_cap_override_usd,
+		     spend_cap_override_until = EXCLUDED.spend_cap_override_until,
+		     updated_at = now()
+		 RETURNING `+spendCapColumns,
+		tenantID, capUSD, until)
+}
+
+// updateSpendCap runs an upsert of tenant_limits and re-arms this month's
+// alerts, which were raised against the previous cap.
+func (s *PostgresStore) updateSpendCap(ctx context.Context, tenantID string, query string, args ...any) (models.SpendCap, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return models.SpendCap{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	saved := models.SpendCap{}
+	if err := tx.QueryRow(ctx, query, args...).Scan(spendCapScanTargets(&saved)...); err != nil {
+		return models.SpendCap{}, fmt.Errorf("update spend cap: %w", err)
+	}
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE tenant_spend SET soft_alerted_at = NULL, cap_reached_at = NULL
+		 WHERE tenant_id = $1 AND month = `+spendMonth,
+		tenantID,
+	); err != nil {
+		return models.SpendCap{}, fmt.Errorf("reset spend alerts: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.SpendCap{}, fmt.Errorf("commit spend cap: %w", err)
+	}
+	return saved, nil
+}
+
+// recordSpendTx adds an attempt's cost to the tenant's spend this month and
+// records a tenant.spend_alert or tenant.spend_cap_reached event the first
+// time the spend crosses SpendAlertRatio of the cap or the cap itself.
+func recordSpendTx(ctx context.Context, tx pgx.Tx, tenantID string, jobID string, workerID string, costUSD float64) error {
+	if costUSD <= 0 {
+		return nil
+	}
+
+	var spendCap models.SpendCap
+	var spentUSD float64
+	var softAlertedAt, capReachedAt *time.Time
+	err := tx.QueryRow(
+		ctx,
+		`WITH spend AS (
+		     INSERT INTO tenant_spend (tenant_id, month, spent_usd, updated_at)
+		     VALUES ($1, `+spendMonth+`, $2, now())
+		     ON CONFLICT (tenant_id, month) DO UPDATE
+		     SET spent_usd = tenant_spend.spent_usd + EXCLUDED.spent_usd, updated_at = now()
+		     RETURNING tenant_id, spent_usd, soft_alerted_at, cap_reached_at
+		 )
+		 SELECT spend.tenant_id, l.monthly_spend_cap_usd, l.spend_cap_override_usd, l.spend_cap_override_until, l.updated_at,
+		        spend.spent_usd, spend.soft_alerted_at, spend.cap_reached_at
+		 FROM spend
+		 LEFT JOIN tenant_limits l ON l.tenant_id = spend.tenant_id`,
+		tenantID,
+		costUSD,
+	).Scan(append(spendCapScanTargets(&spendCap), &spentUSD, &softAlertedAt, &capReachedAt)...)
+	if err != nil {
+		return fmt.Errorf("record tenant spend: %w", err)
+	}
+
+	status := newSpendStatus(spendCap, spentUSD, softAlertedAt, capReachedAt, time.Now())
+	eventType, capReached := spendAlert(status)
+	if eventType == "" {
+		return nil
+	}
+	// Reaching the cap also counts as the soft alert, so a jump past both
+	// thresholds does not send a stale soft alert later.
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE tenant_spend
+		 SET soft_alerted_at = COALESCE(soft_alerted_at, now()),
+		     cap_reached_at = CASE WHEN $2 THEN now() ELSE cap_reached_at END
+		 WHERE tenant_id = $1 AND month = `+spendMonth,
+		tenantID,
+		capReached,
+	); err != nil {
+		return fmt.Errorf("record spend alert: %w", err)
+	}
+	return appendEventTx(ctx, tx, eventType, &jobID, &workerID, spendAlertDetails(status))
+}
+
+// spendAlert returns the event to record for status, if any, and whether it
+// is the cap being reached rather than the soft alert.
+func spendAlert(status models.SpendStatus) (eventType string, capReached bool) {
+	if status.EffectiveCapUSD == nil {
+		return "", false
+	}
+	switch {
+	case status.OverCap && status.CapReachedAt == nil:
+		return "tenant.spend_cap_reached", true
+	case status.SpentUSD >= *status.EffectiveCapUSD*models.SpendAlertRatio && status.SoftAlertedAt == nil:
+		return "tenant.spend_alert", false
+	}
+	return "", false
+}
+
+func spendAlertDetails(status models.SpendStatus) string {
+	return fmt.Sprintf("Tenant %s has spent $%.2f of its $%.2f monthly cap for %s",
+		status.TenantID, status.SpentUSD, *status.EffectiveCapUSD, status.Month)
+}
+
+// DeferJob returns a job the worker has just marked running to scheduled
+// until runAt without counting the attempt, recording why in the job's error
+// fields. It is used when the job may not run yet, for example because its
+// tenant is over its spend cap.
+func (s *PostgresStore) DeferJob(ctx context.Context, jobID string, workerID string, runAt time.Time, errorCode string, errorMessage string) error {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	var attempt int
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE jobs
+		 SET status = 'scheduled',
+		     run_at = $2,
+		     attempt = attempt - 1,
+		     started_at = (SELECT a.started_at FROM job_attempts a WHERE a.job_id = jobs.id AND a.attempt = jobs.attempt - 1),
+		     model_used = (SELECT a.model FROM job_attempts a WHERE a.job_id = jobs.id AND a.attempt = jobs.attempt - 1),
+		     error_code = $3,
+		     error_message = $4
+		 WHERE id = $1 AND status = 'running'
+		 RETURNING attempt + 1`,
+		jobID,
+		runAt,
+		errorCode,
+		errorMessage,
+	).Scan(&attempt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("defer job: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM job_attempts WHERE job_id = $1 AND attempt = $2`, jobID, attempt); err != nil {
+		return fmt.Errorf("delete deferred attempt: %w", err)
+	}
+	if err := appendEventTx(ctx, tx, "job.held", &jobID, &workerID, errorMessage+"; retrying at "+runAt.UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit defer job: %w", err)
+	}
+	return nil
+}
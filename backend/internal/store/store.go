@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// Store is the persistence used by the job service, the worker runner and
+// the background loops. PostgresStore is the production implementation;
+// MemoryStore keeps the same state transitions in memory for tests.
+type Store interface {
+	CreateJob(ctx context.Context, input models.CreateJobInput) (models.Job, bool, error)
+	CreateJobsBatch(ctx context.Context, inputs []models.CreateJobInput, atomic bool) ([]models.CreateJobResult, error)
+	// ReplayedInputs reports, per input, whether its idempotency key is held
+	// by an existing job, so that creating it would replay that job.
+	ReplayedInputs(ctx context.Context, inputs []models.CreateJobInput) ([]bool, error)
+	GetJobByID(ctx context.Context, jobID string) (models.Job, *models.JobAttempt, error)
+	ListJobAttempts(ctx context.Context, jobID string) (models.JobAttemptHistory, error)
+	ListJobs(ctx context.Context, filter models.ListJobsFilter) (models.JobPage, error)
+	CancelJob(ctx context.Context, jobID string, reason string) (models.Job, error)
+	RetryJob(ctx context.Context, jobID string, reason string) (models.Job, error)
+	RescheduleJob(ctx context.Context, jobID string, runAt time.Time) (models.Job, error)
+	PromoteDueJobs(ctx context.Context, limit int) ([]string, error)
+	ExpireJob(ctx context.Context, jobID string, workerID string) (bool, error)
+	ExpireOverdueJobs(ctx context.Context, limit int) ([]string, error)
+
+	MarkJobRunning(ctx context.Context, jobID string, workerID string) (models.Job, bool, error)
+	MarkJobSucceeded(ctx context.Context, jobID string, workerID string, usage models.Usage, costUSD float64, providerMeta json.RawMessage, result json.RawMessage) error
+	MarkJobFailed(ctx context.Context, jobID string, workerID string, errorCode string, errorMessage string) error
+	FallBackJob(ctx context.Context, jobID string, workerID string, errorCode string, errorMessage string, model string) (models.Job, error)
+	DeferJob(ctx context.Context, jobID string, workerID string, runAt time.Time, errorCode string, errorMessage string) error
+	UpsertWorkerHeartbeat(ctx context.Context, workerID string, state string, runningJobID string, concurrency int) error
+	// RecordEvent appends an event that is not part of a job state change;
+	// empty IDs are stored as NULL.
+	RecordEvent(ctx context.Context, eventType string, jobID string, workerID string, details string) error
+
+	GetDependencyOutputs(ctx context.Context, jobID string) ([]models.DependencyOutput, error)
+	GetDependencyIDs(ctx context.Context, jobID string) ([]string, error)
+
+	CreateGroup(ctx context.Context, input models.CreateJobGroupInput) (models.JobGroup, error)
+	GetGroup(ctx context.Context, groupID string) (models.JobGroup, error)
+	GetGroupSummary(ctx context.Context, groupID string) (models.JobGroupSummary, error)
+	CancelGroup(ctx context.Context, groupID string, reason string) ([]string, error)
+	RetryFailedGroup(ctx context.Context, groupID string) ([]string, error)
+
+	CreateSchedule(ctx context.Context, input models.CreateScheduleInput, nextFireAt time.Time) (models.Schedule, error)
+	GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error)
+	ListSchedules(ctx context.Context, tenantID string) ([]models.Schedule, error)
+	SetSchedulePaused(ctx context.Context, scheduleID string, paused bool, nextFireAt *time.Time) (models.Schedule, error)
+	DeleteSchedule(ctx context.Context, scheduleID string) error
+
+	UpsertRetentionPolicy(ctx context.Context, policy models.RetentionPolicy) (models.RetentionPolicy, error)
+	GetRetentionPolicy(ctx context.Context, tenantID string) (models.RetentionPolicy, error)
+	ListRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error)
+	DeleteRetentionPolicy(ctx context.Context, tenantID string) error
+
+	CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error)
+	GetAPIKey(ctx context.Context, keyID string) (models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context, tenantID string) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) (models.APIKey, error)
+	RotateAPIKey(ctx context.Context, keyID string, prefix string, keyHash string, oldExpiresAt time.Time) (models.APIKey, error)
+
+	CreateModelPrice(ctx context.Context, price models.ModelPrice) (models.ModelPrice, error)
+	ListModelPrices(ctx context.Context, model string) ([]models.ModelPrice, error)
+	GetModelPrice(ctx context.Context, model string, at time.Time) (models.ModelPrice, error)
+
+	UpsertFallbackPolicy(ctx context.Context, policy models.FallbackPolicy) (models.FallbackPolicy, error)
+	GetFallbackPolicy(ctx context.Context, tenantID string, model string) (models.FallbackPolicy, error)
+	ListFallbackPolicies(ctx context.Context, tenantID string) ([]models.FallbackPolicy, error)
+	DeleteFallbackPolicy(ctx context.Context, tenantID string, model string) error
+
+	// InTx runs fn in one transaction; store calls made with the context fn
+	// receives join it. Audited actions record their entry through it, so the
+	// change and its audit entry commit together.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	AppendAudit(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error)
+	ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+
+	UsageReport(ctx context.Context, filter models.UsageFilter) ([]models.UsageRow, error)
+
+	GetSpendStatus(ctx context.Context, tenantID string) (models.SpendStatus, error)
+	ListSpendStatuses(ctx context.Context) ([]models.SpendStatus, error)
+	SetSpendCap(ctx context.Context, tenantID string, capUSD *float64) (models.SpendCap, error)
+	SetSpendCapOverride(ctx context.Context, tenantID string, capUSD *float64, until *time.Time) (models.SpendCap, error)
+
+	RelayOutbox(ctx context.Context, limit int, publish func(ctx context.Context, entries []models.OutboxEntry) error) (int, error)
+}
+
+var (
+	_ Store = (*PostgresStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
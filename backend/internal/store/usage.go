@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+const usageAmountColumns = `attempts, failed_attempts, prompt_tokens, cached_prompt_tokens, completion_tokens, tokens, cost_usd, failed_cost_usd`
+
+// usageAggregate sums attempts finished in [$1, $2) per UTC day, tenant and
+// the model the attempt ran on, for the tenant in $3 or every tenant when it
+// is empty. from and to override the bounds with other expressions.
+func usageAggregate(from string, to string) string {
+	return `SELECT (a.finished_at AT TIME ZONE 'UTC')::date AS day,
+	       j.tenant_id,
+	       COALESCE(a.model, j.model),
+	       count(*) AS attempts,
+	       count(*) FILTER (WHERE a.success IS NOT TRUE) AS failed_attempts,
+	       COALESCE(sum(a.prompt_tokens), 0) AS prompt_tokens,
+	       COALESCE(sum(a.cached_prompt_tokens), 0) AS cached_prompt_tokens,
+	       COALESCE(sum(a.completion_tokens), 0) AS completion_tokens,
+	       COALESCE(sum(a.tokens), 0) AS tokens,
+	       COALESCE(sum(a.cost_usd), 0) AS cost_usd,
+	       COALESCE(sum(a.cost_usd) FILTER (WHERE a.success IS NOT TRUE), 0) AS failed_cost_usd
+	FROM job_attempts a
+	JOIN jobs j ON j.id = a.job_id
+	WHERE a.finished_at >= ` + from + ` AND a.finished_at < ` + to + `
+	  AND ($3 = '' OR j.tenant_id = $3)
+	GROUP BY 1, 2, 3`
+}
+
+// UsageReport sums the usage of finished attempts. Days before the rollup
+// watermark are read from usage_daily; later days are aggregated from
+// job_attempts, so the report is current up to the last finished attempt.
+func (s *PostgresStore) UsageReport(ctx context.Context, filter models.UsageFilter) ([]models.UsageRow, error) {
+	key := `to_char(day, 'YYYY-MM-DD')`
+	if filter.GroupBy == models.UsageGroupByModel {
+		key = `model`
+	}
+	rows, err := s.db(ctx).Query(
+		ctx,
+		`WITH state AS (
+		     SELECT rolled_up_to, (rolled_up_to::timestamp AT TIME ZONE 'UTC') AS rolled_up_at FROM usage_rollup_state
+		 ), usage AS (
+		     SELECT day, tenant_id, model, `+usageAmountColumns+`
+		     FROM usage_daily, state
+		     WHERE day >= ($1::timestamptz AT TIME ZONE 'UTC')::date
+		       AND day < LEAST(($2::timestamptz AT TIME ZONE 'UTC')::date, state.rolled_up_to)
+		       AND ($3 = '' OR tenant_id = $3)
+		     UNION ALL
+		     `+usageAggregate(`GREATEST($1::timestamptz, (SELECT rolled_up_at FROM state))`, `$2`)+`
+		 )
+		 SELECT tenant_id, `+key+`, sum(attempts)::bigint, sum(failed_attempts)::bigint, sum(prompt_tokens)::bigint,
+		        sum(cached_prompt_tokens)::bigint, sum(completion_tokens)::bigint, sum(tokens)::bigint,
+		        sum(cost_usd), sum(failed_cost_usd)
+		 FROM usage
+		 GROUP BY 1, 2
+		 ORDER BY 1, 2`,
+		filter.From,
+		filter.To.AddDate(0, 0, 1),
+		filter.TenantID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("usage report query: %w", err)
+	}
+	defer rows.Close()
+
+	report := make([]models.UsageRow, 0)
+	for rows.Next() {
+		var row models.UsageRow
+		var groupKey string
+		if err := rows.Scan(
+			&row.TenantID,
+			&groupKey,
+			&row.Attempts,
+			&row.FailedAttempts,
+			&row.PromptTokens,
+			&row.CachedPromptTokens,
+			&row.CompletionTokens,
+			&row.Tokens,
+			&row.CostUSD,
+			&row.FailedCostUSD,
+		); err != nil {
+			return nil, fmt.Errorf("usage report scan: %w", err)
+		}
+		if filter.GroupBy == models.UsageGroupByModel {
+			row.Model = groupKey
+		} else {
+			row.Day = groupKey
+		}
+		report = append(report, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("usage report rows: %w", err)
+	}
+	return report, nil
+}
+
+// RollUpUsage writes usage_daily for up to maxDays days after the watermark
+// that ended before until, and advances the watermark past them. It returns
+// the number of days rolled up. A day is rewritten whole, so rolling it up
+// again is harmless.
+func (s *PostgresStore) RollUpUsage(ctx context.Context, until time.Time, maxDays int) (int, error) {
+	tx, err := s.db(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	var rolledUpTo time.Time
+	if err := tx.QueryRow(ctx, `SELECT rolled_up_to FROM usage_rollup_state FOR UPDATE`).Scan(&rolledUpTo); err != nil {
+		return 0, fmt.Errorf("lock usage rollup state: %w", err)
+	}
+	from := time.Date(rolledUpTo.Year(), rolledUpTo.Month(), rolledUpTo.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, maxDays)
+	if limit := dayStart(until); to.After(limit) {
+		to = limit
+	}
+	if !to.After(from) {
+		return 0, nil
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM usage_daily
+		 WHERE day >= ($1::timestamptz AT TIME ZONE 'UTC')::date AND day < ($2::timestamptz AT TIME ZONE 'UTC')::date`,
+		from,
+		to,
+	); err != nil {
+		return 0, fmt.Errorf("clear usage days: %w", err)
+	}
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO usage_daily (day, tenant_id, model, `+usageAmountColumns+`) `+usageAggregate(`$1`, `$2`),
+		from,
+		to,
+		"",
+	); err != nil {
+		return 0, fmt.Errorf("roll up usage: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE usage_rollup_state SET rolled_up_to = ($1::timestamptz AT TIME ZONE 'UTC')::date`, to); err != nil {
+		return 0, fmt.Errorf("advance usage rollup state: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit usage rollup: %w", err)
+	}
+	return int(to.Sub(from).Hours() / 24), nil
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
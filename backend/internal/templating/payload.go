@@ -0,0 +1,97 @@
+package templating
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"job-queue-llm-orchestrator/backend/internal/models"
+)
+
+// Data is what payload templates are evaluated against. Parents follows the
+// order of depends_on, so `{{ (index .Parents 0).text }}` reads the first
+// parent's result; ParentsByID addresses parents by job ID.
+type Data struct {
+	Parents     []any
+	ParentsByID map[string]any
+}
+
+// RenderPayload evaluates every string in payload that contains a template
+// action against the parents' results. Payloads without templates are
+// returned unchanged.
+func RenderPayload(payload json.RawMessage, parents []models.DependencyOutput) (json.RawMessage, error) {
+	if len(parents) == 0 || !bytes.Contains(payload, []byte("{{")) {
+		return payload, nil
+	}
+
+	data := Data{
+		Parents:     make([]any, 0, len(parents)),
+		ParentsByID: make(map[string]any, len(parents)),
+	}
+	for _, parent := range parents {
+		var result any
+		if len(parent.Result) > 0 {
+			if err := json.Unmarshal(parent.Result, &result); err != nil {
+				return nil, fmt.Errorf("decode result of parent %s: %w", parent.JobID, err)
+			}
+		}
+		data.Parents = append(data.Parents, result)
+		data.ParentsByID[parent.JobID] = result
+	}
+
+	var document any
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	rendered, err := renderValue(document, data)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("encode rendered payload: %w", err)
+	}
+	return out, nil
+}
+
+func renderValue(value any, data Data) (any, error) {
+	switch typed := value.(type) {
+	case string:
+		if !strings.Contains(typed, "{{") {
+			return typed, nil
+		}
+		tmpl, err := template.New("payload").Option("missingkey=error").Parse(typed)
+		if err != nil {
+			return nil, fmt.Errorf("parse payload template: %w", err)
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, fmt.Errorf("execute payload template: %w", err)
+		}
+		return out.String(), nil
+	case map[string]any:
+		for key, child := range typed {
+			rendered, err := renderValue(child, data)
+			if err != nil {
+				return nil, err
+			}
+			typed[key] = rendered
+		}
+		return typed, nil
+	case []any:
+		for i, child := range typed {
+			rendered, err := renderValue(child, data)
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = rendered
+		}
+		return typed, nil
+	default:
+		return typed, nil
+	}
+}
@@ -0,0 +1,56 @@
+// Package usage keeps the daily usage rollup behind the usage report.
+package usage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+// rollupLockKey is the Postgres advisory lock key that elects the single
+// process allowed to write the rollup.
+const rollupLockKey int64 = 0x6a6f627573616765 // "jobusage"
+
+// settleDelay is how long after a UTC day ends it is rolled up, leaving time
+// for attempts that finished just before midnight to commit.
+const settleDelay = time.Hour
+
+// daysPerBatch bounds the days rolled up in one transaction while backfilling.
+const daysPerBatch = 7
+
+// Rollup writes each finished UTC day's usage into usage_daily once, so usage
+// reports over long ranges read a row per tenant, model and day instead of
+// every attempt. Reports aggregate the days not yet rolled up on the fly.
+type Rollup struct {
+	store    *store.PostgresStore
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewRollup(store *store.PostgresStore, interval time.Duration, logger *slog.Logger) *Rollup {
+	return &Rollup{store: store, interval: interval, logger: logger}
+}
+
+// Run rolls up settled days every interval while this process is the rollup
+// leader, starting with a pass at startup.
+func (r *Rollup) Run(ctx context.Context) error {
+	r.store.RunAsLeader(ctx, rollupLockKey, "usage rollup", r.interval, r.logger, r.runOnce)
+	return nil
+}
+
+// runOnce rolls up every settled day, a batch at a time.
+func (r *Rollup) runOnce(ctx context.Context) {
+	for ctx.Err() == nil {
+		days, err := r.store.RollUpUsage(ctx, time.Now().Add(-settleDelay), daysPerBatch)
+		if err != nil {
+			r.logger.Error("usage rollup failed", "error", err)
+			return
+		}
+		if days == 0 {
+			return
+		}
+		r.logger.Info("rolled up usage", "days", days)
+	}
+}
@@ -0,0 +1,175 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/config"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+// Dispatcher delivers group completion webhooks. Delivery is at-least-once:
+// a group is only marked delivered after the receiver answered with 2xx.
+type Dispatcher struct {
+	store  *store.PostgresStore
+	client *http.Client
+	cfg    config.Config
+	logger *slog.Logger
+}
+
+func NewDispatcher(store *store.PostgresStore, cfg config.Config, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: newClient(cfg.WebhookTimeout, cfg.WebhookPrivate),
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// newClient returns the HTTP client webhooks are posted with. Unless
+// allowPrivate is set, it refuses to connect to loopback, private, link-local
+// and other non-public addresses, checked after DNS resolution and on every
+// redirect, so a tenant's webhook_url cannot reach services inside the
+// cluster. It never uses a proxy, which would hide the real target.
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = rejectNonPublic
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// rejectNonPublic is a net.Dialer Control hook that fails connections to
+// addresses that are not publicly routable.
+func rejectNonPublic(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook target %q: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublic(ip) {
+		return fmt.Errorf("webhook target %s is not a public address", host)
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which net.IP does not
+// classify as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublic(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		if ip4[0] == 0 || sharedAddressSpace.Contains(ip4) {
+			return false
+		}
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// signatureHeader carries the HMAC of each delivery as "t=<unix seconds>,
+// v1=<hex HMAC-SHA256 of "<t>.<body>">". Receivers recompute it with the shared
+// secret and reject stale timestamps to stop replays.
+const signatureHeader = "X-Webhook-Signature"
+
+func sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.cfg.WebhookPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		groups, err := d.store.ClaimGroupWebhooks(ctx, 20, d.cfg.WebhookMaxTries, d.cfg.WebhookBackoff)
+		if err != nil {
+			d.logger.Error("claim group webhooks failed", "error", err)
+			continue
+		}
+
+		for _, group := range groups {
+			if err := d.deliver(ctx, group); err != nil {
+				d.logger.Warn("group webhook delivery failed", "group_id", group.ID, "error", err)
+				if markErr := d.store.MarkGroupWebhookFailed(ctx, group.ID, err.Error()); markErr != nil {
+					d.logger.Error("record group webhook failure", "group_id", group.ID, "error", markErr)
+				}
+				continue
+			}
+			if err := d.store.MarkGroupWebhookDelivered(ctx, group.ID); err != nil {
+				d.logger.Error("mark group webhook delivered", "group_id", group.ID, "error", err)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, group models.JobGroup) error {
+	summary, err := d.store.GetGroupSummary(ctx, group.ID)
+	if err != nil {
+		return fmt.Errorf("load group summary: %w", err)
+	}
+
+	body, err := json.Marshal(groupWebhookPayload{
+		Event:   "group.completed",
+		Summary: summary,
+	})
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, group.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Group-Id", group.ID)
+	if d.cfg.WebhookSecret != "" {
+		request.Header.Set(signatureHeader, sign(d.cfg.WebhookSecret, time.Now(), body))
+	}
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer response.Body.Close() //nolint:errcheck
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook receiver returned status %d", response.StatusCode)
+	}
+	return nil
+}
+
+type groupWebhookPayload struct {
+	Event   string                 `json:"event"`
+	Summary models.JobGroupSummary `json:"summary"`
+}
@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublic(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestClientRefusesPrivateTargets(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	_, err := newClient(time.Second, false).Post(receiver.URL, "application/json", strings.NewReader("{}"))
+	if err == nil || !strings.Contains(err.Error(), "not a public address") {
+		t.Fatalf("post to loopback: err = %v, want a refusal", err)
+	}
+
+	response, err := newClient(time.Second, true).Post(receiver.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("post with private targets allowed: %v", err)
+	}
+	response.Body.Close() //nolint:errcheck
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"event":"group.completed"}' | openssl dgst -sha256 -hmac secret
+	got := sign("secret", time.Unix(1700000000, 0), []byte(`{"event":"group.completed"}`))
+	want := "t=1700000000,v1=f870a5d42731d847c699ec150ed321219d00585eb748db4132e1a991ee1cf566"
+	if got != want {
+		t.Fatalf("sign = %s, want %s", got, want)
+	}
+}
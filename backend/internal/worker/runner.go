@@ -3,33 +3,56 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
 	"time"
 
+	"job-queue-llm-orchestrator/backend/internal/breaker"
 	"job-queue-llm-orchestrator/backend/internal/config"
 	"job-queue-llm-orchestrator/backend/internal/models"
 	"job-queue-llm-orchestrator/backend/internal/queue"
 	"job-queue-llm-orchestrator/backend/internal/store"
+	"job-queue-llm-orchestrator/backend/internal/telemetry"
+	"job-queue-llm-orchestrator/backend/internal/templating"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("job-queue-llm-orchestrator/backend/internal/worker")
+
+// providerName identifies the model provider in circuit breakers and attempt
+// metadata.
+const providerName = "mock-llm"
+
 type Runner struct {
-	store  *store.PostgresStore
-	queue  *queue.RedisQueue
-	cfg    config.Config
-	logger *slog.Logger
-	rng    *rand.Rand
+	store    store.Store
+	queue    queue.Queue
+	breakers *breaker.Breakers
+	cfg      config.Config
+	logger   *slog.Logger
+	rng      *rand.Rand
+
+	// complete calls the model provider; tests replace it.
+	complete func(ctx context.Context, job models.Job, payload json.RawMessage) (json.RawMessage, models.Usage, error)
 }
 
-func NewRunner(store *store.PostgresStore, queue *queue.RedisQueue, cfg config.Config, logger *slog.Logger) *Runner {
-	return &Runner{
-		store:  store,
-		queue:  queue,
-		cfg:    cfg,
-		logger: logger,
-		rng:    rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+// NewRunner builds a worker. With nil breakers every provider call goes ahead.
+func NewRunner(store store.Store, queue queue.Queue, breakers *breaker.Breakers, cfg config.Config, logger *slog.Logger) *Runner {
+	r := &Runner{
+		store:    store,
+		queue:    queue,
+		breakers: breakers,
+		cfg:      cfg,
+		logger:   logger,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
 	}
+	r.complete = r.callProvider
+	return r
 }
 
 func (r *Runner) Run(ctx context.Context) error {
@@ -66,6 +89,9 @@ func (r *Runner) Run(ctx context.Context) error {
 
 		jobID, err := r.queue.DequeueJob(ctx, 2*time.Second)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
 			r.logger.Error("dequeue failed", "error", err)
 			time.Sleep(750 * time.Millisecond)
 			continue
@@ -73,6 +99,7 @@ func (r *Runner) Run(ctx context.Context) error {
 		if jobID == "" {
 			continue
 		}
+		dequeuedAt := time.Now()
 
 		leaseAcquired, err := r.queue.AcquireLease(ctx, jobID, r.cfg.WorkerID)
 		if err != nil {
@@ -82,6 +109,7 @@ func (r *Runner) Run(ctx context.Context) error {
 		if !leaseAcquired {
 			continue
 		}
+		leasedAt := time.Now()
 
 		job, updated, err := r.store.MarkJobRunning(ctx, jobID, r.cfg.WorkerID)
 		if err != nil {
@@ -90,31 +118,80 @@ func (r *Runner) Run(ctx context.Context) error {
 			continue
 		}
 		if !updated {
+			// A queued job past its deadline is expired here rather than run.
+			expired, err := r.store.ExpireJob(ctx, jobID, r.cfg.WorkerID)
+			if err != nil {
+				r.logger.Error("expire job failed", "job_id", jobID, "error", err)
+			} else if expired {
+				r.logger.Info("skipped job past its deadline", "job_id", jobID)
+			}
 			_ = r.queue.ReleaseLease(ctx, jobID)
 			continue
 		}
+		if r.holdOverSpendCap(ctx, job) || r.holdOpenCircuit(ctx, job) {
+			_ = r.queue.ReleaseLease(ctx, jobID)
+			continue
+		}
+		markedRunningAt := time.Now()
 
 		state = "busy"
 		runningJobID = job.ID
 		emitHeartbeat()
 
-		runErr := r.executeJob(ctx, job)
+		// The trace context only becomes known once the job row is loaded, so
+		// the queue wait, lease and running transition spans are recorded
+		// retroactively with their measured timestamps.
+		jobCtx, jobSpan := r.startJobSpan(ctx, job, dequeuedAt)
+		// The wait starts when the job last became runnable, not at creation,
+		// so delays, holds and earlier attempts are not counted as queueing.
+		if job.QueuedAt != nil {
+			r.recordSpan(jobCtx, "queue.wait", *job.QueuedAt, dequeuedAt)
+		}
+		r.recordSpan(jobCtx, "queue.lease", dequeuedAt, leasedAt)
+		r.recordSpan(jobCtx, "db.mark_running", leasedAt, markedRunningAt)
+
+		result, usage, runErr := r.executeJob(jobCtx, job)
+		fallbackModels, fallbackOn := r.fallbackChain(jobCtx, job)
+		for next := 0; runErr != nil && next < len(fallbackModels) && jobCtx.Err() == nil; next++ {
+			if !containsCode(fallbackOn, errorCode(runErr)) {
+				break
+			}
+			if _, allowed := r.allowCall(jobCtx, job, fallbackModels[next]); !allowed {
+				r.logger.Info("skipping fallback model with an open circuit", "job_id", job.ID, "model", fallbackModels[next])
+				continue
+			}
+			fallback, err := r.store.FallBackJob(jobCtx, job.ID, r.cfg.WorkerID, errorCode(runErr), runErr.Error(), fallbackModels[next])
+			if err != nil {
+				r.logger.Error("fall back to next model failed", "job_id", job.ID, "model", fallbackModels[next], "error", err)
+				r.releaseCall(jobCtx, job, fallbackModels[next])
+				break
+			}
+			r.logger.Info("falling back to next model", "job_id", job.ID, "from", job.ModelUsed, "to", fallback.ModelUsed, "error_code", errorCode(runErr))
+			job = fallback
+			result, usage, runErr = r.executeJob(jobCtx, job)
+		}
 		if runErr != nil {
-			if err := r.store.MarkJobFailed(ctx, job.ID, r.cfg.WorkerID, "PROVIDER_TIMEOUT", runErr.Error()); err != nil {
+			dbCtx, dbSpan := tracer.Start(jobCtx, "db.mark_failed")
+			err := r.store.MarkJobFailed(dbCtx, job.ID, r.cfg.WorkerID, errorCode(runErr), runErr.Error())
+			endSpan(dbSpan, err)
+			if err != nil {
 				r.logger.Error("mark failed update error", "job_id", job.ID, "error", err)
 			}
+			recordSpanError(jobSpan, runErr)
 		} else {
-			tokens := 200 + r.rng.Intn(500)
-			costUSD := float64(tokens) * 0.00001
-			providerMeta := json.RawMessage(`{"provider":"mock-llm","latency_source":"simulated"}`)
-			if err := r.store.MarkJobSucceeded(ctx, job.ID, r.cfg.WorkerID, tokens, costUSD, providerMeta); err != nil {
+			costUSD, providerMeta := r.priceAttempt(jobCtx, job, usage)
+			dbCtx, dbSpan := tracer.Start(jobCtx, "db.mark_succeeded")
+			err := r.store.MarkJobSucceeded(dbCtx, job.ID, r.cfg.WorkerID, usage, costUSD, providerMeta, result)
+			endSpan(dbSpan, err)
+			if err != nil {
 				r.logger.Error("mark success update error", "job_id", job.ID, "error", err)
 			}
 		}
 
-		if err := r.queue.ReleaseLease(ctx, job.ID); err != nil {
+		if err := r.queue.ReleaseLease(jobCtx, job.ID); err != nil {
 			r.logger.Warn("failed to release lease", "job_id", job.ID, "error", err)
 		}
+		jobSpan.End()
 
 		state = "idle"
 		runningJobID = ""
@@ -122,21 +199,299 @@ func (r *Runner) Run(ctx context.Context) error {
 	}
 }
 
-func (r *Runner) executeJob(ctx context.Context, job models.Job) error {
+// holdOverSpendCap puts a job whose tenant has reached its monthly spend cap
+// back on the schedule instead of running it, and reports whether it did. The
+// job is checked again after SpendHoldRecheck, so it runs once the cap is
+// raised, overridden or the month rolls over. If the spend cannot be read the
+// job runs.
+func (r *Runner) holdOverSpendCap(ctx context.Context, job models.Job) bool {
+	status, err := r.store.GetSpendStatus(ctx, job.TenantID)
+	if err != nil {
+		r.logger.Error("spend cap check failed; running job", "job_id", job.ID, "tenant_id", job.TenantID, "error", err)
+		return false
+	}
+	if !status.OverCap {
+		return false
+	}
+
+	message := fmt.Sprintf("Tenant has spent $%.2f of its $%.2f monthly cap", status.SpentUSD, *status.EffectiveCapUSD)
+	if err := r.store.DeferJob(ctx, job.ID, r.cfg.WorkerID, time.Now().Add(r.cfg.SpendHoldRecheck), "SPEND_CAP_EXCEEDED", message); err != nil {
+		r.logger.Error("hold job over spend cap failed", "job_id", job.ID, "error", err)
+		return false
+	}
+	r.logger.Info("held job over spend cap", "job_id", job.ID, "tenant_id", job.TenantID)
+	return true
+}
+
+// holdOpenCircuit puts a job back on the schedule instead of running it when
+// the circuit breaker for its model rejects the call, and reports whether it
+// did. The job runs again once the breaker lets probes through.
+func (r *Runner) holdOpenCircuit(ctx context.Context, job models.Job) bool {
+	circuit, allowed := r.allowCall(ctx, job, job.ModelUsed)
+	if allowed {
+		return false
+	}
+
+	message := fmt.Sprintf("Circuit breaker for %s/%s is %s", providerName, job.ModelUsed, circuit.State)
+	if err := r.store.DeferJob(ctx, job.ID, r.cfg.WorkerID, *circuit.RetryAt, "CIRCUIT_OPEN", message); err != nil {
+		r.logger.Error("hold job behind open circuit failed", "job_id", job.ID, "error", err)
+		return false
+	}
+	r.logger.Info("held job behind open circuit", "job_id", job.ID, "model", job.ModelUsed, "retry_at", circuit.RetryAt)
+	return true
+}
+
+// allowCall asks the circuit breaker for model whether the job may call it.
+// The call goes ahead when breakers are off or cannot be reached.
+func (r *Runner) allowCall(ctx context.Context, job models.Job, model string) (models.CircuitBreaker, bool) {
+	if r.breakers == nil {
+		return models.CircuitBreaker{}, true
+	}
+	result, err := r.breakers.Allow(ctx, providerName, model)
+	if err != nil {
+		r.logger.Error("circuit breaker check failed; calling provider", "job_id", job.ID, "model", model, "error", err)
+		return models.CircuitBreaker{}, true
+	}
+	r.recordBreakerChange(ctx, job, result)
+	return result.Breaker, result.Allowed
+}
+
+// breakerFailureCodes are the errors that count against a circuit breaker:
+// timeouts and PROVIDER_ERROR, a 5xx from the provider. Any other outcome
+// means the provider answered.
+var breakerFailureCodes = []string{"PROVIDER_TIMEOUT", "PROVIDER_ERROR"}
+
+// recordCall reports the outcome of a provider call to its circuit breaker.
+// Calls cut short by the worker shutting down say nothing about the provider.
+func (r *Runner) recordCall(ctx context.Context, job models.Job, callErr error) {
+	if r.breakers == nil || ctx.Err() != nil {
+		return
+	}
+	failed := callErr != nil && containsCode(breakerFailureCodes, errorCode(callErr))
+	result, err := r.breakers.Record(ctx, providerName, job.ModelUsed, failed)
+	if err != nil {
+		r.logger.Error("circuit breaker update failed", "job_id", job.ID, "model", job.ModelUsed, "error", err)
+		return
+	}
+	r.recordBreakerChange(ctx, job, result)
+}
+
+// releaseCall hands back the breaker slot allowCall took for model when the
+// job fails before calling the provider, so a half-open breaker is not left
+// waiting for a probe that never runs.
+func (r *Runner) releaseCall(ctx context.Context, job models.Job, model string) {
+	if r.breakers == nil {
+		return
+	}
+	if err := r.breakers.Release(ctx, providerName, model); err != nil {
+		r.logger.Error("circuit breaker release failed", "job_id", job.ID, "model", model, "error", err)
+	}
+}
+
+func (r *Runner) recordBreakerChange(ctx context.Context, job models.Job, result breaker.Result) {
+	if !result.Changed {
+		return
+	}
+	details := breaker.Describe(result.Breaker)
+	r.logger.Warn("circuit breaker changed state", "provider", result.Breaker.Provider, "model", result.Breaker.Model, "state", result.Breaker.State)
+	if err := r.store.RecordEvent(ctx, "circuit_breaker."+string(result.Breaker.State), job.ID, r.cfg.WorkerID, details); err != nil {
+		r.logger.Error("record circuit breaker event failed", "job_id", job.ID, "error", err)
+	}
+}
+
+// fallbackChain returns the models to try after the requested one and the
+// error codes that move a job along the chain. A job's own chain takes
+// precedence over its tenant's policy for the requested model.
+func (r *Runner) fallbackChain(ctx context.Context, job models.Job) ([]string, []string) {
+	fallbackModels, fallbackOn := job.FallbackModels, job.FallbackOn
+	if len(fallbackModels) == 0 {
+		policy, err := r.store.GetFallbackPolicy(ctx, job.TenantID, job.Model)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return nil, nil
+		case err != nil:
+			r.logger.Error("fallback policy lookup failed; running without fallbacks", "job_id", job.ID, "error", err)
+			return nil, nil
+		}
+		fallbackModels, fallbackOn = policy.FallbackModels, policy.FallbackOn
+	}
+	if len(fallbackOn) == 0 {
+		fallbackOn = models.DefaultFallbackOn
+	}
+	return fallbackModels, fallbackOn
+}
+
+func containsCode(codes []string, code string) bool {
+	for _, candidate := range codes {
+		if candidate == code {
+			return true
+		}
+	}
+	return false
+}
+
+// jobError carries the error code recorded on the job and attempt.
+type jobError struct {
+	code string
+	err  error
+}
+
+func (e *jobError) Error() string { return e.err.Error() }
+func (e *jobError) Unwrap() error { return e.err }
+
+func errorCode(err error) string {
+	var coded *jobError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return "PROVIDER_TIMEOUT"
+}
+
+func (r *Runner) executeJob(ctx context.Context, job models.Job) (json.RawMessage, models.Usage, error) {
+	payload, err := r.renderPayload(ctx, job)
+	if err != nil {
+		r.releaseCall(ctx, job, job.ModelUsed)
+		return nil, models.Usage{}, &jobError{code: "TEMPLATE_ERROR", err: err}
+	}
+	result, usage, err := r.complete(ctx, job, payload)
+	r.recordCall(ctx, job, err)
+	return result, usage, err
+}
+
+// providerMeta is stored with each successful attempt.
+type providerMeta struct {
+	Provider      string `json:"provider"`
+	LatencySource string `json:"latency_source"`
+	// PriceEffectiveFrom identifies the catalog price the cost was computed
+	// with; it is absent when the model had no price.
+	PriceEffectiveFrom *time.Time `json:"price_effective_from,omitempty"`
+}
+
+// priceAttempt computes the cost of usage at the price of the model the attempt
+// ran on, in effect when the attempt started. A model missing from the catalog costs
+// nothing and is logged rather than failing a job that already ran.
+func (r *Runner) priceAttempt(ctx context.Context, job models.Job, usage models.Usage) (float64, json.RawMessage) {
+	startedAt := time.Now()
+	if job.StartedAt != nil {
+		startedAt = *job.StartedAt
+	}
+
+	costUSD := 0.0
+	meta := providerMeta{Provider: providerName, LatencySource: "simulated"}
+	price, err := r.store.GetModelPrice(ctx, job.ModelUsed, startedAt)
+	switch {
+	case err == nil:
+		costUSD = price.Cost(usage)
+		meta.PriceEffectiveFrom = &price.EffectiveFrom
+	case errors.Is(err, store.ErrNotFound):
+		r.logger.Warn("model has no price; recording zero cost", "job_id", job.ID, "model", job.ModelUsed)
+	default:
+		r.logger.Error("model price lookup failed; recording zero cost", "job_id", job.ID, "model", job.ModelUsed, "error", err)
+	}
+
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		encoded = json.RawMessage(`{}`)
+	}
+	return costUSD, encoded
+}
+
+// renderPayload substitutes parent results into the payload of jobs created
+// with depends_on.
+func (r *Runner) renderPayload(ctx context.Context, job models.Job) (json.RawMessage, error) {
+	parents, err := r.store.GetDependencyOutputs(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load parent outputs: %w", err)
+	}
+	return templating.RenderPayload(job.PayloadJSON, parents)
+}
+
+func (r *Runner) callProvider(ctx context.Context, job models.Job, payload json.RawMessage) (result json.RawMessage, usage models.Usage, err error) {
+	ctx, span := tracer.Start(ctx, "provider.call", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("llm.model", job.ModelUsed),
+		attribute.String("llm.provider", providerName),
+	))
+	defer func() { endSpan(span, err) }()
+
 	providerCtx, cancel := context.WithTimeout(ctx, r.cfg.ProviderTimeout)
 	defer cancel()
 
 	latency := time.Duration(500+r.rng.Intn(1400)) * time.Millisecond
 	select {
 	case <-providerCtx.Done():
-		return fmt.Errorf("provider context cancelled: %w", providerCtx.Err())
+		return nil, models.Usage{}, fmt.Errorf("provider context cancelled: %w", providerCtx.Err())
 	case <-time.After(latency):
 	}
 
 	// Keep some failed jobs visible while the retry/DLQ phase is pending.
 	if r.rng.Float64() < 0.2 {
-		return fmt.Errorf("mock provider timeout")
+		return nil, models.Usage{}, fmt.Errorf("mock provider timeout")
+	}
+
+	return mockCompletion(job.ModelUsed, payload)
+}
+
+// mockCompletion echoes the prompt. Its usage estimates four characters per
+// token, the rule of thumb for English text.
+func mockCompletion(model string, payload json.RawMessage) (json.RawMessage, models.Usage, error) {
+	var request struct {
+		Prompt string `json:"prompt"`
+	}
+	_ = json.Unmarshal(payload, &request)
+
+	prompt := request.Prompt
+	if len(prompt) > 120 {
+		prompt = prompt[:120]
+	}
+	text := "Mock completion for: " + prompt
+	result, err := json.Marshal(map[string]string{
+		"model": model,
+		"text":  text,
+	})
+	if err != nil {
+		return nil, models.Usage{}, err
 	}
+	usage := models.Usage{
+		PromptTokens:     estimateTokens(len(payload)),
+		CompletionTokens: estimateTokens(len(text)),
+	}
+	return result, usage, nil
+}
+
+func estimateTokens(chars int) int {
+	return (chars + 3) / 4
+}
+
+// startJobSpan continues the trace stored on the job at creation time.
+func (r *Runner) startJobSpan(ctx context.Context, job models.Job, start time.Time) (context.Context, trace.Span) {
+	parentCtx := telemetry.ExtractTraceparent(ctx, job.Traceparent, job.Tracestate)
+	return tracer.Start(
+		parentCtx,
+		"job.run",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.tenant_id", job.TenantID),
+			attribute.Int("job.attempt", job.Attempt),
+			attribute.String("worker.id", r.cfg.WorkerID),
+		),
+	)
+}
+
+func (r *Runner) recordSpan(ctx context.Context, name string, start time.Time, end time.Time) {
+	_, span := tracer.Start(ctx, name, trace.WithTimestamp(start))
+	span.End(trace.WithTimestamp(end))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		recordSpanError(span, err)
+	}
+	span.End()
+}
 
-	return nil
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
 }
@@ -0,0 +1,552 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"job-queue-llm-orchestrator/backend/internal/breaker"
+	"job-queue-llm-orchestrator/backend/internal/config"
+	"job-queue-llm-orchestrator/backend/internal/models"
+	"job-queue-llm-orchestrator/backend/internal/outbox"
+	"job-queue-llm-orchestrator/backend/internal/queue"
+	"job-queue-llm-orchestrator/backend/internal/store"
+)
+
+const testWorkerID = "worker-test"
+
+// fakeProvider records the rendered payloads it receives and answers with
+// respond, or with an echo of the prompt when respond is nil.
+type fakeProvider struct {
+	mu       sync.Mutex
+	payloads map[string]json.RawMessage
+	respond  func(job models.Job) (json.RawMessage, error)
+}
+
+func (p *fakeProvider) complete(ctx context.Context, job models.Job, payload json.RawMessage) (json.RawMessage, models.Usage, error) {
+	p.mu.Lock()
+	p.payloads[job.ID] = payload
+	p.mu.Unlock()
+	if p.respond != nil {
+		result, err := p.respond(job)
+		return result, models.Usage{}, err
+	}
+	return mockCompletion(job.ModelUsed, payload)
+}
+
+func (p *fakeProvider) payload(jobID string) (json.RawMessage, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	payload, ok := p.payloads[jobID]
+	return payload, ok
+}
+
+func (p *fakeProvider) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.payloads)
+}
+
+type runnerHarness struct {
+	t        *testing.T
+	store    *store.MemoryStore
+	queue    *queue.MemoryQueue
+	provider *fakeProvider
+	runner   *Runner
+}
+
+func newRunnerHarness(t *testing.T, respond func(job models.Job) (json.RawMessage, error)) *runnerHarness {
+	t.Helper()
+	memStore := store.NewMemoryStore()
+	memQueue := queue.NewMemoryQueue(30 * time.Second)
+	cfg := config.Config{WorkerID: testWorkerID, WorkerConcurrency: 1, ProviderTimeout: time.Second}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	provider := &fakeProvider{payloads: make(map[string]json.RawMessage), respond: respond}
+	runner := NewRunner(memStore, memQueue, nil, cfg, logger)
+	runner.complete = provider.complete
+
+	return &runnerHarness{t: t, store: memStore, queue: memQueue, provider: provider, runner: runner}
+}
+
+// start runs the worker and the outbox relay until the test ends.
+func (h *runnerHarness) start() {
+	h.t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	relay := outbox.NewRelay(h.store, h.queue, 5*time.Millisecond, logger)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = relay.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		if err := h.runner.Run(ctx); err != nil {
+			h.t.Errorf("runner stopped: %v", err)
+		}
+	}()
+	h.t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+}
+
+func (h *runnerHarness) createJob(input models.CreateJobInput) models.Job {
+	h.t.Helper()
+	if input.TenantID == "" {
+		input.TenantID = "acme"
+	}
+	if input.Model == "" {
+		input.Model = "gpt-4.1-mini"
+	}
+	job, _, err := h.store.CreateJob(context.Background(), input)
+	if err != nil {
+		h.t.Fatalf("create job: %v", err)
+	}
+	return job
+}
+
+func (h *runnerHarness) job(jobID string) models.Job {
+	h.t.Helper()
+	job, _, err := h.store.GetJobByID(context.Background(), jobID)
+	if err != nil {
+		h.t.Fatalf("get job %s: %v", jobID, err)
+	}
+	return job
+}
+
+// waitForStatus polls until the job reaches status or the test times out.
+func (h *runnerHarness) waitForStatus(jobID string, status models.JobStatus) models.Job {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job := h.job(jobID)
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("job %s is %s, want %s", jobID, job.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitForQueueDrained polls until the worker has taken every queued ID.
+func (h *runnerHarness) waitForQueueDrained() {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for h.queue.Len() > 0 {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("queue still holds %d entries", h.queue.Len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (h *runnerHarness) hasEvent(eventType string, jobID string, workerID string) bool {
+	for _, event := range h.store.Events() {
+		if event.Type == eventType && event.JobID == jobID && event.WorkerID == workerID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRunnerCompletesQueuedJob(t *testing.T) {
+	h := newRunnerHarness(t, nil)
+	h.start()
+
+	job := h.createJob(models.CreateJobInput{PayloadJSON: json.RawMessage(`{"prompt":"Summarize this incident."}`)})
+	done := h.waitForStatus(job.ID, models.JobStatusSucceeded)
+
+	if done.Attempt != 1 || done.StartedAt == nil || done.FinishedAt == nil {
+		t.Fatalf("finished job = %+v", done)
+	}
+	var result map[string]string
+	if err := json.Unmarshal(done.ResultJSON, &result); err != nil {
+		t.Fatalf("decode result %s: %v", done.ResultJSON, err)
+	}
+	if result["text"] != "Mock completion for: Summarize this incident." {
+		t.Fatalf("result = %v", result)
+	}
+
+	history, err := h.store.ListJobAttempts(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("list attempts: %v", err)
+	}
+	if len(history.Attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1", len(history.Attempts))
+	}
+	attempt := history.Attempts[0]
+	if attempt.WorkerID != testWorkerID || attempt.Success == nil || !*attempt.Success || attempt.Tokens <= 0 {
+		t.Fatalf("attempt = %+v", attempt)
+	}
+	if !h.hasEvent("job.started", job.ID, testWorkerID) || !h.hasEvent("job.succeeded", job.ID, testWorkerID) {
+		t.Fatal("missing job.started or job.succeeded event")
+	}
+	if holder, ok := h.queue.LeaseHolder(job.ID); ok {
+		t.Fatalf("lease still held by %s", holder)
+	}
+	if heartbeat, ok := h.store.Heartbeat(testWorkerID); !ok || heartbeat.Concurrency != 1 {
+		t.Fatalf("heartbeat = %+v (reported %v)", heartbeat, ok)
+	}
+}
+
+func TestRunnerRendersParentResults(t *testing.T) {
+	h := newRunnerHarness(t, nil)
+	h.start()
+
+	parent := h.createJob(models.CreateJobInput{PayloadJSON: json.RawMessage(`{"prompt":"extract"}`)})
+	child := h.createJob(models.CreateJobInput{
+		DependsOn:   []string{parent.ID},
+		PayloadJSON: json.RawMessage(`{"prompt":"Summarize: {{ (index .Parents 0).text }}"}`),
+	})
+
+	h.waitForStatus(child.ID, models.JobStatusSucceeded)
+
+	payload, ok := h.provider.payload(child.ID)
+	if !ok {
+		t.Fatal("provider was not called for the child")
+	}
+	var rendered map[string]string
+	if err := json.Unmarshal(payload, &rendered); err != nil {
+		t.Fatalf("decode payload %s: %v", payload, err)
+	}
+	if rendered["prompt"] != "Summarize: Mock completion for: extract" {
+		t.Fatalf("rendered prompt = %q", rendered["prompt"])
+	}
+}
+
+func TestRunnerRecordsFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		respond  func(job models.Job) (json.RawMessage, error)
+		wantCode string
+	}{
+		{
+			name:    "coded provider error",
+			payload: `{"prompt":"hi"}`,
+			respond: func(models.Job) (json.RawMessage, error) {
+				return nil, &jobError{code: "RATE_LIMITED", err: errors.New("slow down")}
+			},
+			wantCode: "RATE_LIMITED",
+		},
+		{
+			name:    "uncoded provider error",
+			payload: `{"prompt":"hi"}`,
+			respond: func(models.Job) (json.RawMessage, error) {
+				return nil, errors.New("mock provider timeout")
+			},
+			wantCode: "PROVIDER_TIMEOUT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newRunnerHarness(t, tt.respond)
+			h.start()
+
+			job := h.createJob(models.CreateJobInput{PayloadJSON: json.RawMessage(tt.payload)})
+			child := h.createJob(models.CreateJobInput{DependsOn: []string{job.ID}})
+
+			failed := h.waitForStatus(job.ID, models.JobStatusFailed)
+			if failed.ErrorCode != tt.wantCode {
+				t.Fatalf("error code = %q, want %q", failed.ErrorCode, tt.wantCode)
+			}
+			if got := h.job(child.ID); got.Status != models.JobStatusCancelled {
+				t.Fatalf("child status = %s, want cancelled", got.Status)
+			}
+			history, err := h.store.ListJobAttempts(context.Background(), job.ID)
+			if err != nil {
+				t.Fatalf("list attempts: %v", err)
+			}
+			if history.Totals.Failed != 1 || history.Attempts[0].ErrorCode != tt.wantCode {
+				t.Fatalf("history = %+v", history)
+			}
+		})
+	}
+}
+
+func TestRunnerFailsBrokenTemplate(t *testing.T) {
+	h := newRunnerHarness(t, nil)
+	h.start()
+
+	parent := h.createJob(models.CreateJobInput{})
+	child := h.createJob(models.CreateJobInput{
+		DependsOn:   []string{parent.ID},
+		PayloadJSON: json.RawMessage(`{"prompt":"{{ .Missing }}"}`),
+	})
+
+	failed := h.waitForStatus(child.ID, models.JobStatusFailed)
+	if failed.ErrorCode != "TEMPLATE_ERROR" || !strings.Contains(failed.ErrorMessage, "template") {
+		t.Fatalf("child = %s: %s", failed.ErrorCode, failed.ErrorMessage)
+	}
+	if _, called := h.provider.payload(child.ID); called {
+		t.Fatal("provider was called with an unrendered payload")
+	}
+}
+
+func TestRunnerSkipsJobsItMustNotRun(t *testing.T) {
+	ctx := context.Background()
+	h := newRunnerHarness(t, nil)
+
+	cancelled := h.createJob(models.CreateJobInput{})
+	if _, err := h.store.CancelJob(ctx, cancelled.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Millisecond)
+	expired := h.createJob(models.CreateJobInput{Deadline: &deadline})
+
+	leased := h.createJob(models.CreateJobInput{})
+	if ok, err := h.queue.AcquireLease(ctx, leased.ID, "worker-other"); err != nil || !ok {
+		t.Fatalf("acquire foreign lease: %v, %v", ok, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	h.start()
+	h.waitForQueueDrained()
+	h.waitForStatus(expired.ID, models.JobStatusFailed)
+
+	if calls := h.provider.calls(); calls != 0 {
+		t.Fatalf("provider called %d times, want 0", calls)
+	}
+	if got := h.job(expired.ID); got.ErrorCode != store.ExpiredCode {
+		t.Fatalf("expired job error code = %q", got.ErrorCode)
+	}
+	if !h.hasEvent("job.expired", expired.ID, testWorkerID) {
+		t.Fatal("missing job.expired event from the worker")
+	}
+	if got := h.job(cancelled.ID); got.Status != models.JobStatusCancelled {
+		t.Fatalf("cancelled job status = %s", got.Status)
+	}
+	// Another worker holds the lease, so the job is left for it.
+	if got := h.job(leased.ID); got.Status != models.JobStatusQueued {
+		t.Fatalf("leased job status = %s, want queued", got.Status)
+	}
+	if holder, _ := h.queue.LeaseHolder(leased.ID); holder != "worker-other" {
+		t.Fatalf("lease holder = %q, want worker-other", holder)
+	}
+	for _, jobID := range []string{cancelled.ID, expired.ID} {
+		if holder, ok := h.queue.LeaseHolder(jobID); ok {
+			t.Fatalf("lease on %s still held by %s", jobID, holder)
+		}
+	}
+}
+
+func TestRunnerPricesAttemptsFromCatalog(t *testing.T) {
+	h := newRunnerHarness(t, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	current := models.ModelPrice{Model: "gpt-4.1-mini", EffectiveFrom: now.Add(-time.Hour), InputPer1K: 0.4, CachedInputPer1K: 0.1, OutputPer1K: 1.6}
+	for _, price := range []models.ModelPrice{
+		{Model: "gpt-4.1-mini", EffectiveFrom: now.Add(-48 * time.Hour), InputPer1K: 9, CachedInputPer1K: 9, OutputPer1K: 9},
+		current,
+		{Model: "gpt-4.1-mini", EffectiveFrom: now.Add(time.Hour), InputPer1K: 5, CachedInputPer1K: 5, OutputPer1K: 5},
+	} {
+		if _, err := h.store.CreateModelPrice(ctx, price); err != nil {
+			t.Fatalf("create price: %v", err)
+		}
+	}
+	h.start()
+
+	priced := h.createJob(models.CreateJobInput{PayloadJSON: json.RawMessage(`{"prompt":"Summarize this incident."}`)})
+	unpriced := h.createJob(models.CreateJobInput{Model: "unlisted", PayloadJSON: json.RawMessage(`{"prompt":"hi"}`)})
+	h.waitForStatus(priced.ID, models.JobStatusSucceeded)
+	h.waitForStatus(unpriced.ID, models.JobStatusSucceeded)
+
+	history, err := h.store.ListJobAttempts(ctx, priced.ID)
+	if err != nil {
+		t.Fatalf("list attempts: %v", err)
+	}
+	attempt := history.Attempts[0]
+	if attempt.PromptTokens <= 0 || attempt.CompletionTokens <= 0 || attempt.Tokens != attempt.PromptTokens+attempt.CompletionTokens {
+		t.Fatalf("attempt tokens = %d (prompt %d, completion %d)", attempt.Tokens, attempt.PromptTokens, attempt.CompletionTokens)
+	}
+	usage := models.Usage{PromptTokens: attempt.PromptTokens, CompletionTokens: attempt.CompletionTokens}
+	if want := current.Cost(usage); attempt.CostUSD != want {
+		t.Fatalf("cost = %v, want %v at the current price", attempt.CostUSD, want)
+	}
+	var meta providerMeta
+	if err := json.Unmarshal(attempt.ProviderMeta, &meta); err != nil {
+		t.Fatalf("decode provider meta %s: %v", attempt.ProviderMeta, err)
+	}
+	if meta.PriceEffectiveFrom == nil || !meta.PriceEffectiveFrom.Equal(current.EffectiveFrom) {
+		t.Fatalf("price_effective_from = %v, want %v", meta.PriceEffectiveFrom, current.EffectiveFrom)
+	}
+
+	history, err = h.store.ListJobAttempts(ctx, unpriced.ID)
+	if err != nil {
+		t.Fatalf("list attempts: %v", err)
+	}
+	if attempt := history.Attempts[0]; attempt.CostUSD != 0 || attempt.Tokens <= 0 {
+		t.Fatalf("unpriced attempt = %+v, want tokens and no cost", attempt)
+	}
+}
+
+func TestRunnerHoldsJobsOverSpendCap(t *testing.T) {
+	h := newRunnerHarness(t, nil)
+	h.runner.cfg.SpendHoldRecheck = time.Hour
+	zero := 0.0
+	if _, err := h.store.SetSpendCap(context.Background(), "acme", &zero); err != nil {
+		t.Fatalf("set cap: %v", err)
+	}
+	h.start()
+
+	held := h.createJob(models.CreateJobInput{PayloadJSON: json.RawMessage(`{"prompt":"hold me"}`)})
+	uncapped := h.createJob(models.CreateJobInput{TenantID: "globex", PayloadJSON: json.RawMessage(`{"prompt":"run me"}`)})
+	h.waitForStatus(uncapped.ID, models.JobStatusSucceeded)
+	job := h.waitForStatus(held.ID, models.JobStatusScheduled)
+
+	if job.Attempt != 0 || job.StartedAt != nil || job.ErrorCode != "SPEND_CAP_EXCEEDED" {
+		t.Fatalf("held job = attempt %d, started %v, error %q", job.Attempt, job.StartedAt, job.ErrorCode)
+	}
+	if job.RunAt == nil || time.Until(*job.RunAt) < 50*time.Minute {
+		t.Fatalf("run_at = %v, want about an hour from now", job.RunAt)
+	}
+	if _, ok := h.provider.payload(held.ID); ok {
+		t.Fatal("held job reached the provider")
+	}
+	history, err := h.store.ListJobAttempts(context.Background(), held.ID)
+	if err != nil || len(history.Attempts) != 0 {
+		t.Fatalf("attempts = %+v, %v; want none", history.Attempts, err)
+	}
+	if !h.hasEvent("job.held", held.ID, testWorkerID) {
+		t.Fatal("missing job.held event")
+	}
+}
+
+func TestRunnerFallsBackAlongTheChain(t *testing.T) {
+	// Only the last model of each chain answers.
+	h := newRunnerHarness(t, func(job models.Job) (json.RawMessage, error) {
+		switch job.ModelUsed {
+		case "gpt-4o-mini", "policy-backup":
+			return json.RawMessage(`{"text":"ok"}`), nil
+		case "rate-limited":
+			return nil, &jobError{code: "RATE_LIMITED", err: errors.New("slow down")}
+		}
+		return nil, errors.New("mock provider timeout")
+	})
+	ctx := context.Background()
+	if _, err := h.store.UpsertFallbackPolicy(ctx, models.FallbackPolicy{
+		TenantID:       "acme",
+		Model:          "policy-primary",
+		FallbackModels: []string{"policy-backup"},
+	}); err != nil {
+		t.Fatalf("upsert policy: %v", err)
+	}
+	h.start()
+
+	chained := h.createJob(models.CreateJobInput{FallbackModels: []string{"unreliable", "gpt-4o-mini"}})
+	viaPolicy := h.createJob(models.CreateJobInput{Model: "policy-primary"})
+	untriggered := h.createJob(models.CreateJobInput{
+		Model:          "rate-limited",
+		FallbackModels: []string{"gpt-4o-mini"},
+		FallbackOn:     []string{"PROVIDER_TIMEOUT"},
+	})
+
+	job := h.waitForStatus(chained.ID, models.JobStatusSucceeded)
+	if job.Model != "gpt-4.1-mini" || job.ModelUsed != "gpt-4o-mini" || job.Attempt != 3 {
+		t.Fatalf("job = model %s, model_used %s, attempt %d", job.Model, job.ModelUsed, job.Attempt)
+	}
+	history, err := h.store.ListJobAttempts(ctx, chained.ID)
+	if err != nil {
+		t.Fatalf("list attempts: %v", err)
+	}
+	var ran []string
+	for _, attempt := range history.Attempts {
+		ran = append(ran, attempt.Model)
+	}
+	if strings.Join(ran, ",") != "gpt-4.1-mini,unreliable,gpt-4o-mini" || history.Totals.Failed != 2 || history.Totals.Succeeded != 1 {
+		t.Fatalf("attempts ran on %v (%+v)", ran, history.Totals)
+	}
+	if history.Attempts[0].ErrorCode != "PROVIDER_TIMEOUT" {
+		t.Fatalf("first attempt error code = %q", history.Attempts[0].ErrorCode)
+	}
+	if !h.hasEvent("job.fallback", chained.ID, testWorkerID) {
+		t.Fatal("missing job.fallback event")
+	}
+
+	if job := h.waitForStatus(viaPolicy.ID, models.JobStatusSucceeded); job.ModelUsed != "policy-backup" {
+		t.Fatalf("policy job ran on %s, want policy-backup", job.ModelUsed)
+	}
+	if job := h.waitForStatus(untriggered.ID, models.JobStatusFailed); job.ModelUsed != "rate-limited" || job.Attempt != 1 {
+		t.Fatalf("untriggered job = model_used %s, attempt %d; want no fallback", job.ModelUsed, job.Attempt)
+	}
+}
+
+func TestRunnerHoldsJobsBehindOpenCircuit(t *testing.T) {
+	h := newRunnerHarness(t, func(job models.Job) (json.RawMessage, error) {
+		switch job.ModelUsed {
+		case "flaky":
+			return nil, &jobError{code: "PROVIDER_ERROR", err: errors.New("provider returned 503")}
+		case "shaky":
+			return nil, errors.New("mock provider timeout")
+		}
+		return json.RawMessage(`{"text":"ok"}`), nil
+	})
+	h.runner.breakers = breaker.New(breaker.NewMemoryBackend(), breaker.Config{
+		Window:      time.Minute,
+		MinRequests: 2,
+		ErrorRate:   0.5,
+		OpenFor:     time.Hour,
+	})
+	h.start()
+
+	// Two failures in a row open the breaker for "flaky".
+	for i := 0; i < 2; i++ {
+		job := h.createJob(models.CreateJobInput{Model: "flaky"})
+		h.waitForStatus(job.ID, models.JobStatusFailed)
+	}
+	held := h.createJob(models.CreateJobInput{Model: "flaky"})
+	job := h.waitForStatus(held.ID, models.JobStatusScheduled)
+	if job.Attempt != 0 || job.ErrorCode != "CIRCUIT_OPEN" {
+		t.Fatalf("held job = attempt %d, error %q", job.Attempt, job.ErrorCode)
+	}
+	if job.RunAt == nil || time.Until(*job.RunAt) < 50*time.Minute {
+		t.Fatalf("run_at = %v, want about an hour from now", job.RunAt)
+	}
+	if _, ok := h.provider.payload(held.ID); ok {
+		t.Fatal("held job reached the provider")
+	}
+	if !h.hasEvent("job.held", held.ID, testWorkerID) {
+		t.Fatal("missing job.held event")
+	}
+
+	// Fallbacks skip models whose circuit is open.
+	fallback := h.createJob(models.CreateJobInput{Model: "shaky", FallbackModels: []string{"flaky", "backup"}})
+	job = h.waitForStatus(fallback.ID, models.JobStatusSucceeded)
+	if job.ModelUsed != "backup" || job.Attempt != 2 {
+		t.Fatalf("fallback job = model_used %s, attempt %d; want backup on attempt 2", job.ModelUsed, job.Attempt)
+	}
+
+	breakers, err := h.runner.breakers.List(context.Background())
+	if err != nil {
+		t.Fatalf("list breakers: %v", err)
+	}
+	states := make(map[string]models.BreakerState)
+	for _, circuit := range breakers {
+		states[circuit.Model] = circuit.State
+	}
+	if states["flaky"] != models.BreakerOpen || states["shaky"] != models.BreakerClosed || states["backup"] != models.BreakerClosed {
+		t.Fatalf("breaker states = %v", states)
+	}
+	var opened int
+	for _, event := range h.store.Events() {
+		if event.Type == "circuit_breaker.open" {
+			opened++
+		}
+	}
+	if opened != 1 {
+		t.Fatalf("circuit_breaker.open events = %d, want 1", opened)
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS tenant_limits;
+DROP TABLE IF EXISTS workers;
+DROP TABLE IF EXISTS job_attempts;
+DROP TABLE IF EXISTS jobs;
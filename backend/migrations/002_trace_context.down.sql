@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_jobs_trace_id;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS tracestate;
+ALTER TABLE jobs DROP COLUMN IF EXISTS traceparent;
@@ -0,0 +1,4 @@
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS traceparent TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS tracestate TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_trace_id ON jobs (trace_id);
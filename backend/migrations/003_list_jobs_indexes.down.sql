@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS idx_jobs_status_created_at ON jobs (status, created_at DESC);
+
+DROP INDEX IF EXISTS idx_jobs_error_code;
+DROP INDEX IF EXISTS idx_jobs_finished_at;
+DROP INDEX IF EXISTS idx_jobs_priority_created_at_id;
+DROP INDEX IF EXISTS idx_jobs_status_created_at_id;
+DROP INDEX IF EXISTS idx_jobs_tenant_created_at_id;
+DROP INDEX IF EXISTS idx_jobs_created_at_id;
@@ -0,0 +1,9 @@
+-- Keyset pagination over (created_at, id) and the common list filters.
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at_id ON jobs (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_created_at_id ON jobs (tenant_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_created_at_id ON jobs (status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_priority_created_at_id ON jobs (priority DESC, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at ON jobs (finished_at DESC) WHERE finished_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_jobs_error_code ON jobs (error_code) WHERE error_code IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_jobs_status_created_at;
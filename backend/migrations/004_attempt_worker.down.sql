@@ -0,0 +1 @@
+ALTER TABLE job_attempts DROP COLUMN IF EXISTS worker_id;
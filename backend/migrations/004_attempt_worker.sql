@@ -0,0 +1 @@
+ALTER TABLE job_attempts ADD COLUMN IF NOT EXISTS worker_id TEXT;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_jobs_group_status;
+ALTER TABLE jobs DROP COLUMN IF EXISTS group_id;
+
+DROP TABLE IF EXISTS job_groups;
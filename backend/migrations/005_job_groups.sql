@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS job_groups (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    webhook_url TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ,
+    webhook_delivered_at TIMESTAMPTZ,
+    webhook_attempts INTEGER NOT NULL DEFAULT 0,
+    webhook_next_attempt_at TIMESTAMPTZ,
+    webhook_last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_groups_tenant_created_at ON job_groups (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_job_groups_webhook_pending
+ON job_groups (webhook_next_attempt_at)
+WHERE completed_at IS NOT NULL AND webhook_url IS NOT NULL AND webhook_delivered_at IS NULL;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS group_id TEXT REFERENCES job_groups(id);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_group_status ON jobs (group_id, status) WHERE group_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Fails while any job is still blocked; cancel or finish those first.
+DROP TABLE IF EXISTS job_dependencies;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS result_json;
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'retry_scheduled', 'dlq', 'cancelled'));
@@ -0,0 +1,14 @@
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'retry_scheduled', 'dlq', 'cancelled', 'blocked'));
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS result_json JSONB;
+
+CREATE TABLE IF NOT EXISTS job_dependencies (
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    depends_on_job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (job_id, depends_on_job_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_dependencies_parent ON job_dependencies (depends_on_job_id);
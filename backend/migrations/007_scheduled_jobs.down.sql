@@ -0,0 +1,7 @@
+-- Fails while any job is still scheduled; promote or cancel those first.
+DROP INDEX IF EXISTS idx_jobs_scheduled_run_at;
+ALTER TABLE jobs DROP COLUMN IF EXISTS run_at;
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'retry_scheduled', 'dlq', 'cancelled', 'blocked'));
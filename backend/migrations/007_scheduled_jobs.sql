@@ -0,0 +1,7 @@
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'retry_scheduled', 'dlq', 'cancelled', 'blocked', 'scheduled'));
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS run_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_scheduled_run_at ON jobs (run_at) WHERE status = 'scheduled';
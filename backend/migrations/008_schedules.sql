@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    cron_expr TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    model TEXT NOT NULL,
+    payload_json JSONB NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 3,
+    max_attempts INTEGER NOT NULL DEFAULT 3,
+    overlap_policy TEXT NOT NULL DEFAULT 'skip' CHECK (overlap_policy IN ('skip', 'queue', 'allow')),
+    paused BOOLEAN NOT NULL DEFAULT false,
+    next_fire_at TIMESTAMPTZ,
+    last_fired_at TIMESTAMPTZ,
+    last_job_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_tenant ON schedules (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules (next_fire_at) WHERE NOT paused;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_jobs_pending_deadline;
+ALTER TABLE jobs DROP COLUMN IF EXISTS deadline;
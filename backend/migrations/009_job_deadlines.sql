@@ -0,0 +1,4 @@
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS deadline TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_pending_deadline ON jobs (deadline)
+WHERE deadline IS NOT NULL AND status IN ('queued', 'scheduled', 'blocked', 'retry_scheduled');
@@ -0,0 +1,2 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS idempotency_expires_at;
+ALTER TABLE jobs DROP COLUMN IF EXISTS idempotency_fingerprint;
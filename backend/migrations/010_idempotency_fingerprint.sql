@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS idempotency_fingerprint TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS idempotency_expires_at TIMESTAMPTZ;
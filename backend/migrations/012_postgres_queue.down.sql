@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS queue_leases;
+DROP TABLE IF EXISTS queue_jobs;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS queue_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    job_id TEXT NOT NULL,
+    enqueued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    claimed_until TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_queue_jobs_job_id ON queue_jobs (job_id);
+
+CREATE TABLE IF NOT EXISTS queue_leases (
+    job_id TEXT PRIMARY KEY,
+    worker_id TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,26 @@
+ALTER TABLE events RENAME TO events_partitioned;
+ALTER TABLE events_partitioned RENAME CONSTRAINT events_pkey TO events_partitioned_pkey;
+ALTER INDEX idx_events_created_at RENAME TO idx_events_partitioned_created_at;
+ALTER INDEX idx_events_job_id RENAME TO idx_events_partitioned_job_id;
+
+CREATE TABLE events (
+    id BIGINT PRIMARY KEY DEFAULT nextval('events_id_seq'),
+    event_type TEXT NOT NULL,
+    job_id TEXT,
+    worker_id TEXT,
+    details TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER SEQUENCE events_id_seq OWNED BY events.id;
+
+CREATE INDEX idx_events_created_at ON events (created_at DESC);
+CREATE INDEX idx_events_job_id ON events (job_id);
+
+INSERT INTO events (id, event_type, job_id, worker_id, details, created_at)
+SELECT id, event_type, job_id, worker_id, details, created_at FROM events_partitioned;
+
+DROP TABLE events_partitioned;
+
+DROP INDEX IF EXISTS idx_jobs_finished_at_id;
+DROP TABLE IF EXISTS retention_policies;
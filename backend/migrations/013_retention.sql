@@ -0,0 +1,58 @@
+CREATE TABLE IF NOT EXISTS retention_policies (
+    tenant_id TEXT PRIMARY KEY,
+    jobs_retention_days INTEGER NOT NULL CHECK (jobs_retention_days >= 0),
+    archive BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at_id ON jobs (finished_at, id) WHERE finished_at IS NOT NULL;
+
+-- Rebuild events as a table partitioned by month (UTC). Partitions are named
+-- events_YYYY_MM; the worker's archiver creates upcoming ones and drops the
+-- ones past EVENTS_RETENTION_MONTHS.
+ALTER TABLE events RENAME TO events_unpartitioned;
+ALTER TABLE events_unpartitioned RENAME CONSTRAINT events_pkey TO events_unpartitioned_pkey;
+ALTER INDEX idx_events_created_at RENAME TO idx_events_unpartitioned_created_at;
+ALTER INDEX idx_events_job_id RENAME TO idx_events_unpartitioned_job_id;
+
+CREATE TABLE events (
+    id BIGINT NOT NULL DEFAULT nextval('events_id_seq'),
+    event_type TEXT NOT NULL,
+    job_id TEXT,
+    worker_id TEXT,
+    details TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+ALTER SEQUENCE events_id_seq OWNED BY events.id;
+
+CREATE INDEX idx_events_created_at ON events (created_at DESC);
+CREATE INDEX idx_events_job_id ON events (job_id);
+
+DO $$
+DECLARE
+    month_start TIMESTAMP;
+    last_month TIMESTAMP;
+BEGIN
+    SELECT
+        date_trunc('month', COALESCE(MIN(created_at), now()) AT TIME ZONE 'UTC'),
+        date_trunc('month', GREATEST(COALESCE(MAX(created_at), now()), now() + interval '2 months') AT TIME ZONE 'UTC')
+    INTO month_start, last_month
+    FROM events_unpartitioned;
+
+    WHILE month_start <= last_month LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF events FOR VALUES FROM (%L) TO (%L)',
+            'events_' || to_char(month_start, 'YYYY_MM'),
+            month_start AT TIME ZONE 'UTC',
+            (month_start + interval '1 month') AT TIME ZONE 'UTC'
+        );
+        month_start := month_start + interval '1 month';
+    END LOOP;
+END $$;
+
+INSERT INTO events (id, event_type, job_id, worker_id, details, created_at)
+SELECT id, event_type, job_id, worker_id, details, created_at FROM events_unpartitioned;
+
+DROP TABLE events_unpartitioned;
@@ -0,0 +1,3 @@
+-- Keys issued before roles existed keep the access they had: tenant keys
+-- could submit and manage their tenant's jobs.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'tenant-user';
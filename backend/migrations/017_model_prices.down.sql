@@ -0,0 +1,6 @@
+ALTER TABLE job_attempts
+    DROP COLUMN IF EXISTS completion_tokens,
+    DROP COLUMN IF EXISTS cached_prompt_tokens,
+    DROP COLUMN IF EXISTS prompt_tokens;
+
+DROP TABLE IF EXISTS model_prices;
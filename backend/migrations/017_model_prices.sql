@@ -0,0 +1,24 @@
+-- Prices are effective-dated: an attempt is charged the price of its model
+-- with the latest effective_from at or before the attempt started, and the
+-- resulting cost is stored on the attempt.
+CREATE TABLE IF NOT EXISTS model_prices (
+    model TEXT NOT NULL,
+    effective_from TIMESTAMPTZ NOT NULL,
+    input_per_1k_usd NUMERIC(12, 8) NOT NULL CHECK (input_per_1k_usd >= 0),
+    cached_input_per_1k_usd NUMERIC(12, 8) NOT NULL CHECK (cached_input_per_1k_usd >= 0),
+    output_per_1k_usd NUMERIC(12, 8) NOT NULL CHECK (output_per_1k_usd >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (model, effective_from)
+);
+
+INSERT INTO model_prices (model, effective_from, input_per_1k_usd, cached_input_per_1k_usd, output_per_1k_usd)
+VALUES
+    ('gpt-4o-mini', '2024-01-01T00:00:00Z', 0.00015, 0.000075, 0.0006),
+    ('gpt-4.1-mini', '2024-01-01T00:00:00Z', 0.0004, 0.0001, 0.0016)
+ON CONFLICT DO NOTHING;
+
+-- tokens stays the billed total of prompt and completion tokens.
+ALTER TABLE job_attempts
+    ADD COLUMN IF NOT EXISTS prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cached_prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS completion_tokens INTEGER NOT NULL DEFAULT 0;
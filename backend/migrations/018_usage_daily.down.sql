@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_job_attempts_finished_at;
+DROP TABLE IF EXISTS usage_rollup_state;
+DROP TABLE IF EXISTS usage_daily;
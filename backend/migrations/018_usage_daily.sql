@@ -0,0 +1,35 @@
+-- usage_daily holds finished attempts summed per UTC day (by finished_at),
+-- tenant and model. The worker's usage rollup fills in each day once it has
+-- ended; days from usage_rollup_state.rolled_up_to onwards are aggregated
+-- from job_attempts when queried. Rolled-up days outlive the jobs retention
+-- deletes.
+CREATE TABLE IF NOT EXISTS usage_daily (
+    day DATE NOT NULL,
+    tenant_id TEXT NOT NULL,
+    model TEXT NOT NULL,
+    attempts BIGINT NOT NULL,
+    failed_attempts BIGINT NOT NULL,
+    prompt_tokens BIGINT NOT NULL,
+    cached_prompt_tokens BIGINT NOT NULL,
+    completion_tokens BIGINT NOT NULL,
+    tokens BIGINT NOT NULL,
+    cost_usd DOUBLE PRECISION NOT NULL,
+    failed_cost_usd DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (day, tenant_id, model)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_daily_tenant_day ON usage_daily (tenant_id, day);
+
+-- A single row: usage_daily is complete for every day before rolled_up_to.
+CREATE TABLE IF NOT EXISTS usage_rollup_state (
+    singleton BOOLEAN PRIMARY KEY DEFAULT true CHECK (singleton),
+    rolled_up_to DATE NOT NULL
+);
+
+-- The rollup backfills existing attempts starting from the oldest one.
+INSERT INTO usage_rollup_state (rolled_up_to)
+SELECT COALESCE(min((finished_at AT TIME ZONE 'UTC')::date), (now() AT TIME ZONE 'UTC')::date)
+FROM job_attempts
+ON CONFLICT DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_job_attempts_finished_at ON job_attempts (finished_at) WHERE finished_at IS NOT NULL;
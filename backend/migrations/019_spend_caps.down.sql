@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS tenant_spend;
+
+DELETE FROM tenant_limits WHERE concurrency IS NULL OR rps IS NULL OR token_budget_per_min IS NULL;
+ALTER TABLE tenant_limits
+    DROP COLUMN IF EXISTS spend_cap_override_until,
+    DROP COLUMN IF EXISTS spend_cap_override_usd,
+    DROP COLUMN IF EXISTS monthly_spend_cap_usd,
+    ALTER COLUMN concurrency SET NOT NULL,
+    ALTER COLUMN rps SET NOT NULL,
+    ALTER COLUMN token_budget_per_min SET NOT NULL;
@@ -0,0 +1,29 @@
+-- Spend caps live with the other per-tenant limits. The older limit columns
+-- become optional so a tenant can have a cap and nothing else.
+ALTER TABLE tenant_limits
+    ALTER COLUMN concurrency DROP NOT NULL,
+    ALTER COLUMN rps DROP NOT NULL,
+    ALTER COLUMN token_budget_per_min DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS monthly_spend_cap_usd DOUBLE PRECISION CHECK (monthly_spend_cap_usd >= 0),
+    ADD COLUMN IF NOT EXISTS spend_cap_override_usd DOUBLE PRECISION CHECK (spend_cap_override_usd >= 0),
+    ADD COLUMN IF NOT EXISTS spend_cap_override_until TIMESTAMPTZ;
+
+-- Spend per tenant and UTC month, added to as attempts finish. The alert
+-- timestamps make each threshold fire once per month and cap.
+CREATE TABLE IF NOT EXISTS tenant_spend (
+    tenant_id TEXT NOT NULL,
+    month DATE NOT NULL,
+    spent_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    soft_alerted_at TIMESTAMPTZ,
+    cap_reached_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, month)
+);
+
+INSERT INTO tenant_spend (tenant_id, month, spent_usd)
+SELECT j.tenant_id, date_trunc('month', now() AT TIME ZONE 'UTC')::date, sum(a.cost_usd)
+FROM job_attempts a
+JOIN jobs j ON j.id = a.job_id
+WHERE a.finished_at >= date_trunc('month', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+GROUP BY j.tenant_id
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS model_fallback_policies;
+
+ALTER TABLE job_attempts DROP COLUMN IF EXISTS model;
+
+ALTER TABLE jobs
+    DROP COLUMN IF EXISTS model_used,
+    DROP COLUMN IF EXISTS fallback_on,
+    DROP COLUMN IF EXISTS fallback_models;
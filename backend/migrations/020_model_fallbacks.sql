@@ -0,0 +1,23 @@
+-- A job may carry its own fallback chain; model stays the requested model and
+-- model_used records the model of the latest attempt.
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS fallback_models TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS fallback_on TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS model_used TEXT;
+
+UPDATE jobs SET model_used = model WHERE attempt > 0 AND model_used IS NULL;
+
+ALTER TABLE job_attempts ADD COLUMN IF NOT EXISTS model TEXT;
+
+UPDATE job_attempts a SET model = j.model FROM jobs j WHERE j.id = a.job_id AND a.model IS NULL;
+
+-- Tenant policies apply to jobs of the tenant that request model and carry no
+-- chain of their own.
+CREATE TABLE IF NOT EXISTS model_fallback_policies (
+    tenant_id TEXT NOT NULL,
+    model TEXT NOT NULL,
+    fallback_models TEXT[] NOT NULL,
+    fallback_on TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, model)
+);
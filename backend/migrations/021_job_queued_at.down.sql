@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS jobs_queued_at ON jobs;
+DROP FUNCTION IF EXISTS jobs_set_queued_at();
+ALTER TABLE jobs DROP COLUMN IF EXISTS queued_at;
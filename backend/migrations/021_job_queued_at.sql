@@ -0,0 +1,21 @@
+-- queued_at is when a job last became runnable: created, promoted from
+-- scheduled, unblocked or retried. The trigger keeps it current on every path
+-- that moves a job to 'queued'.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS queued_at TIMESTAMPTZ;
+
+UPDATE jobs SET queued_at = GREATEST(created_at, COALESCE(run_at, created_at))
+WHERE status = 'queued' AND queued_at IS NULL;
+
+CREATE OR REPLACE FUNCTION jobs_set_queued_at() RETURNS trigger AS $$
+BEGIN
+    IF NEW.status = 'queued' AND (TG_OP = 'INSERT' OR OLD.status IS DISTINCT FROM 'queued') THEN
+        NEW.queued_at := now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS jobs_queued_at ON jobs;
+CREATE TRIGGER jobs_queued_at
+    BEFORE INSERT OR UPDATE OF status ON jobs
+    FOR EACH ROW EXECUTE FUNCTION jobs_set_queued_at();
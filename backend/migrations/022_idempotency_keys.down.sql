@@ -0,0 +1,11 @@
+-- Only the job currently holding a key keeps it, so the unique index can be
+-- rebuilt.
+UPDATE jobs
+SET idempotency_key = NULL
+WHERE idempotency_key IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM idempotency_keys WHERE idempotency_keys.job_id = jobs.id);
+
+DROP INDEX IF EXISTS idx_jobs_tenant_idempotency_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_tenant_idempotency ON jobs (tenant_id, idempotency_key);
+
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,24 @@
+-- Idempotency keys are reserved here rather than through a unique index on
+-- jobs, so an expired key can be handed to a new job while the old job keeps
+-- the key it was created with.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    tenant_id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    -- Deferred so a key can be claimed before the job that holds it is
+    -- inserted in the same transaction.
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+    fingerprint TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    PRIMARY KEY (tenant_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_job_id ON idempotency_keys (job_id);
+
+INSERT INTO idempotency_keys (tenant_id, key, job_id, fingerprint, expires_at)
+SELECT tenant_id, idempotency_key, id, COALESCE(idempotency_fingerprint, ''), idempotency_expires_at
+FROM jobs
+WHERE idempotency_key IS NOT NULL
+ON CONFLICT (tenant_id, key) DO NOTHING;
+
+DROP INDEX IF EXISTS idx_jobs_tenant_idempotency;
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_idempotency_key ON jobs (tenant_id, idempotency_key);
@@ -0,0 +1,10 @@
+package migrations
+
+import "embed"
+
+// Files holds the NNN_name.sql up migrations and their NNN_name.down.sql
+// counterparts, so cmd/migrate and the startup schema check ship with the SQL
+// they were built against.
+//
+//go:embed *.sql
+var Files embed.FS
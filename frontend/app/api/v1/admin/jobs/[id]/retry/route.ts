@@ -1,5 +1,5 @@
 import { NextResponse } from "next/server";
-import { getBackendBaseURL } from "@/lib/backend-url";
+import { getBackendAuthHeaders, getBackendBaseURL } from "@/lib/backend-url";
 
 const toErrorResponse = (message: string, status = 502): NextResponse =>
   NextResponse.json(
@@ -21,7 +21,8 @@ export async function POST(
     const response = await fetch(backendURL, {
       method: "POST",
       headers: {
-        Accept: "application/json"
+        Accept: "application/json",
+        ...getBackendAuthHeaders()
       }
     });
 
@@ -1,5 +1,5 @@
 import { NextRequest, NextResponse } from "next/server";
-import { getBackendBaseURL } from "@/lib/backend-url";
+import { getBackendAuthHeaders, getBackendBaseURL } from "@/lib/backend-url";
 
 const toErrorResponse = (message: string, status = 502): NextResponse =>
   NextResponse.json(
@@ -17,7 +17,8 @@ export async function GET(request: NextRequest): Promise<NextResponse> {
     const response = await fetch(backendURL, {
       method: "GET",
       headers: {
-        Accept: "application/json"
+        Accept: "application/json",
+        ...getBackendAuthHeaders()
       },
       cache: "no-store"
     });
@@ -45,7 +46,8 @@ export async function POST(request: NextRequest): Promise<NextResponse> {
       method: "POST",
       headers: {
         "Content-Type": request.headers.get("Content-Type") ?? "application/json",
-        ...(idempotencyKey ? { "Idempotency-Key": idempotencyKey } : {})
+        ...(idempotencyKey ? { "Idempotency-Key": idempotencyKey } : {}),
+        ...getBackendAuthHeaders()
       },
       body
     });
@@ -1,2 +1,9 @@
 export const getBackendBaseURL = (): string =>
   process.env.BACKEND_URL?.replace(/\/+$/, "") || "http://localhost:8080";
+
+// Server-side only: the key never reaches the browser, the API routes attach it
+// to every call they proxy to the backend.
+export const getBackendAuthHeaders = (): Record<string, string> => {
+  const apiKey = process.env.BACKEND_API_KEY?.trim();
+  return apiKey ? { "X-API-Key": apiKey } : {};
+};